@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// maxAnalyzeLineLength is the longest line --analyze-file accepts.
+const maxAnalyzeLineLength = 1024 * 1024
+
+// analysisResult is the strength of the password on one line of the input.
+// The password itself is never echoed, only its line number.
+type analysisResult struct {
+	Line    int     `json:"line"`
+	Level   string  `json:"level"`
+	Score   int     `json:"score"`
+	Entropy float64 `json:"entropy"`
+}
+
+type analysisSummary struct {
+	Total        int     `json:"total"`
+	WeakOrBelow  int     `json:"weak_or_below"`
+	AverageScore float64 `json:"average_score"`
+	EntropyModel string  `json:"entropy_model"`
+}
+
+// analysisWriter writes one result at a time so large files are streamed
+// instead of held in memory.
+type analysisWriter interface {
+	write(result analysisResult) error
+	finish(summary analysisSummary) error
+}
+
+// analyzePasswords reads passwords from r, one per line, and writes the
+// strength of each to w in the given format, followed by a summary. Blank
+// lines are skipped but still counted, so line numbers match the input.
+// analyzer scores each password, and entropyModel is the model it was
+// configured with, for the summary.
+func analyzePasswords(r io.Reader, w io.Writer, format string, analyzer pwgen.Analyzer, entropyModel string) (analysisSummary, error) {
+	var output analysisWriter
+	switch format {
+	case "csv":
+		output = newCSVAnalysisWriter(w)
+	case "json":
+		output = &jsonAnalysisWriter{w: w}
+	default:
+		output = &textAnalysisWriter{w: w}
+	}
+
+	summary := analysisSummary{EntropyModel: entropyModel}
+	if summary.EntropyModel == "" {
+		summary.EntropyModel = pwgen.EntropyModelUniform
+	}
+	totalScore := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxAnalyzeLineLength)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		password := strings.TrimSuffix(scanner.Text(), "\r")
+		if password == "" {
+			continue
+		}
+
+		strength := analyzer.Analyze(password)
+		summary.Total++
+		totalScore += strength.Score
+		if strength.Level <= pwgen.Weak {
+			summary.WeakOrBelow++
+		}
+
+		if err := output.write(analysisResult{
+			Line:    line,
+			Level:   strength.Level.String(),
+			Score:   strength.Score,
+			Entropy: strength.Entropy,
+		}); err != nil {
+			return summary, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return summary, fmt.Errorf("line %d: %w", line+1, err)
+	}
+
+	if summary.Total > 0 {
+		summary.AverageScore = float64(totalScore) / float64(summary.Total)
+	}
+
+	return summary, output.finish(summary)
+}
+
+type textAnalysisWriter struct {
+	w      io.Writer
+	header bool
+}
+
+func (o *textAnalysisWriter) write(result analysisResult) error {
+	if !o.header {
+		o.header = true
+		if _, err := fmt.Fprintf(o.w, "%6s  %-11s  %5s  %7s\n", "Line", "Level", "Score", "Entropy"); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(o.w, "%6d  %-11s  %5d  %7.1f\n", result.Line, result.Level, result.Score, result.Entropy)
+	return err
+}
+
+func (o *textAnalysisWriter) finish(summary analysisSummary) error {
+	if summary.Total == 0 {
+		_, err := fmt.Fprintln(o.w, "No passwords to analyze")
+		return err
+	}
+
+	_, err := fmt.Fprintf(o.w, "\nAnalyzed %d passwords: %d Weak or below (%.0f%%), average score %.1f/100\n",
+		summary.Total,
+		summary.WeakOrBelow,
+		100*float64(summary.WeakOrBelow)/float64(summary.Total),
+		summary.AverageScore,
+	)
+	if err == nil && entropyModelLabel(summary.EntropyModel) != "" {
+		_, err = fmt.Fprintf(o.w, "Entropy model: %s\n", summary.EntropyModel)
+	}
+	return err
+}
+
+type csvAnalysisWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+func newCSVAnalysisWriter(w io.Writer) *csvAnalysisWriter {
+	return &csvAnalysisWriter{writer: csv.NewWriter(w)}
+}
+
+// write leaves the summary out so the output stays one row per password.
+func (o *csvAnalysisWriter) write(result analysisResult) error {
+	if !o.header {
+		o.header = true
+		if err := o.writer.Write([]string{"line", "level", "score", "entropy"}); err != nil {
+			return err
+		}
+	}
+
+	return o.writer.Write([]string{
+		strconv.Itoa(result.Line),
+		result.Level,
+		strconv.Itoa(result.Score),
+		strconv.FormatFloat(result.Entropy, 'f', 1, 64),
+	})
+}
+
+func (o *csvAnalysisWriter) finish(analysisSummary) error {
+	o.writer.Flush()
+	return o.writer.Error()
+}
+
+// jsonAnalysisWriter writes {"results": [...], "summary": {...}}, emitting
+// each result as soon as it is analyzed.
+type jsonAnalysisWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (o *jsonAnalysisWriter) write(result analysisResult) error {
+	prefix := ",\n    "
+	if o.count == 0 {
+		prefix = "{\n  \"results\": [\n    "
+	}
+	o.count++
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(o.w, "%s%s", prefix, data)
+	return err
+}
+
+func (o *jsonAnalysisWriter) finish(summary analysisSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	if o.count == 0 {
+		_, err = fmt.Fprintf(o.w, "{\n  \"results\": [],\n  \"summary\": %s\n}\n", data)
+	} else {
+		_, err = fmt.Fprintf(o.w, "\n  ],\n  \"summary\": %s\n}\n", data)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+const analyzeInput = "password\nC0mpl3x!P@ssw0rd#2024\n\nabc\r\n"
+
+func TestAnalyzePasswordsSummary(t *testing.T) {
+	var buf bytes.Buffer
+	summary, err := analyzePasswords(strings.NewReader(analyzeInput), &buf, "text", pwgen.BuiltinAnalyzer{}, "")
+	if err != nil {
+		t.Fatalf("analyzePasswords() error = %v", err)
+	}
+
+	if summary.Total != 3 {
+		t.Errorf("analyzePasswords() Total = %d, want 3 (blank lines skipped)", summary.Total)
+	}
+	if summary.WeakOrBelow != 2 {
+		t.Errorf("analyzePasswords() WeakOrBelow = %d, want 2", summary.WeakOrBelow)
+	}
+
+	output := buf.String()
+	if strings.Contains(output, "C0mpl3x") || strings.Contains(output, "abc") {
+		t.Errorf("analyzePasswords() output should not echo passwords:\n%s", output)
+	}
+	if !strings.Contains(output, "Analyzed 3 passwords: 2 Weak or below") {
+		t.Errorf("analyzePasswords() output is missing the summary:\n%s", output)
+	}
+}
+
+func TestAnalyzePasswordsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := analyzePasswords(strings.NewReader(analyzeInput), &buf, "json", pwgen.BuiltinAnalyzer{}, ""); err != nil {
+		t.Fatalf("analyzePasswords() error = %v", err)
+	}
+
+	var report struct {
+		Results []analysisResult `json:"results"`
+		Summary analysisSummary  `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("analyzePasswords() produced invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	// Line numbers count the skipped blank line
+	var lines []int
+	for _, result := range report.Results {
+		lines = append(lines, result.Line)
+	}
+	if len(lines) != 3 || lines[0] != 1 || lines[1] != 2 || lines[2] != 4 {
+		t.Errorf("analyzePasswords() lines = %v, want [1 2 4]", lines)
+	}
+	if report.Summary.Total != 3 {
+		t.Errorf("analyzePasswords() summary total = %d, want 3", report.Summary.Total)
+	}
+
+	buf.Reset()
+	if _, err := analyzePasswords(strings.NewReader(""), &buf, "json", pwgen.BuiltinAnalyzer{}, ""); err != nil {
+		t.Fatalf("analyzePasswords() error = %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Errorf("analyzePasswords() produced invalid JSON for empty input: %v\n%s", err, buf.String())
+	}
+}
+
+func TestAnalyzePasswordsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := analyzePasswords(strings.NewReader(analyzeInput), &buf, "csv", pwgen.BuiltinAnalyzer{}, ""); err != nil {
+		t.Fatalf("analyzePasswords() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("analyzePasswords() produced invalid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("analyzePasswords() wrote %d CSV rows, want a header and 3 rows", len(records))
+	}
+	if strings.Join(records[0], ",") != "line,level,score,entropy" {
+		t.Errorf("analyzePasswords() header = %v", records[0])
+	}
+}
+
+func TestAnalyzePasswordsLongLine(t *testing.T) {
+	input := strings.Repeat("a", maxAnalyzeLineLength+1) + "\n"
+	if _, err := analyzePasswords(strings.NewReader(input), &bytes.Buffer{}, "text", pwgen.BuiltinAnalyzer{}, ""); err == nil {
+		t.Error("analyzePasswords() should fail on a line longer than the limit")
+	}
+}
+
+func TestAnalyzePasswordsAnalyzer(t *testing.T) {
+	// Every password goes to the analyzer given, not the built-in scorer
+	calls := 0
+	stub := pwgen.AnalyzerFunc(func(password string) pwgen.PasswordStrength {
+		calls++
+		return pwgen.PasswordStrength{Score: 90, Level: pwgen.VeryStrong, Entropy: 128}
+	})
+
+	var buf bytes.Buffer
+	summary, err := analyzePasswords(strings.NewReader(analyzeInput), &buf, "text", stub, pwgen.EntropyModelUniform)
+	if err != nil {
+		t.Fatalf("analyzePasswords() error = %v", err)
+	}
+	if calls != 3 || summary.AverageScore != 90 || summary.WeakOrBelow != 0 {
+		t.Errorf("analyzePasswords() = %+v after %d calls, want the stub's scores for 3 passwords", summary, calls)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// annotateLimit is the largest --count --annotate accepts. Annotated
+// passwords are for reading, not for bulk output.
+const annotateLimit = 20
+
+// annotatedClasses are the classes --annotate tells apart, in legend order.
+// pwgen.ClassOther is left out, as a generated password only holds
+// characters of the charset it was drawn from.
+var annotatedClasses = []pwgen.CharClass{
+	pwgen.ClassLower,
+	pwgen.ClassUpper,
+	pwgen.ClassDigit,
+	pwgen.ClassSymbol,
+}
+
+// classColor returns the ANSI color --annotate shows class in.
+func classColor(class pwgen.CharClass) string {
+	switch class {
+	case pwgen.ClassLower:
+		return "\033[94m" // Blue
+	case pwgen.ClassUpper:
+		return "\033[95m" // Magenta
+	case pwgen.ClassDigit:
+		return "\033[93m" // Yellow
+	case pwgen.ClassSymbol:
+		return "\033[92m" // Green
+	default:
+		return "\033[91m" // Red
+	}
+}
+
+// classLetter returns the letter --annotate marks class with when color is
+// off: the initial of its name, or "?" for a character outside the charset.
+func classLetter(class pwgen.CharClass) byte {
+	if class == pwgen.ClassOther {
+		return '?'
+	}
+	return class.String()[0]
+}
+
+// annotateLegend returns the line printed above --annotate output, naming
+// each class in its color, or with its letter when color is off.
+func annotateLegend(color bool) string {
+	names := make([]string, len(annotatedClasses))
+	for i, class := range annotatedClasses {
+		if color {
+			names[i] = classColor(class) + class.String() + colorReset
+		} else {
+			names[i] = string(classLetter(class)) + "=" + class.String()
+		}
+	}
+	return "Classes: " + strings.Join(names, " ")
+}
+
+// colorClasses returns password with each character in the color of its
+// class within charset, see pwgen.CharClasses. The color only changes
+// between characters of different classes.
+func colorClasses(password, charset string) string {
+	var colored strings.Builder
+	classes := pwgen.CharClasses(password, charset)
+	for i, char := range []rune(password) {
+		if i == 0 || classes[i] != classes[i-1] {
+			colored.WriteString(classColor(classes[i]))
+		}
+		colored.WriteRune(char)
+	}
+	colored.WriteString(colorReset)
+	return colored.String()
+}
+
+// classLetters returns the letter of the class of each character of
+// password within charset, to print beneath it when color is off.
+func classLetters(password, charset string) string {
+	classes := pwgen.CharClasses(password, charset)
+	letters := make([]byte, len(classes))
+	for i, class := range classes {
+		letters[i] = classLetter(class)
+	}
+	return string(letters)
+}
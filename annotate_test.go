@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassLetters(t *testing.T) {
+	if got := classLetters("aB3$-", "aB3$"); got != "luds?" {
+		t.Errorf("classLetters() = %q, want %q", got, "luds?")
+	}
+	if got := classLetters("Tree-9", ""); got != "ulllsd" {
+		t.Errorf("classLetters() without a charset = %q, want %q", got, "ulllsd")
+	}
+}
+
+func TestColorClasses(t *testing.T) {
+	got := colorClasses("ab1", "")
+	want := "\033[94mab\033[93m1" + colorReset
+	if got != want {
+		t.Errorf("colorClasses() = %q, want %q", got, want)
+	}
+}
+
+func TestAnnotateLegend(t *testing.T) {
+	if got := annotateLegend(false); got != "Classes: l=lowercase u=uppercase d=digit s=symbol" {
+		t.Errorf("annotateLegend() without color = %q", got)
+	}
+	if got := annotateLegend(true); !strings.Contains(got, "\033[94mlowercase"+colorReset) {
+		t.Errorf("annotateLegend() with color = %q, want each class in its color", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+type clipboardCommand struct {
+	name string
+	args []string
+}
+
+// clipboardCommands lists the clipboard utilities to try for the given
+// operating system, in order of preference.
+func clipboardCommands(goos string) []clipboardCommand {
+	switch goos {
+	case "darwin":
+		return []clipboardCommand{{name: "pbcopy"}}
+	case "windows":
+		return []clipboardCommand{{name: "clip.exe"}}
+	default:
+		return []clipboardCommand{
+			{name: "wl-copy"},
+			{name: "xclip", args: []string{"-selection", "clipboard"}},
+			{name: "xsel", args: []string{"--clipboard", "--input"}},
+		}
+	}
+}
+
+// findClipboardCommand returns the first clipboard utility available on PATH.
+func findClipboardCommand() (clipboardCommand, error) {
+	candidates := clipboardCommands(runtime.GOOS)
+
+	var names []string
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate.name); err == nil {
+			return candidate, nil
+		}
+		names = append(names, candidate.name)
+	}
+
+	return clipboardCommand{}, fmt.Errorf("no clipboard utility found (tried %s)", strings.Join(names, ", "))
+}
+
+func copyToClipboard(command clipboardCommand, text string) error {
+	cmd := exec.Command(command.name, command.args...)
+	cmd.Stdin = strings.NewReader(text)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", command.name, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
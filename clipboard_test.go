@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClipboardCommands(t *testing.T) {
+	tests := []struct {
+		goos  string
+		first string
+	}{
+		{"darwin", "pbcopy"},
+		{"windows", "clip.exe"},
+		{"linux", "wl-copy"},
+		{"freebsd", "wl-copy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			commands := clipboardCommands(tt.goos)
+			if len(commands) == 0 {
+				t.Fatalf("clipboardCommands(%q) returned no commands", tt.goos)
+			}
+
+			if commands[0].name != tt.first {
+				t.Errorf("clipboardCommands(%q)[0] = %s, want %s", tt.goos, commands[0].name, tt.first)
+			}
+		})
+	}
+}
+
+func TestFindClipboardCommandUnavailable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if _, err := findClipboardCommand(); err == nil {
+		t.Error("findClipboardCommand() should return error when no clipboard utility is on PATH")
+	}
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "clipboard")
+	command := clipboardCommand{name: "sh", args: []string{"-c", "cat > " + output}}
+
+	if err := copyToClipboard(command, "s3cr3t"); err != nil {
+		t.Fatalf("copyToClipboard() error = %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read clipboard output: %v", err)
+	}
+
+	if string(content) != "s3cr3t" {
+		t.Errorf("copyToClipboard() wrote %q, want %q", content, "s3cr3t")
+	}
+}
+
+func TestCopyToClipboardError(t *testing.T) {
+	command := clipboardCommand{name: "sh", args: []string{"-c", "exit 1"}}
+
+	if err := copyToClipboard(command, "s3cr3t"); err == nil {
+		t.Error("copyToClipboard() should return error when the utility fails")
+	}
+}
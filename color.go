@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+	"golang.org/x/term"
+)
+
+const colorReset = "\033[0m"
+
+// colorEnabled reports whether output should use ANSI colors. Colors are
+// off when --no-color is given, when NO_COLOR is set to any non-empty
+// value (https://no-color.org), or when stdout is not a terminal.
+func colorEnabled(noColor bool) bool {
+	return shouldColor(noColor, os.Getenv("NO_COLOR"), term.IsTerminal(int(os.Stdout.Fd())))
+}
+
+func shouldColor(noColor bool, noColorEnv string, isTerminal bool) bool {
+	return !noColor && noColorEnv == "" && isTerminal
+}
+
+// formatLevel returns the strength level's name, wrapped in its color when
+// color is enabled.
+func formatLevel(level pwgen.StrengthLevel, color bool) string {
+	if !color {
+		return level.String()
+	}
+	return level.Color() + level.String() + colorReset
+}
+
+// barWidth is the number of cells in a --bar strength bar, each worth 10
+// points of the score.
+const barWidth = 10
+
+// formatBar renders score out of 100 as a bar like "████████░░ 80/100",
+// its filled cells in the color of level when color is enabled. The score
+// is rounded to the nearest cell.
+func formatBar(score int, level pwgen.StrengthLevel, color bool) string {
+	filled := min(max((score*barWidth+50)/100, 0), barWidth)
+	bar := strings.Repeat("█", filled)
+	if color && filled > 0 {
+		bar = level.Color() + bar + colorReset
+	}
+	return fmt.Sprintf("%s%s %d/100", bar, strings.Repeat("░", barWidth-filled), score)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestShouldColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		noColor    bool
+		noColorEnv string
+		isTerminal bool
+		want       bool
+	}{
+		{name: "terminal", isTerminal: true, want: true},
+		{name: "flag", noColor: true, isTerminal: true, want: false},
+		{name: "NO_COLOR", noColorEnv: "1", isTerminal: true, want: false},
+		{name: "not a terminal", isTerminal: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldColor(tt.noColor, tt.noColorEnv, tt.isTerminal); got != tt.want {
+				t.Errorf("shouldColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLevel(t *testing.T) {
+	if got := formatLevel(pwgen.Strong, false); got != "Strong" {
+		t.Errorf("formatLevel() without color = %q, want %q", got, "Strong")
+	}
+
+	colored := formatLevel(pwgen.Strong, true)
+	if !strings.HasPrefix(colored, "\033[") || !strings.HasSuffix(colored, colorReset) {
+		t.Errorf("formatLevel() with color = %q, want ANSI-wrapped level", colored)
+	}
+}
+
+func TestFormatBar(t *testing.T) {
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{0, "░░░░░░░░░░ 0/100"},
+		{4, "░░░░░░░░░░ 4/100"},
+		{5, "█░░░░░░░░░ 5/100"}, // Rounded to the nearest cell
+		{80, "████████░░ 80/100"},
+		{100, "██████████ 100/100"},
+		{-10, "░░░░░░░░░░ -10/100"},
+		{120, "██████████ 120/100"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBar(tt.score, pwgen.Strong, false); got != tt.want {
+			t.Errorf("formatBar(%d) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+
+	// Only the filled cells are colored
+	want := pwgen.Weak.Color() + "███" + colorReset + "░░░░░░░ 30/100"
+	if got := formatBar(30, pwgen.Weak, true); got != want {
+		t.Errorf("formatBar() with color = %q, want %q", got, want)
+	}
+	if got := formatBar(0, pwgen.VeryWeak, true); strings.Contains(got, "\033[") {
+		t.Errorf("formatBar() with color and no filled cell = %q, want no escape codes", got)
+	}
+}
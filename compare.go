@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// writeComparison prints the strength of two passwords side by side for
+// --compare, followed by the feedback only one of them gets and which one
+// is stronger. The passwords themselves are never printed.
+func writeComparison(w io.Writer, first, second pwgen.PasswordStrength, lang string) {
+	rows := [][3]string{
+		{"", "Password 1", "Password 2"},
+		{"Level", first.Level.String(), second.Level.String()},
+		{"Score", fmt.Sprintf("%d/100", first.Score), fmt.Sprintf("%d/100", second.Score)},
+		{"Entropy", fmt.Sprintf("%.1f bits%s", first.Entropy, entropyModelLabel(first.EntropyModel)), fmt.Sprintf("%.1f bits%s", second.Entropy, entropyModelLabel(second.EntropyModel))},
+		{"Time to crack", first.TimeToCrack, second.TimeToCrack},
+	}
+
+	var widths [2]int
+	for _, row := range rows {
+		widths[0] = max(widths[0], len(row[0]))
+		widths[1] = max(widths[1], len(row[1]))
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%-*s  %-*s  %s\n", widths[0], row[0], widths[1], row[1], row[2])
+	}
+
+	firstFeedback := pwgen.LocalizeFeedback(first.Messages, lang)
+	secondFeedback := pwgen.LocalizeFeedback(second.Messages, lang)
+	if only := missingFrom(firstFeedback, secondFeedback); len(only) > 0 {
+		fmt.Fprintf(w, "\nOnly password 1: %s\n", strings.Join(only, "; "))
+	}
+	if only := missingFrom(secondFeedback, firstFeedback); len(only) > 0 {
+		fmt.Fprintf(w, "\nOnly password 2: %s\n", strings.Join(only, "; "))
+	}
+
+	switch {
+	case first.Score > second.Score:
+		fmt.Fprintf(w, "\nPassword 1 is stronger by %d points\n", first.Score-second.Score)
+	case second.Score > first.Score:
+		fmt.Fprintf(w, "\nPassword 2 is stronger by %d points\n", second.Score-first.Score)
+	default:
+		fmt.Fprintln(w, "\nBoth passwords score the same")
+	}
+}
+
+// missingFrom returns the items of feedback that other does not contain.
+func missingFrom(feedback, other []string) []string {
+	var missing []string
+	for _, item := range feedback {
+		if !slices.Contains(other, item) {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestWriteComparison(t *testing.T) {
+	weak := pwgen.AnalyzePasswordStrength("Summer2024!")
+	strong := pwgen.AnalyzePasswordStrength("Kx7!mQ#z8305vB")
+
+	var buf bytes.Buffer
+	writeComparison(&buf, weak, strong, "en")
+	output := buf.String()
+
+	for _, want := range []string{
+		"               Password 1  Password 2\n",
+		fmt.Sprintf("Score          %-10s  %d/100\n", fmt.Sprintf("%d/100", weak.Score), strong.Score),
+		"Only password 1: Consider using 12+ characters for better security; Avoid years and dates",
+		"Only password 2: Excellent password strength!\n",
+		fmt.Sprintf("Password 2 is stronger by %d points\n", strong.Score-weak.Score),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("writeComparison() output is missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Summer2024!") {
+		t.Error("writeComparison() printed a password")
+	}
+
+	buf.Reset()
+	writeComparison(&buf, strong, strong, "en")
+	if output := buf.String(); strings.Contains(output, "Only password") || !strings.HasSuffix(output, "Both passwords score the same\n") {
+		t.Errorf("writeComparison() of equal passwords =\n%s", output)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// completionShells lists the shells supported by the completion subcommand.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// subcommands lists the words accepted in place of flags as the first
+// argument.
+var subcommands = []string{"completion", "policy", "serve"}
+
+// fileFlags take a path, so the shell completes file names for them.
+var fileFlags = map[string]bool{
+	"output":        true,
+	"config":        true,
+	"save-config":   true,
+	"save-policy":   true,
+	"analyze-file":  true,
+	"validate-file": true,
+	"history":       true,
+	"wordlist":      true,
+}
+
+// completionFlag is a command-line flag as seen by a completion script.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+	isFile bool
+	values []string // Fixed choices for the flag's value, if any
+}
+
+// completionFlags describes every flag defined on flags, in lexical order.
+// The choices for --policy come from ListPolicies, so completion always
+// offers the builtin policies of this build, and likewise for --profile.
+func completionFlags(flags *flag.FlagSet) []completionFlag {
+	policies := pwgen.ListPolicies()
+	sort.Strings(policies)
+	profiles := slices.Sorted(maps.Keys(builtinProfiles))
+
+	values := map[string][]string{
+		"policy":     policies,
+		"p":          policies,
+		"profile":    profiles,
+		"symbol-set": pwgen.ListSymbolSets(),
+		"format":     outputFormats,
+		"hash":       hashAlgorithms,
+		"lang":       pwgen.Languages(),
+		"guess-rate": {"online", "offline-slow-hash", "offline-gpu"},
+		"min-level":  pwgen.StrengthLevelNames,
+	}
+
+	var result []completionFlag
+	flags.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		result = append(result, completionFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			isBool: ok && boolFlag.IsBoolFlag(),
+			isFile: fileFlags[f.Name],
+			values: values[f.Name],
+		})
+	})
+	return result
+}
+
+// writeCompletion writes the completion script for shell to w.
+func writeCompletion(w io.Writer, shell string, flags []completionFlag) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion(flags)
+	case "zsh":
+		script = zshCompletion(flags)
+	case "fish":
+		script = fishCompletion(flags)
+	default:
+		return fmt.Errorf("unknown shell %q (available: %s)", shell, strings.Join(completionShells, ", "))
+	}
+
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// flagSpelling returns how a flag is usually written: -x for single letter
+// flags and --name otherwise. Go accepts either number of dashes.
+func flagSpelling(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func bashCompletion(flags []completionFlag) string {
+	var b strings.Builder
+	var names, fileNames, valueNames []string
+
+	b.WriteString("# bash completion for pwgen\n")
+	b.WriteString("_pwgen() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n\n")
+	b.WriteString("    if [[ $COMP_CWORD -eq 2 && ${COMP_WORDS[1]} == completion ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(completionShells, " "))
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    if [[ $COMP_CWORD -ge 2 && ${COMP_WORDS[1]} == policy ]]; then\n")
+	b.WriteString("        if [[ $COMP_CWORD -eq 2 ]]; then\n")
+	b.WriteString("            COMPREPLY=($(compgen -W \"validate\" -- \"$cur\"))\n")
+	b.WriteString("        else\n")
+	b.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	b.WriteString("        fi\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+	b.WriteString("    case \"$prev\" in\n")
+
+	for _, f := range flags {
+		names = append(names, flagSpelling(f.name))
+		spellings := "-" + f.name + "|--" + f.name
+
+		switch {
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "        %s)\n", spellings)
+			fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(f.values, " "))
+			b.WriteString("            return\n")
+			b.WriteString("            ;;\n")
+		case f.isFile:
+			fileNames = append(fileNames, spellings)
+		case !f.isBool:
+			valueNames = append(valueNames, spellings)
+		}
+	}
+
+	if len(fileNames) > 0 {
+		fmt.Fprintf(&b, "        %s)\n", strings.Join(fileNames, "|"))
+		b.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		b.WriteString("            return\n")
+		b.WriteString("            ;;\n")
+	}
+	if len(valueNames) > 0 {
+		// Free-form values such as lengths and counts have nothing to offer
+		fmt.Fprintf(&b, "        %s)\n", strings.Join(valueNames, "|"))
+		b.WriteString("            return\n")
+		b.WriteString("            ;;\n")
+	}
+
+	b.WriteString("    esac\n\n")
+	b.WriteString("    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(subcommands, " "))
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+	fmt.Fprintf(&b, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	b.WriteString("}\n")
+	b.WriteString("complete -F _pwgen pwgen\n")
+
+	return b.String()
+}
+
+func zshCompletion(flags []completionFlag) string {
+	var b strings.Builder
+
+	b.WriteString("#compdef pwgen\n\n")
+	b.WriteString("_pwgen() {\n")
+	b.WriteString("    if [[ ${words[2]} == completion ]]; then\n")
+	fmt.Fprintf(&b, "        _arguments '2:shell:(%s)'\n", strings.Join(completionShells, " "))
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    if [[ ${words[2]} == policy ]]; then\n")
+	b.WriteString("        _arguments '2:action:(validate)' '*:policy file:_files'\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+	b.WriteString("    _arguments -s \\\n")
+
+	for _, f := range flags {
+		spec := flagSpelling(f.name) + "[" + zshEscape(f.usage) + "]"
+		switch {
+		case len(f.values) > 0:
+			spec += ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+		case f.isFile:
+			spec += ":file:_files"
+		case !f.isBool:
+			spec += ":" + f.name + ":"
+		}
+		fmt.Fprintf(&b, "        %s \\\n", shellQuote(spec))
+	}
+
+	fmt.Fprintf(&b, "        '1::command:(%s)'\n", strings.Join(subcommands, " "))
+	b.WriteString("}\n\n")
+	b.WriteString("compdef _pwgen pwgen\n")
+
+	return b.String()
+}
+
+func fishCompletion(flags []completionFlag) string {
+	var b strings.Builder
+
+	b.WriteString("# fish completion for pwgen\n")
+	b.WriteString("complete -c pwgen -n '__fish_use_subcommand' -a completion -d 'Print a shell completion script'\n")
+	b.WriteString("complete -c pwgen -n '__fish_use_subcommand' -a policy -d 'Check custom policy files'\n")
+	fmt.Fprintf(&b, "complete -c pwgen -n '__fish_seen_subcommand_from completion' -f -a '%s'\n", strings.Join(completionShells, " "))
+	b.WriteString("complete -c pwgen -n '__fish_seen_subcommand_from policy; and not __fish_seen_subcommand_from validate' -f -a validate -d 'Report problems in policy files'\n")
+	b.WriteString("complete -c pwgen -n '__fish_seen_subcommand_from validate' -F\n")
+
+	for _, f := range flags {
+		option := "-l " + f.name
+		if len(f.name) == 1 {
+			option = "-s " + f.name
+		}
+
+		fmt.Fprintf(&b, "complete -c pwgen %s -d %s", option, shellQuote(f.usage))
+		switch {
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, " -x -a %s", shellQuote(strings.Join(f.values, " ")))
+		case f.isFile:
+			b.WriteString(" -r -F")
+		case !f.isBool:
+			b.WriteString(" -x")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// shellQuote quotes s in single quotes for bash, zsh and fish.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshEscape escapes the characters _arguments treats specially in a flag
+// description.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
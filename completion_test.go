@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func testCompletionFlags() []completionFlag {
+	flags := flag.NewFlagSet("pwgen", flag.ContinueOnError)
+	flags.Int("length", 12, "Password length")
+	flags.Bool("symbols", false, "Include symbols")
+	flags.String("policy", "", "Apply password policy template")
+	flags.String("p", "", "Apply password policy template (short)")
+	flags.String("output", "", "Write passwords to this file")
+	flags.String("format", "text", "Output format")
+	flags.String("pattern", "", "A=upper, \\ escapes, [it's] literal")
+	return completionFlags(flags)
+}
+
+func TestCompletionFlags(t *testing.T) {
+	flags := testCompletionFlags()
+
+	byName := make(map[string]completionFlag)
+	for _, f := range flags {
+		byName[f.name] = f
+	}
+
+	if !byName["symbols"].isBool || byName["length"].isBool {
+		t.Error("completionFlags() should only mark boolean flags as isBool")
+	}
+	if !byName["output"].isFile {
+		t.Error("completionFlags() should mark --output as taking a file")
+	}
+	if got, want := len(byName["policy"].values), len(pwgen.ListPolicies()); got != want {
+		t.Errorf("completionFlags() --policy has %d values, want %d", got, want)
+	}
+	if got, want := len(byName["p"].values), len(pwgen.ListPolicies()); got != want {
+		t.Errorf("completionFlags() -p has %d values, want %d", got, want)
+	}
+	if !slices.Contains(byName["format"].values, "markdown") {
+		t.Errorf("completionFlags() --format values = %v, want markdown among them", byName["format"].values)
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	flags := testCompletionFlags()
+
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletion(&buf, shell, flags); err != nil {
+				t.Fatalf("writeCompletion() error = %v", err)
+			}
+
+			script := buf.String()
+			for _, name := range pwgen.ListPolicies() {
+				if !strings.Contains(script, name) {
+					t.Errorf("%s completion does not offer policy %q", shell, name)
+				}
+			}
+			for _, want := range []string{"length", "symbols", "output", "completion", "policy", "validate"} {
+				if !strings.Contains(script, want) {
+					t.Errorf("%s completion does not mention %q", shell, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteCompletionUnknownShell(t *testing.T) {
+	if err := writeCompletion(io.Discard, "powershell", testCompletionFlags()); err == nil {
+		t.Error("writeCompletion() should fail for an unsupported shell")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"plain", "'plain'"},
+		{"it's", `'it'\''s'`},
+		{"", "''"},
+	}
+
+	for _, tt := range tests {
+		if got := shellQuote(tt.input); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestZshEscape(t *testing.T) {
+	if got, want := zshEscape(`a [b]: \c`), `a \[b\]\: \\c`; got != want {
+		t.Errorf("zshEscape() = %s, want %s", got, want)
+	}
+}
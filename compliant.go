@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// errPolicyAttempts is returned when no attempt for a password met the
+// policy, so the batch can skip it and carry on.
+var errPolicyAttempts = errors.New("no password met the policy")
+
+// policyGenerator returns passwords from generate, regenerating any that
+// violates policy so every password it returns is compliant. It gives up on
+// a password after attempts tries, pwgen.DefaultMaxAttempts when zero.
+type policyGenerator struct {
+	generate func() (string, error)
+	policy   pwgen.PasswordPolicy
+	attempts int
+}
+
+func (g *policyGenerator) next() (string, error) {
+	for attempt := 0; attempt < attemptLimit(g.attempts); attempt++ {
+		password, err := g.generate()
+		if err != nil {
+			return "", err
+		}
+
+		if len(pwgen.ValidatePasswordAgainstPolicy(password, g.policy)) == 0 {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w in %d attempts", errPolicyAttempts, attemptLimit(g.attempts))
+}
+
+// entropyGenerator returns passwords from generate, regenerating any whose
+// entropy, as analyzer estimates it, is below minEntropy bits. It applies
+// --min-entropy to the modes pwgen.GenerateWithMinEntropy does not cover,
+// such as --passphrase or --pattern, and gives up after attempts tries,
+// pwgen.DefaultMaxAttempts when zero.
+type entropyGenerator struct {
+	generate   func() (string, error)
+	analyzer   pwgen.Analyzer
+	minEntropy float64
+	attempts   int
+}
+
+func (g *entropyGenerator) next() (string, error) {
+	best := 0.0
+	for attempt := 0; attempt < attemptLimit(g.attempts); attempt++ {
+		password, err := g.generate()
+		if err != nil {
+			return "", err
+		}
+
+		entropy := g.analyzer.Analyze(password).Entropy
+		if entropy >= g.minEntropy {
+			return password, nil
+		}
+		best = max(best, entropy)
+	}
+
+	return "", fmt.Errorf("%w after %d attempts: entropy of %.1f bits not reached, the best password had %.1f bits", pwgen.ErrMaxAttempts, attemptLimit(g.attempts), g.minEntropy, best)
+}
+
+// attemptLimit returns attempts, or pwgen.DefaultMaxAttempts when it is
+// zero, for the regeneration loops of --count-by-policy, --min-entropy,
+// --unique and --history.
+func attemptLimit(attempts int) int {
+	if attempts > 0 {
+		return attempts
+	}
+	return pwgen.DefaultMaxAttempts
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestPolicyGenerator(t *testing.T) {
+	// Without the policy applied to the config, most short lowercase and
+	// digit passwords lack a digit or a second letter
+	config := pwgen.Config{Length: 4, IncludeLower: true, IncludeDigits: true}
+	policy := pwgen.PasswordPolicy{MinLength: 4, MinDigits: 2, MinLower: 2}
+	generator := pwgen.NewSeededGenerator("count-by-policy")
+	compliant := &policyGenerator{
+		generate: func() (string, error) { return generator.Generate(config) },
+		policy:   policy,
+	}
+
+	for i := 0; i < 100; i++ {
+		password, err := compliant.next()
+		if err != nil {
+			t.Fatalf("next() error = %v", err)
+		}
+		if violations := pwgen.ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+			t.Fatalf("next() = %q violates the policy: %v", password, violations)
+		}
+	}
+}
+
+func TestPolicyGeneratorGivesUp(t *testing.T) {
+	attempts := 0
+	compliant := &policyGenerator{
+		generate: func() (string, error) {
+			attempts++
+			return "abc", nil
+		},
+		policy: pwgen.PasswordPolicy{RequireDigits: true},
+	}
+
+	if _, err := compliant.next(); !errors.Is(err, errPolicyAttempts) {
+		t.Fatalf("next() error = %v, want errPolicyAttempts", err)
+	}
+	if attempts != pwgen.DefaultMaxAttempts {
+		t.Errorf("next() made %d attempts, want %d", attempts, pwgen.DefaultMaxAttempts)
+	}
+
+	// --max-attempts lowers the limit
+	attempts = 0
+	compliant.attempts = 10
+	if _, err := compliant.next(); !errors.Is(err, errPolicyAttempts) || attempts != 10 {
+		t.Errorf("next() error = %v after %d attempts, want errPolicyAttempts after 10", err, attempts)
+	}
+}
+
+func TestEntropyGenerator(t *testing.T) {
+	// A stub analyzer rates passwords by length, so only the long one passes
+	passwords := []string{"ab", "abcd", "abcdefgh"}
+	calls := 0
+	g := &entropyGenerator{
+		generate: func() (string, error) {
+			password := passwords[calls%len(passwords)]
+			calls++
+			return password, nil
+		},
+		analyzer: pwgen.AnalyzerFunc(func(password string) pwgen.PasswordStrength {
+			return pwgen.PasswordStrength{Entropy: float64(8 * len(password))}
+		}),
+		minEntropy: 60,
+	}
+
+	if password, err := g.next(); err != nil || password != "abcdefgh" || calls != 3 {
+		t.Errorf("next() = %q, %v after %d calls, want the 64-bit password after 3", password, err, calls)
+	}
+
+	// A mode that can never reach the threshold gives up
+	g.minEntropy = 100
+	g.attempts = 10
+	calls = 0
+	_, err := g.next()
+	if !errors.Is(err, pwgen.ErrMaxAttempts) || calls != 10 || !strings.Contains(err.Error(), "the best password had 64.0 bits") {
+		t.Errorf("next() error = %v after %d calls, want ErrMaxAttempts after 10 naming the best entropy", err, calls)
+	}
+}
@@ -1,79 +1,420 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
+	"github.com/romdj/password-generator/pkg/pwgen"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Length           int    `yaml:"length"`
-	IncludeUpper     bool   `yaml:"include_upper"`
-	IncludeLower     bool   `yaml:"include_lower"`
-	IncludeDigits    bool   `yaml:"include_digits"`
-	IncludeSymbols   bool   `yaml:"include_symbols"`
-	ExcludeAmbiguous bool   `yaml:"exclude_ambiguous"`
-	Count            int    `yaml:"count"`
-	ShowStrength     bool   `yaml:"show_strength"`
-	PolicyTemplate   string `yaml:"policy_template"`
+	Length                   int     `yaml:"length" toml:"length" json:"length"`
+	IncludeUpper             bool    `yaml:"include_upper" toml:"include_upper" json:"include_upper"`
+	IncludeLower             bool    `yaml:"include_lower" toml:"include_lower" json:"include_lower"`
+	IncludeDigits            bool    `yaml:"include_digits" toml:"include_digits" json:"include_digits"`
+	IncludeSymbols           bool    `yaml:"include_symbols" toml:"include_symbols" json:"include_symbols"`
+	IncludeSpace             bool    `yaml:"include_space" toml:"include_space" json:"include_space"`
+	ExcludeAmbiguous         bool    `yaml:"exclude_ambiguous" toml:"exclude_ambiguous" json:"exclude_ambiguous"`
+	Charset                  string  `yaml:"charset" toml:"charset" json:"charset"`
+	ExcludeChars             string  `yaml:"exclude_chars" toml:"exclude_chars" json:"exclude_chars"`
+	ASCIIOnly                bool    `yaml:"ascii_only" toml:"ascii_only" json:"ascii_only"`
+	AllowControl             bool    `yaml:"allow_control" toml:"allow_control" json:"allow_control"`
+	AmbiguousChars           string  `yaml:"ambiguous_chars" toml:"ambiguous_chars" json:"ambiguous_chars"`
+	SymbolSet                string  `yaml:"symbol_set" toml:"symbol_set" json:"symbol_set"`
+	Count                    int     `yaml:"count" toml:"count" json:"count"`
+	ShowStrength             bool    `yaml:"show_strength" toml:"show_strength" json:"show_strength"`
+	PolicyTemplate           string  `yaml:"policy_template" toml:"policy_template" json:"policy_template"`
+	GuessRate                string  `yaml:"guess_rate" toml:"guess_rate" json:"guess_rate"`
+	Passphrase               bool    `yaml:"passphrase" toml:"passphrase" json:"passphrase"`
+	Words                    int     `yaml:"words" toml:"words" json:"words"`
+	Separator                string  `yaml:"separator" toml:"separator" json:"separator"`
+	Capitalize               bool    `yaml:"capitalize" toml:"capitalize" json:"capitalize"`
+	Wordlist                 string  `yaml:"wordlist" toml:"wordlist" json:"wordlist"`
+	WordSeparators           string  `yaml:"word_separators" toml:"word_separators" json:"word_separators"`
+	Pronounceable            bool    `yaml:"pronounceable" toml:"pronounceable" json:"pronounceable"`
+	Hybrid                   bool    `yaml:"hybrid" toml:"hybrid" json:"hybrid"`
+	Clipboard                bool    `yaml:"clipboard" toml:"clipboard" json:"clipboard"`
+	RequireShow              bool    `yaml:"require_show" toml:"require_show" json:"require_show"`
+	PIN                      bool    `yaml:"pin" toml:"pin" json:"pin"`
+	ForbidTrivialPIN         bool    `yaml:"forbid_trivial_pin" toml:"forbid_trivial_pin" json:"forbid_trivial_pin"`
+	MinEntropy               float64 `yaml:"min_entropy" toml:"min_entropy" json:"min_entropy"`
+	Pattern                  string  `yaml:"pattern" toml:"pattern" json:"pattern"`
+	Hex                      int     `yaml:"hex" toml:"hex" json:"hex"`
+	Base64                   int     `yaml:"base64" toml:"base64" json:"base64"`
+	Base64URL                bool    `yaml:"base64_url" toml:"base64_url" json:"base64_url"`
+	MinUpper                 int     `yaml:"min_upper" toml:"min_upper" json:"min_upper"`
+	MinLower                 int     `yaml:"min_lower" toml:"min_lower" json:"min_lower"`
+	MinDigits                int     `yaml:"min_digits" toml:"min_digits" json:"min_digits"`
+	MinSymbols               int     `yaml:"min_symbols" toml:"min_symbols" json:"min_symbols"`
+	MaxUpper                 int     `yaml:"max_upper" toml:"max_upper" json:"max_upper"`
+	MaxLower                 int     `yaml:"max_lower" toml:"max_lower" json:"max_lower"`
+	MaxDigits                int     `yaml:"max_digits" toml:"max_digits" json:"max_digits"`
+	MaxSymbols               int     `yaml:"max_symbols" toml:"max_symbols" json:"max_symbols"`
+	MaxRepeat                int     `yaml:"max_repeat" toml:"max_repeat" json:"max_repeat"`
+	MaxAttempts              int     `yaml:"max_attempts" toml:"max_attempts" json:"max_attempts"`
+	MaxClassRun              int     `yaml:"max_class_run" toml:"max_class_run" json:"max_class_run"`
+	MinDistinct              int     `yaml:"min_distinct" toml:"min_distinct" json:"min_distinct"`
+	AvoidCommon              bool    `yaml:"avoid_common" toml:"avoid_common" json:"avoid_common"`
+	AlternateClasses         bool    `yaml:"alternate_classes" toml:"alternate_classes" json:"alternate_classes"`
+	NoRepeat                 bool    `yaml:"no_repeat" toml:"no_repeat" json:"no_repeat"`
+	Weight                   string  `yaml:"weight" toml:"weight" json:"weight"`
+	Format                   string  `yaml:"format" toml:"format" json:"format"`
+	CrackTimes               bool    `yaml:"crack_times" toml:"crack_times" json:"crack_times"`
+	Lang                     string  `yaml:"lang" toml:"lang" json:"lang"`
+	EntropyModel             string  `yaml:"entropy_model" toml:"entropy_model" json:"entropy_model"`
+	EntropyPenaltyRepeated   float64 `yaml:"entropy_penalty_repeated" toml:"entropy_penalty_repeated" json:"entropy_penalty_repeated"`
+	EntropyPenaltySequential float64 `yaml:"entropy_penalty_sequential" toml:"entropy_penalty_sequential" json:"entropy_penalty_sequential"`
+	EntropyPenaltySubstrings float64 `yaml:"entropy_penalty_substrings" toml:"entropy_penalty_substrings" json:"entropy_penalty_substrings"`
+	EntropyPenaltyCommon     float64 `yaml:"entropy_penalty_common" toml:"entropy_penalty_common" json:"entropy_penalty_common"`
+	EntropyPenaltyDate       float64 `yaml:"entropy_penalty_date" toml:"entropy_penalty_date" json:"entropy_penalty_date"`
+	EntropyPenaltyDistinct   float64 `yaml:"entropy_penalty_distinct" toml:"entropy_penalty_distinct" json:"entropy_penalty_distinct"`
+	Analyzer                 string  `yaml:"analyzer" toml:"analyzer" json:"analyzer"`
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Length:           12,
-		IncludeUpper:     true,
-		IncludeLower:     true,
-		IncludeDigits:    true,
-		IncludeSymbols:   false,
-		ExcludeAmbiguous: false,
-		Count:            1,
-		ShowStrength:     false,
-		PolicyTemplate:   "",
+		Length:                   12,
+		IncludeUpper:             true,
+		IncludeLower:             true,
+		IncludeDigits:            true,
+		IncludeSymbols:           false,
+		IncludeSpace:             false,
+		ExcludeAmbiguous:         false,
+		Charset:                  "",
+		ExcludeChars:             "",
+		ASCIIOnly:                false,
+		AllowControl:             false,
+		AmbiguousChars:           pwgen.Ambiguous,
+		SymbolSet:                pwgen.DefaultSymbolSet,
+		Count:                    1,
+		ShowStrength:             false,
+		PolicyTemplate:           "",
+		GuessRate:                "",
+		Passphrase:               false,
+		Words:                    4,
+		Separator:                "-",
+		Capitalize:               false,
+		Wordlist:                 "",
+		WordSeparators:           "",
+		Pronounceable:            false,
+		Hybrid:                   false,
+		Clipboard:                false,
+		RequireShow:              false,
+		PIN:                      false,
+		ForbidTrivialPIN:         false,
+		MinEntropy:               0,
+		Pattern:                  "",
+		Hex:                      0,
+		Base64:                   0,
+		Base64URL:                false,
+		MinUpper:                 0,
+		MinLower:                 0,
+		MinDigits:                0,
+		MinSymbols:               0,
+		MaxUpper:                 0,
+		MaxLower:                 0,
+		MaxDigits:                0,
+		MaxSymbols:               0,
+		MaxRepeat:                0,
+		MaxAttempts:              pwgen.DefaultMaxAttempts,
+		MaxClassRun:              0,
+		MinDistinct:              0,
+		AvoidCommon:              false,
+		AlternateClasses:         false,
+		NoRepeat:                 false,
+		Weight:                   "",
+		Format:                   "text",
+		CrackTimes:               false,
+		Lang:                     "",
+		EntropyModel:             pwgen.EntropyModelUniform,
+		EntropyPenaltyRepeated:   pwgen.DefaultPatternPenalties.Repeated,
+		EntropyPenaltySequential: pwgen.DefaultPatternPenalties.Sequential,
+		EntropyPenaltySubstrings: pwgen.DefaultPatternPenalties.Substrings,
+		EntropyPenaltyCommon:     pwgen.DefaultPatternPenalties.Common,
+		EntropyPenaltyDate:       pwgen.DefaultPatternPenalties.Date,
+		EntropyPenaltyDistinct:   pwgen.DefaultPatternPenalties.Distinct,
+		Analyzer:                 pwgen.AnalyzerBuiltin,
 	}
 }
 
+// LoadConfig loads the file named by PWGEN_CONFIG, or the default config
+// files when it is unset, applies the PWGEN_PROFILE profile, then PWGEN_*
+// environment variables.
 func LoadConfig() (Config, error) {
+	config, _, err := LoadConfigFrom(os.Getenv("PWGEN_CONFIG"), os.Getenv("PWGEN_PROFILE"))
+	return config, err
+}
+
+// LoadConfigFrom loads exactly the config file at path, skipping the
+// default search, applies the named profile unless it is empty, then
+// PWGEN_* environment variables. It also returns every profile available:
+// the builtin ones and those of the files loaded. Unlike the default
+// files, which are skipped when missing, an explicit file that cannot be
+// loaded is an error. An empty path searches the default locations. Either
+// way, a file with unknown keys or out-of-range values is an error naming
+// the file.
+func LoadConfigFrom(path, profile string) (Config, map[string]Profile, error) {
+	config, profiles, _, err := loadConfigSources(path, profile)
+	return config, profiles, err
+}
+
+// loadConfigSources is LoadConfigFrom, also returning where each setting
+// came from for --explain-config.
+func loadConfigSources(path, profile string) (Config, map[string]Profile, configSources, error) {
 	config := DefaultConfig()
+	profiles := maps.Clone(builtinProfiles)
+	sources := newConfigSources()
 
-	// Load from config files (in order of precedence)
-	configPaths := []string{
-		".pwgen.yaml",
-		".pwgen.yml",
-	}
+	if path != "" {
+		fileProfiles, err := loadConfigFromFile(path, &config)
+		if err != nil {
+			return config, nil, nil, fmt.Errorf("could not load config file %s: %w", path, err)
+		}
+		maps.Copy(profiles, fileProfiles)
+		sources.set(configFileKeys(path), path)
+	} else {
+		// Without a home directory only the current directory is searched
+		homeDir, _ := os.UserHomeDir()
 
-	// Add home directory config paths
-	if homeDir, err := os.UserHomeDir(); err == nil {
-		configPaths = append(configPaths,
-			filepath.Join(homeDir, ".pwgen.yaml"),
-			filepath.Join(homeDir, ".pwgen.yml"),
-			filepath.Join(homeDir, ".config", "pwgen", "config.yaml"),
-			filepath.Join(homeDir, ".config", "pwgen", "config.yml"),
-		)
+		// Each layer only overrides the settings its file actually contains,
+		// so a project file setting one key keeps the rest of the home config
+		for _, layer := range configLayers(homeDir) {
+			for _, candidate := range layer {
+				fileProfiles, err := loadConfigFromFile(candidate, &config)
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return config, nil, nil, fmt.Errorf("could not load config file %s: %w", candidate, err)
+				}
+				maps.Copy(profiles, fileProfiles)
+				sources.set(configFileKeys(candidate), candidate)
+				break // Use the first file found in each layer
+			}
+		}
 	}
 
-	for _, path := range configPaths {
-		if err := loadConfigFromFile(path, &config); err == nil {
-			break // Use first config file found
+	// A profile sits between the config files and the environment
+	if profile != "" {
+		selected, ok := profiles[profile]
+		if !ok {
+			return config, nil, nil, fmt.Errorf("unknown profile '%s' (available: %s)", profile, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+		}
+		if err := selected.apply(&config); err != nil {
+			return config, nil, nil, fmt.Errorf("profile %s: %w", profile, err)
 		}
+		sources.set(slices.Collect(maps.Keys(selected.Settings)), "profile "+profile)
 	}
 
 	// Override with environment variables
 	loadConfigFromEnv(&config)
+	for _, key := range envConfigKeys() {
+		sources[key] = "env " + envVarName(key)
+	}
 
-	return config, nil
+	return config, profiles, sources, nil
 }
 
-func loadConfigFromFile(path string, config *Config) error {
+// configLayers returns the config file candidates grouped by location, from
+// lowest to highest precedence: the home directory, ~/.config/pwgen, then
+// the current directory. Within a location YAML is preferred over TOML and
+// JSON.
+func configLayers(homeDir string) [][]string {
+	var layers [][]string
+
+	if homeDir != "" {
+		xdgDir := filepath.Join(homeDir, ".config", "pwgen")
+		layers = append(layers,
+			[]string{
+				filepath.Join(homeDir, ".pwgen.yaml"),
+				filepath.Join(homeDir, ".pwgen.yml"),
+				filepath.Join(homeDir, ".pwgen.toml"),
+				filepath.Join(homeDir, ".pwgen.json"),
+			},
+			[]string{
+				filepath.Join(xdgDir, "config.yaml"),
+				filepath.Join(xdgDir, "config.yml"),
+				filepath.Join(xdgDir, "config.toml"),
+				filepath.Join(xdgDir, "config.json"),
+			},
+		)
+	}
+
+	return append(layers, []string{
+		".pwgen.yaml",
+		".pwgen.yml",
+		".pwgen.toml",
+		".pwgen.json",
+	})
+}
+
+// configFile is the layout of a config file: the settings, plus the
+// profiles selected with --profile under "profiles".
+type configFile struct {
+	Config   `yaml:",inline"`
+	Profiles map[string]map[string]any `yaml:"profiles" toml:"profiles" json:"profiles"`
+}
+
+// loadConfigFromFile decodes the config file at path onto config and
+// returns the profiles it defines.
+func loadConfigFromFile(path string, config *Config) (map[string]Profile, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Decode onto a copy so a malformed file leaves config untouched. Keys
+	// missing from the file keep their current value.
+	merged := configFile{Config: *config}
+
+	// The extension picks the format; anything else is read as YAML.
+	// Unknown keys are an error, so a misspelled setting is not silently
+	// ignored.
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), &merged)
+		if undecoded := meta.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown key %q", undecoded[0].String())
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&merged)
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// An empty file sets nothing
+		if err = decoder.Decode(&merged); errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+		// Report "field x not found in type main.configFile" by key
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			unknownKey := strings.NewReplacer("field ", "unknown key ", " not found in type main.configFile", "")
+			var problems []error
+			for _, message := range typeErr.Errors {
+				problems = append(problems, errors.New(unknownKey.Replace(message)))
+			}
+			err = errors.Join(problems...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkConfigRanges(merged.Config); err != nil {
+		return nil, err
+	}
+
+	profiles, err := parseProfiles(merged.Profiles, merged.Config)
+	if err != nil {
+		return nil, err
 	}
 
-	return yaml.Unmarshal(data, config)
+	*config = merged.Config
+	return profiles, nil
+}
+
+// checkConfigRanges reports every setting of config outside its valid
+// range, by its config file key, so a mistake is caught when the file is
+// loaded rather than when generating.
+func checkConfigRanges(config Config) error {
+	var problems []error
+
+	atLeastOne := []struct {
+		key   string
+		value int
+	}{
+		{"length", config.Length},
+		{"count", config.Count},
+		{"words", config.Words},
+		{"max_attempts", config.MaxAttempts},
+	}
+	for _, setting := range atLeastOne {
+		if setting.value < 1 {
+			problems = append(problems, fmt.Errorf("%s must be at least 1, got %d", setting.key, setting.value))
+		}
+	}
+
+	notNegative := []struct {
+		key   string
+		value int
+	}{
+		{"min_upper", config.MinUpper},
+		{"min_lower", config.MinLower},
+		{"min_digits", config.MinDigits},
+		{"min_symbols", config.MinSymbols},
+		{"max_upper", config.MaxUpper},
+		{"max_lower", config.MaxLower},
+		{"max_digits", config.MaxDigits},
+		{"max_symbols", config.MaxSymbols},
+		{"max_repeat", config.MaxRepeat},
+		{"max_class_run", config.MaxClassRun},
+		{"min_distinct", config.MinDistinct},
+		{"hex", config.Hex},
+		{"base64", config.Base64},
+	}
+	for _, setting := range notNegative {
+		if setting.value < 0 {
+			problems = append(problems, fmt.Errorf("%s must not be negative, got %d", setting.key, setting.value))
+		}
+	}
+
+	if config.MinEntropy < 0 {
+		problems = append(problems, fmt.Errorf("min_entropy must not be negative, got %.1f", config.MinEntropy))
+	}
+
+	if err := pwgen.ValidateEntropyModel(config.EntropyModel); err != nil {
+		problems = append(problems, fmt.Errorf("entropy_model: %w", err))
+	}
+
+	if err := checkPatternPenalties(config.ToPatternPenalties()); err != nil {
+		problems = append(problems, err)
+	}
+
+	if err := pwgen.ValidateAnalyzer(config.Analyzer); err != nil {
+		problems = append(problems, fmt.Errorf("analyzer: %w", err))
+	}
+
+	return errors.Join(problems...)
+}
+
+// checkPatternPenalties reports every multiplier of penalties outside
+// (0, 1] by its config file key. Unlike pwgen.ValidatePatternPenalties it
+// rejects 0, as a setting always holds the multiplier itself.
+func checkPatternPenalties(penalties pwgen.PatternPenalties) error {
+	var problems []error
+	multipliers := []struct {
+		key   string
+		value float64
+	}{
+		{"entropy_penalty_repeated", penalties.Repeated},
+		{"entropy_penalty_sequential", penalties.Sequential},
+		{"entropy_penalty_substrings", penalties.Substrings},
+		{"entropy_penalty_common", penalties.Common},
+		{"entropy_penalty_date", penalties.Date},
+		{"entropy_penalty_distinct", penalties.Distinct},
+	}
+	for _, setting := range multipliers {
+		if !(setting.value > 0 && setting.value <= 1) {
+			problems = append(problems, fmt.Errorf("%s must be more than 0 and at most 1, got %g", setting.key, setting.value))
+		}
+	}
+	return errors.Join(problems...)
 }
 
 func loadConfigFromEnv(config *Config) {
@@ -99,10 +440,38 @@ func loadConfigFromEnv(config *Config) {
 		config.IncludeSymbols = parseBool(val, config.IncludeSymbols)
 	}
 
+	if val := os.Getenv("PWGEN_INCLUDE_SPACE"); val != "" {
+		config.IncludeSpace = parseBool(val, config.IncludeSpace)
+	}
+
 	if val := os.Getenv("PWGEN_EXCLUDE_AMBIGUOUS"); val != "" {
 		config.ExcludeAmbiguous = parseBool(val, config.ExcludeAmbiguous)
 	}
 
+	if val := os.Getenv("PWGEN_CHARSET"); val != "" {
+		config.Charset = val
+	}
+
+	if val := os.Getenv("PWGEN_EXCLUDE_CHARS"); val != "" {
+		config.ExcludeChars = val
+	}
+
+	if val := os.Getenv("PWGEN_ASCII_ONLY"); val != "" {
+		config.ASCIIOnly = parseBool(val, config.ASCIIOnly)
+	}
+
+	if val := os.Getenv("PWGEN_ALLOW_CONTROL"); val != "" {
+		config.AllowControl = parseBool(val, config.AllowControl)
+	}
+
+	if val := os.Getenv("PWGEN_AMBIGUOUS_CHARS"); val != "" {
+		config.AmbiguousChars = val
+	}
+
+	if val := os.Getenv("PWGEN_SYMBOL_SET"); val != "" {
+		config.SymbolSet = val
+	}
+
 	if val := os.Getenv("PWGEN_COUNT"); val != "" {
 		if count, err := strconv.Atoi(val); err == nil {
 			config.Count = count
@@ -116,6 +485,264 @@ func loadConfigFromEnv(config *Config) {
 	if val := os.Getenv("PWGEN_POLICY_TEMPLATE"); val != "" {
 		config.PolicyTemplate = val
 	}
+
+	if val := os.Getenv("PWGEN_GUESS_RATE"); val != "" {
+		config.GuessRate = val
+	}
+
+	if val := os.Getenv("PWGEN_PASSPHRASE"); val != "" {
+		config.Passphrase = parseBool(val, config.Passphrase)
+	}
+
+	if val := os.Getenv("PWGEN_WORDS"); val != "" {
+		if words, err := strconv.Atoi(val); err == nil {
+			config.Words = words
+		}
+	}
+
+	if val := os.Getenv("PWGEN_SEPARATOR"); val != "" {
+		config.Separator = val
+	}
+
+	if val := os.Getenv("PWGEN_CAPITALIZE"); val != "" {
+		config.Capitalize = parseBool(val, config.Capitalize)
+	}
+
+	if val := os.Getenv("PWGEN_WORDLIST"); val != "" {
+		config.Wordlist = val
+	}
+
+	if val := os.Getenv("PWGEN_WORD_SEPARATORS"); val != "" {
+		config.WordSeparators = val
+	}
+
+	if val := os.Getenv("PWGEN_PRONOUNCEABLE"); val != "" {
+		config.Pronounceable = parseBool(val, config.Pronounceable)
+	}
+
+	if val := os.Getenv("PWGEN_HYBRID"); val != "" {
+		config.Hybrid = parseBool(val, config.Hybrid)
+	}
+
+	if val := os.Getenv("PWGEN_CLIPBOARD"); val != "" {
+		config.Clipboard = parseBool(val, config.Clipboard)
+	}
+
+	if val := os.Getenv("PWGEN_REQUIRE_SHOW"); val != "" {
+		config.RequireShow = parseBool(val, config.RequireShow)
+	}
+
+	if val := os.Getenv("PWGEN_PIN"); val != "" {
+		config.PIN = parseBool(val, config.PIN)
+	}
+
+	if val := os.Getenv("PWGEN_FORBID_TRIVIAL_PIN"); val != "" {
+		config.ForbidTrivialPIN = parseBool(val, config.ForbidTrivialPIN)
+	}
+
+	if val := os.Getenv("PWGEN_MIN_ENTROPY"); val != "" {
+		if minEntropy, err := strconv.ParseFloat(val, 64); err == nil {
+			config.MinEntropy = minEntropy
+		}
+	}
+
+	if val := os.Getenv("PWGEN_PATTERN"); val != "" {
+		config.Pattern = val
+	}
+
+	if val := os.Getenv("PWGEN_HEX"); val != "" {
+		if hex, err := strconv.Atoi(val); err == nil {
+			config.Hex = hex
+		}
+	}
+
+	if val := os.Getenv("PWGEN_BASE64"); val != "" {
+		if base64, err := strconv.Atoi(val); err == nil {
+			config.Base64 = base64
+		}
+	}
+
+	if val := os.Getenv("PWGEN_BASE64_URL"); val != "" {
+		config.Base64URL = parseBool(val, config.Base64URL)
+	}
+
+	if val := os.Getenv("PWGEN_MIN_UPPER"); val != "" {
+		if minUpper, err := strconv.Atoi(val); err == nil {
+			config.MinUpper = minUpper
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MIN_LOWER"); val != "" {
+		if minLower, err := strconv.Atoi(val); err == nil {
+			config.MinLower = minLower
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MIN_DIGITS"); val != "" {
+		if minDigits, err := strconv.Atoi(val); err == nil {
+			config.MinDigits = minDigits
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MIN_SYMBOLS"); val != "" {
+		if minSymbols, err := strconv.Atoi(val); err == nil {
+			config.MinSymbols = minSymbols
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_UPPER"); val != "" {
+		if maxUpper, err := strconv.Atoi(val); err == nil {
+			config.MaxUpper = maxUpper
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_LOWER"); val != "" {
+		if maxLower, err := strconv.Atoi(val); err == nil {
+			config.MaxLower = maxLower
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_DIGITS"); val != "" {
+		if maxDigits, err := strconv.Atoi(val); err == nil {
+			config.MaxDigits = maxDigits
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_SYMBOLS"); val != "" {
+		if maxSymbols, err := strconv.Atoi(val); err == nil {
+			config.MaxSymbols = maxSymbols
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_REPEAT"); val != "" {
+		if maxRepeat, err := strconv.Atoi(val); err == nil {
+			config.MaxRepeat = maxRepeat
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_CLASS_RUN"); val != "" {
+		if maxClassRun, err := strconv.Atoi(val); err == nil {
+			config.MaxClassRun = maxClassRun
+		}
+	}
+
+	if val := os.Getenv("PWGEN_MAX_ATTEMPTS"); val != "" {
+		if maxAttempts, err := strconv.Atoi(val); err == nil {
+			config.MaxAttempts = maxAttempts
+		}
+	}
+
+	if val := os.Getenv("PWGEN_AVOID_COMMON"); val != "" {
+		config.AvoidCommon = parseBool(val, config.AvoidCommon)
+	}
+
+	if val := os.Getenv("PWGEN_ALTERNATE_CLASSES"); val != "" {
+		config.AlternateClasses = parseBool(val, config.AlternateClasses)
+	}
+
+	if val := os.Getenv("PWGEN_NO_REPEAT"); val != "" {
+		config.NoRepeat = parseBool(val, config.NoRepeat)
+	}
+
+	if val := os.Getenv("PWGEN_WEIGHT"); val != "" {
+		config.Weight = val
+	}
+
+	if val := os.Getenv("PWGEN_FORMAT"); val != "" {
+		config.Format = val
+	}
+
+	if val := os.Getenv("PWGEN_CRACK_TIMES"); val != "" {
+		config.CrackTimes = parseBool(val, config.CrackTimes)
+	}
+
+	if val := os.Getenv("PWGEN_LANG"); val != "" {
+		config.Lang = val
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_MODEL"); val != "" {
+		config.EntropyModel = val
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_PENALTY_REPEATED"); val != "" {
+		if multiplier, err := strconv.ParseFloat(val, 64); err == nil {
+			config.EntropyPenaltyRepeated = multiplier
+		}
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_PENALTY_SEQUENTIAL"); val != "" {
+		if multiplier, err := strconv.ParseFloat(val, 64); err == nil {
+			config.EntropyPenaltySequential = multiplier
+		}
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_PENALTY_SUBSTRINGS"); val != "" {
+		if multiplier, err := strconv.ParseFloat(val, 64); err == nil {
+			config.EntropyPenaltySubstrings = multiplier
+		}
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_PENALTY_COMMON"); val != "" {
+		if multiplier, err := strconv.ParseFloat(val, 64); err == nil {
+			config.EntropyPenaltyCommon = multiplier
+		}
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_PENALTY_DATE"); val != "" {
+		if multiplier, err := strconv.ParseFloat(val, 64); err == nil {
+			config.EntropyPenaltyDate = multiplier
+		}
+	}
+
+	if val := os.Getenv("PWGEN_ANALYZER"); val != "" {
+		config.Analyzer = val
+	}
+
+	if val := os.Getenv("PWGEN_MIN_DISTINCT"); val != "" {
+		if minDistinct, err := strconv.Atoi(val); err == nil {
+			config.MinDistinct = minDistinct
+		}
+	}
+
+	if val := os.Getenv("PWGEN_ENTROPY_PENALTY_DISTINCT"); val != "" {
+		if multiplier, err := strconv.ParseFloat(val, 64); err == nil {
+			config.EntropyPenaltyDistinct = multiplier
+		}
+	}
+}
+
+// configPathFromArgs returns the value of --config from the command line.
+// It is read before flag parsing because the config file supplies the flag
+// defaults.
+func configPathFromArgs(args []string) string {
+	return flagValueFromArgs(args, "config")
+}
+
+// flagValueFromArgs returns the value of the flag name from the command
+// line, for the flags read before flag parsing. Arguments that are not
+// flags are skipped, as they may be the values of other flags.
+func flagValueFromArgs(args []string, name string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break // flag stops parsing here too
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if flagName != name {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
 }
 
 func parseBool(val string, defaultValue bool) bool {
@@ -129,28 +756,178 @@ func parseBool(val string, defaultValue bool) bool {
 	}
 }
 
-func (c Config) ToPasswordConfig() PasswordConfig {
-	return PasswordConfig{
+func (c Config) ToPasswordConfig() pwgen.Config {
+	return pwgen.Config{
 		Length:           c.Length,
 		IncludeUpper:     c.IncludeUpper,
 		IncludeLower:     c.IncludeLower,
 		IncludeDigits:    c.IncludeDigits,
 		IncludeSymbols:   c.IncludeSymbols,
+		IncludeSpace:     c.IncludeSpace,
 		ExcludeAmbiguous: c.ExcludeAmbiguous,
+		Charset:          c.Charset,
+		ExcludeChars:     c.ExcludeChars,
+		ASCIIOnly:        c.ASCIIOnly,
+		AllowControl:     c.AllowControl,
+		AmbiguousChars:   c.AmbiguousChars,
+		SymbolSet:        c.SymbolSet,
+		MinUpper:         c.MinUpper,
+		MinLower:         c.MinLower,
+		MinDigits:        c.MinDigits,
+		MinSymbols:       c.MinSymbols,
+		MaxUpper:         c.MaxUpper,
+		MaxLower:         c.MaxLower,
+		MaxDigits:        c.MaxDigits,
+		MaxSymbols:       c.MaxSymbols,
+		MaxRepeat:        c.MaxRepeat,
+		MaxAttempts:      c.MaxAttempts,
+		MaxClassRun:      c.MaxClassRun,
+		MinDistinct:      c.MinDistinct,
+		AvoidCommon:      c.AvoidCommon,
+		AlternateClasses: c.AlternateClasses,
+		NoRepeat:         c.NoRepeat,
+	}
+}
+
+// ToPassphraseConfig returns the passphrase settings of c. Wordlist is a
+// path, so the words are loaded separately with pwgen.LoadWordlist.
+func (c Config) ToPassphraseConfig() pwgen.PassphraseConfig {
+	return pwgen.PassphraseConfig{
+		Words:      c.Words,
+		Separator:  c.Separator,
+		Capitalize: c.Capitalize,
+		Separators: c.WordSeparators,
 	}
 }
 
+// ToPatternPenalties returns the entropy penalty multipliers of c.
+func (c Config) ToPatternPenalties() pwgen.PatternPenalties {
+	return pwgen.PatternPenalties{
+		Repeated:   c.EntropyPenaltyRepeated,
+		Sequential: c.EntropyPenaltySequential,
+		Substrings: c.EntropyPenaltySubstrings,
+		Common:     c.EntropyPenaltyCommon,
+		Date:       c.EntropyPenaltyDate,
+		Distinct:   c.EntropyPenaltyDistinct,
+	}
+}
+
+// withPasswordConfig returns c with the settings of config and passphrase,
+// the reverse of ToPasswordConfig and ToPassphraseConfig.
+func (c Config) withPasswordConfig(config pwgen.Config, passphrase pwgen.PassphraseConfig) Config {
+	c.Length = config.Length
+	c.IncludeUpper = config.IncludeUpper
+	c.IncludeLower = config.IncludeLower
+	c.IncludeDigits = config.IncludeDigits
+	c.IncludeSymbols = config.IncludeSymbols
+	c.IncludeSpace = config.IncludeSpace
+	c.ExcludeAmbiguous = config.ExcludeAmbiguous
+	c.Charset = config.Charset
+	c.ExcludeChars = config.ExcludeChars
+	c.ASCIIOnly = config.ASCIIOnly
+	c.AllowControl = config.AllowControl
+	c.AmbiguousChars = config.AmbiguousChars
+	c.SymbolSet = config.SymbolSet
+	c.MinUpper = config.MinUpper
+	c.MinLower = config.MinLower
+	c.MinDigits = config.MinDigits
+	c.MinSymbols = config.MinSymbols
+	c.MaxUpper = config.MaxUpper
+	c.MaxLower = config.MaxLower
+	c.MaxDigits = config.MaxDigits
+	c.MaxSymbols = config.MaxSymbols
+	c.MaxRepeat = config.MaxRepeat
+	c.MaxAttempts = config.MaxAttempts
+	c.MaxClassRun = config.MaxClassRun
+	c.MinDistinct = config.MinDistinct
+	c.AvoidCommon = config.AvoidCommon
+	c.AlternateClasses = config.AlternateClasses
+	c.NoRepeat = config.NoRepeat
+
+	c.Words = passphrase.Words
+	c.Separator = passphrase.Separator
+	c.Capitalize = passphrase.Capitalize
+	c.WordSeparators = passphrase.Separators
+	return c
+}
+
+// writeConfig writes config as JSON when format is "json" and as YAML
+// otherwise, using the same keys as the config files.
+func writeConfig(w io.Writer, config Config, format string) error {
+	var data []byte
+	var err error
+	if format == "json" {
+		data, err = json.MarshalIndent(config, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(config)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
 func SaveConfigExample(path string) error {
 	config := Config{
-		Length:           16,
-		IncludeUpper:     true,
-		IncludeLower:     true,
-		IncludeDigits:    true,
-		IncludeSymbols:   true,
-		ExcludeAmbiguous: true,
-		Count:            1,
-		ShowStrength:     true,
-		PolicyTemplate:   "corporate",
+		Length:                   16,
+		IncludeUpper:             true,
+		IncludeLower:             true,
+		IncludeDigits:            true,
+		IncludeSymbols:           true,
+		IncludeSpace:             false,
+		ExcludeAmbiguous:         true,
+		AmbiguousChars:           pwgen.Ambiguous,
+		SymbolSet:                pwgen.DefaultSymbolSet,
+		Count:                    1,
+		ShowStrength:             true,
+		PolicyTemplate:           "corporate",
+		Passphrase:               false,
+		Words:                    4,
+		Separator:                "-",
+		Capitalize:               false,
+		Wordlist:                 "",
+		WordSeparators:           "",
+		Pronounceable:            false,
+		Hybrid:                   false,
+		Clipboard:                false,
+		RequireShow:              false,
+		PIN:                      false,
+		ForbidTrivialPIN:         false,
+		MinEntropy:               0,
+		Pattern:                  "",
+		Hex:                      0,
+		Base64:                   0,
+		Base64URL:                false,
+		MinUpper:                 0,
+		MinLower:                 0,
+		MinDigits:                0,
+		MinSymbols:               0,
+		MaxUpper:                 0,
+		MaxLower:                 0,
+		MaxDigits:                0,
+		MaxSymbols:               0,
+		MaxRepeat:                0,
+		MaxAttempts:              pwgen.DefaultMaxAttempts,
+		MaxClassRun:              0,
+		MinDistinct:              0,
+		AvoidCommon:              false,
+		AlternateClasses:         false,
+		NoRepeat:                 false,
+		Weight:                   "",
+		Format:                   "text",
+		CrackTimes:               false,
+		Lang:                     "",
+		EntropyModel:             pwgen.EntropyModelUniform,
+		EntropyPenaltyRepeated:   pwgen.DefaultPatternPenalties.Repeated,
+		EntropyPenaltySequential: pwgen.DefaultPatternPenalties.Sequential,
+		EntropyPenaltySubstrings: pwgen.DefaultPatternPenalties.Substrings,
+		EntropyPenaltyCommon:     pwgen.DefaultPatternPenalties.Common,
+		EntropyPenaltyDate:       pwgen.DefaultPatternPenalties.Date,
+		EntropyPenaltyDistinct:   pwgen.DefaultPatternPenalties.Distinct,
+		Analyzer:                 pwgen.AnalyzerBuiltin,
 	}
 
 	data, err := yaml.Marshal(config)
@@ -164,6 +941,13 @@ func SaveConfigExample(path string) error {
 # Place this file in your home directory as ~/.pwgen.yaml or in the current directory as .pwgen.yaml
 # Environment variables (PWGEN_*) will override these settings
 # Command-line flags will override both config file and environment variables
+# min_upper, min_lower, min_digits and min_symbols guarantee at least that many
+# characters of each class; max_upper, max_lower, max_digits and max_symbols cap
+# them (0 means no limit), e.g. max_symbols: 2 for "at most 2 special characters"
+# min_distinct regenerates passwords using fewer different characters (0 for any)
+# entropy_penalty_* are the multipliers, in (0, 1], applied to the entropy
+# estimate for each weak pattern found; 1 ignores the pattern
+# analyzer is the strength engine: builtin
 
 `
 
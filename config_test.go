@@ -1,9 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -176,6 +183,145 @@ count: 3`
 	}
 }
 
+func TestLoadConfigLayered(t *testing.T) {
+	homeDir := t.TempDir()
+	projectDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Chdir(projectDir)
+
+	files := map[string]string{
+		filepath.Join(homeDir, ".pwgen.yaml"): `length: 20
+include_symbols: true
+separator: "."
+policy_template: corporate`,
+		filepath.Join(homeDir, ".config", "pwgen", "config.toml"): `words = 6
+policy_template = "aws"`,
+		filepath.Join(projectDir, ".pwgen.json"): `{"count": 3, "include_symbols": false}`,
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create config directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test config file: %v", err)
+		}
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	// The partial project config keeps unrelated home settings
+	if config.Length != 20 {
+		t.Errorf("LoadConfig() Length = %d, want 20 from the home config", config.Length)
+	}
+
+	if config.Separator != "." {
+		t.Errorf("LoadConfig() Separator = %q, want . from the home config", config.Separator)
+	}
+
+	if config.Words != 6 {
+		t.Errorf("LoadConfig() Words = %d, want 6 from the XDG config", config.Words)
+	}
+
+	if config.PolicyTemplate != "aws" {
+		t.Errorf("LoadConfig() PolicyTemplate = %s, want aws (XDG overrides home)", config.PolicyTemplate)
+	}
+
+	if config.Count != 3 {
+		t.Errorf("LoadConfig() Count = %d, want 3 from the project config", config.Count)
+	}
+
+	// An explicit false in the project config still overrides the home true
+	if config.IncludeSymbols {
+		t.Error("LoadConfig() IncludeSymbols = true, want false from the project config")
+	}
+
+	// Untouched settings keep their defaults
+	if config.IncludeUpper != DefaultConfig().IncludeUpper {
+		t.Errorf("LoadConfig() IncludeUpper = %v, want the default", config.IncludeUpper)
+	}
+}
+
+func TestLoadConfigFromFileMalformed(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "broken.json")
+	if err := os.WriteFile(configPath, []byte(`{"length": 20, "count": "three"}`), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config := DefaultConfig()
+	if _, err := loadConfigFromFile(configPath, &config); err == nil {
+		t.Fatal("loadConfigFromFile() should fail for a malformed file")
+	}
+
+	if config.Length != DefaultConfig().Length {
+		t.Errorf("loadConfigFromFile() Length = %d, a malformed file should leave the config untouched", config.Length)
+	}
+}
+
+func TestLoadConfigFromFileStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string // Empty when the file should load
+	}{
+		{"typo.yaml", "lenght: 20\n", "line 1: unknown key lenght"},
+		{"typo.toml", "length = 20\ncolour = true\n", `unknown key "colour"`},
+		{"typo.json", `{"length": 20, "lenght": 3}`, `unknown field "lenght"`},
+		{"zero-length.yaml", "length: 0\n", "length must be at least 1, got 0"},
+		{"zero-count.toml", "count = 0\n", "count must be at least 1, got 0"},
+		{"zero-attempts.yaml", "max_attempts: 0\n", "max_attempts must be at least 1, got 0"},
+		{"negative.json", `{"min_digits": -1, "min_entropy": -5}`, "min_digits must not be negative, got -1\nmin_entropy must not be negative, got -5.0"},
+		{"model.yaml", "entropy_model: zxcvbn\n", "entropy_model: unknown entropy model 'zxcvbn'"},
+		{"penalty.toml", "entropy_penalty_common = 0\nentropy_penalty_date = 1.5\n", "entropy_penalty_common must be more than 0 and at most 1, got 0\nentropy_penalty_date must be more than 0 and at most 1, got 1.5"},
+		{"no-penalty.yaml", "entropy_penalty_repeated: 1\n", ""},
+		{"empty.yaml", "", ""},
+		{"comments.yaml", "# nothing set yet\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+
+			config := DefaultConfig()
+			_, err := loadConfigFromFile(configPath, &config)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("loadConfigFromFile() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadConfigFromFile() error = %v, want it to contain %q", err, tt.want)
+			}
+			if config != DefaultConfig() {
+				t.Error("loadConfigFromFile() should leave the config untouched on error")
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaultFileErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	// A typo in a default file fails loudly instead of dropping the file
+	if err := os.WriteFile(".pwgen.yaml", []byte("cuont: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	_, err := LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), ".pwgen.yaml") || !strings.Contains(err.Error(), "unknown key cuont") {
+		t.Errorf("LoadConfig() error = %v, want the file and key named", err)
+	}
+}
+
 func TestLoadConfigFromFile(t *testing.T) {
 	tempDir := t.TempDir()
 	configPath := filepath.Join(tempDir, "test-config.yaml")
@@ -192,7 +338,7 @@ count: 3`
 	}
 
 	config := DefaultConfig()
-	err = loadConfigFromFile(configPath, &config)
+	_, err = loadConfigFromFile(configPath, &config)
 	if err != nil {
 		t.Errorf("loadConfigFromFile() error = %v", err)
 	}
@@ -210,12 +356,66 @@ count: 3`
 	}
 
 	// Test with non-existent file
-	err = loadConfigFromFile("nonexistent.yaml", &config)
+	_, err = loadConfigFromFile("nonexistent.yaml", &config)
 	if err == nil {
 		t.Error("loadConfigFromFile() should return error for non-existent file")
 	}
 }
 
+func TestLoadConfigFromFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "config.toml",
+			content: `length = 20
+include_upper = false
+include_symbols = true
+policy_template = "corporate"`,
+		},
+		{
+			name:    "config.json",
+			content: `{"length": 20, "include_upper": false, "include_symbols": true, "policy_template": "corporate"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+
+			config := DefaultConfig()
+			if _, err := loadConfigFromFile(configPath, &config); err != nil {
+				t.Fatalf("loadConfigFromFile() error = %v", err)
+			}
+
+			if config.Length != 20 {
+				t.Errorf("loadConfigFromFile() Length = %d, want 20", config.Length)
+			}
+
+			if config.IncludeUpper {
+				t.Error("loadConfigFromFile() IncludeUpper = true, want false")
+			}
+
+			if !config.IncludeSymbols {
+				t.Error("loadConfigFromFile() IncludeSymbols = false, want true")
+			}
+
+			if config.PolicyTemplate != "corporate" {
+				t.Errorf("loadConfigFromFile() PolicyTemplate = %s, want corporate", config.PolicyTemplate)
+			}
+
+			// Settings missing from the file keep their defaults
+			if !config.IncludeLower {
+				t.Error("loadConfigFromFile() IncludeLower = false, want default true")
+			}
+		})
+	}
+}
+
 func TestLoadConfigFromEnvExtended(t *testing.T) {
 	// Test all environment variables
 	envVars := map[string]string{
@@ -261,6 +461,96 @@ func TestLoadConfigFromEnvExtended(t *testing.T) {
 	}
 }
 
+// --validate without --policy checks against the policy_template of the
+// loaded config, which PWGEN_POLICY_TEMPLATE overrides like any setting.
+func TestLoadConfigValidatePolicyFallback(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Chdir(t.TempDir())
+
+	if err := os.WriteFile(filepath.Join(homeDir, ".pwgen.yaml"), []byte("policy_template: corporate"), 0644); err != nil {
+		t.Fatalf("Failed to create home config: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{"config file", "", "corporate"},
+		{"environment", "high-security", "high-security"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PWGEN_POLICY_TEMPLATE", tt.env)
+
+			config, _, _, err := loadConfigSources("", "")
+			if err != nil {
+				t.Fatalf("loadConfigSources() error = %v", err)
+			}
+			if config.PolicyTemplate != tt.want {
+				t.Fatalf("loadConfigSources() PolicyTemplate = %q, want %q", config.PolicyTemplate, tt.want)
+			}
+
+			results := pwgen.ValidateAgainstPolicies("short", []string{config.PolicyTemplate})
+			if len(results[tt.want]) == 0 {
+				t.Errorf("ValidateAgainstPolicies() = %v, want violations of %s", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromEnvPassphrase(t *testing.T) {
+	envVars := map[string]string{
+		"PWGEN_PASSPHRASE":      "true",
+		"PWGEN_WORDS":           "6",
+		"PWGEN_SEPARATOR":       ".",
+		"PWGEN_CAPITALIZE":      "yes",
+		"PWGEN_WORDLIST":        "/etc/pwgen/words.txt",
+		"PWGEN_WORD_SEPARATORS": "0123456789",
+	}
+
+	for key, value := range envVars {
+		os.Setenv(key, value)
+	}
+
+	defer func() {
+		for key := range envVars {
+			os.Unsetenv(key)
+		}
+	}()
+
+	config := DefaultConfig()
+	loadConfigFromEnv(&config)
+
+	if !config.Passphrase {
+		t.Error("loadConfigFromEnv() should enable passphrase mode")
+	}
+
+	passphraseConfig := config.ToPassphraseConfig()
+
+	if passphraseConfig.Words != 6 {
+		t.Errorf("ToPassphraseConfig() Words = %d, want 6", passphraseConfig.Words)
+	}
+
+	if passphraseConfig.Separator != "." {
+		t.Errorf("ToPassphraseConfig() Separator = %s, want .", passphraseConfig.Separator)
+	}
+
+	if !passphraseConfig.Capitalize {
+		t.Error("ToPassphraseConfig() should enable capitalization")
+	}
+
+	if passphraseConfig.Separators != "0123456789" {
+		t.Errorf("ToPassphraseConfig() Separators = %s, want 0123456789", passphraseConfig.Separators)
+	}
+
+	if config.Wordlist != "/etc/pwgen/words.txt" {
+		t.Errorf("loadConfigFromEnv() Wordlist = %s, want /etc/pwgen/words.txt", config.Wordlist)
+	}
+}
+
 func TestSaveConfigExample(t *testing.T) {
 	tempDir := t.TempDir()
 	configPath := filepath.Join(tempDir, "test-config.yaml")
@@ -343,3 +633,126 @@ func contains(s, substr string) bool {
 		(len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
 			contains(s[1:], substr))))
 }
+
+func TestLoadConfigExplicitPath(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Chdir(t.TempDir())
+
+	// The default files must be ignored when a path is given
+	if err := os.WriteFile(filepath.Join(homeDir, ".pwgen.yaml"), []byte("count: 9\nseparator: ."), 0644); err != nil {
+		t.Fatalf("Failed to create home config: %v", err)
+	}
+
+	explicit := filepath.Join(t.TempDir(), "pwgen.toml")
+	if err := os.WriteFile(explicit, []byte("length = 24\ninclude_symbols = true"), 0644); err != nil {
+		t.Fatalf("Failed to create explicit config: %v", err)
+	}
+
+	t.Setenv("PWGEN_CONFIG", explicit)
+	t.Setenv("PWGEN_LENGTH", "30")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if !config.IncludeSymbols {
+		t.Error("LoadConfig() IncludeSymbols = false, want true from PWGEN_CONFIG")
+	}
+	if config.Length != 30 {
+		t.Errorf("LoadConfig() Length = %d, want 30 (environment overrides the explicit file)", config.Length)
+	}
+	if config.Count != DefaultConfig().Count || config.Separator != DefaultConfig().Separator {
+		t.Errorf("LoadConfig() Count = %d, Separator = %q, the home config should be skipped", config.Count, config.Separator)
+	}
+}
+
+func TestLoadConfigExplicitPathErrors(t *testing.T) {
+	malformed := filepath.Join(t.TempDir(), "broken.json")
+	if err := os.WriteFile(malformed, []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(t.TempDir(), "missing.yaml"), malformed} {
+		if _, _, err := LoadConfigFrom(path, ""); err == nil {
+			t.Errorf("LoadConfigFrom(%q) should fail", path)
+		}
+	}
+}
+
+func TestConfigPathFromArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-length", "20"}, ""},
+		{[]string{"-config", "a.yaml"}, "a.yaml"},
+		{[]string{"--config", "a.yaml", "-count", "2"}, "a.yaml"},
+		{[]string{"-strength", "--config=b.toml"}, "b.toml"},
+		{[]string{"-config"}, ""},
+		{[]string{"--", "-config", "a.yaml"}, ""},
+		{[]string{"-config-example", "x"}, ""},
+		// The value of an earlier flag does not end the scan
+		{[]string{"-length", "20", "-config", "a.yaml"}, "a.yaml"},
+	}
+
+	for _, tt := range tests {
+		if got := configPathFromArgs(tt.args); got != tt.want {
+			t.Errorf("configPathFromArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestWithPasswordConfig(t *testing.T) {
+	base := DefaultConfig()
+	base.Count = 7
+
+	config := base.ToPasswordConfig()
+	config.Length = 20
+	config.MinSymbols = 2
+	config.SymbolSet = "safe-url"
+	passphrase := base.ToPassphraseConfig()
+	passphrase.Words = 6
+	passphrase.Separators = "0123456789"
+
+	got := base.withPasswordConfig(config, passphrase)
+
+	if !reflect.DeepEqual(got.ToPasswordConfig(), config) {
+		t.Errorf("withPasswordConfig() ToPasswordConfig() = %+v, want %+v", got.ToPasswordConfig(), config)
+	}
+	if !reflect.DeepEqual(got.ToPassphraseConfig(), passphrase) {
+		t.Errorf("withPasswordConfig() ToPassphraseConfig() = %+v, want %+v", got.ToPassphraseConfig(), passphrase)
+	}
+	if got.Count != 7 {
+		t.Errorf("withPasswordConfig() Count = %d, want 7 kept from the base config", got.Count)
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.Length = 24
+	config.PolicyTemplate = "corporate"
+
+	for _, format := range []string{"text", "json"} {
+		var buf bytes.Buffer
+		if err := writeConfig(&buf, config, format); err != nil {
+			t.Fatalf("writeConfig(%q) error = %v", format, err)
+		}
+
+		// The output must load back as a config file
+		var got Config
+		var err error
+		if format == "json" {
+			err = json.Unmarshal(buf.Bytes(), &got)
+		} else {
+			err = yaml.Unmarshal(buf.Bytes(), &got)
+		}
+		if err != nil {
+			t.Fatalf("writeConfig(%q) output does not parse: %v\n%s", format, err, buf.String())
+		}
+		if got != config {
+			t.Errorf("writeConfig(%q) round trip = %+v, want %+v", format, got, config)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// sourceDefault and sourceCommandLine are the configSources origins of a
+// setting nothing overrides and of one changed by a flag or --policy.
+const (
+	sourceDefault     = "default"
+	sourceCommandLine = "command line"
+)
+
+// configSources maps each config file key to where its value came from, for
+// --explain-config: "default", the path of a config file, "profile NAME",
+// "env PWGEN_KEY" or "command line".
+type configSources map[string]string
+
+// newConfigSources returns sources with every setting at its default.
+func newConfigSources() configSources {
+	sources := make(configSources)
+	for _, key := range configKeys() {
+		sources[key] = sourceDefault
+	}
+	return sources
+}
+
+// set records origin for each of keys.
+func (s configSources) set(keys []string, origin string) {
+	for _, key := range keys {
+		s[key] = origin
+	}
+}
+
+// withCommandLine returns s with the settings that differ between loaded,
+// the config before flags, and resolved, the config after them, attributed
+// to the command line.
+func (s configSources) withCommandLine(loaded, resolved Config) configSources {
+	sources := make(configSources, len(s))
+	loadedValues, resolvedValues := configValues(loaded), configValues(resolved)
+	for key, origin := range s {
+		if !reflect.DeepEqual(loadedValues[key], resolvedValues[key]) {
+			origin = sourceCommandLine
+		}
+		sources[key] = origin
+	}
+	return sources
+}
+
+// configKeys returns the config file keys of Config in field order.
+func configKeys() []string {
+	configType := reflect.TypeFor[Config]()
+	keys := make([]string, configType.NumField())
+	for i := range keys {
+		keys[i] = configType.Field(i).Tag.Get("yaml")
+	}
+	return keys
+}
+
+// configValues returns the settings of config by config file key.
+func configValues(config Config) map[string]any {
+	value := reflect.ValueOf(config)
+	values := make(map[string]any, value.NumField())
+	for i, key := range configKeys() {
+		values[key] = value.Field(i).Interface()
+	}
+	return values
+}
+
+// envVarName returns the environment variable loadConfigFromEnv reads for
+// the config file key, e.g. PWGEN_MAX_REPEAT for max_repeat.
+func envVarName(key string) string {
+	return "PWGEN_" + strings.ToUpper(key)
+}
+
+// envConfigKeys returns the config file keys loadConfigFromEnv takes from
+// the environment: those whose variable is set to a value it accepts, as
+// numbers and booleans that do not parse are ignored.
+func envConfigKeys() []string {
+	var keys []string
+	fields := reflect.ValueOf(Config{})
+	for i, key := range configKeys() {
+		val := os.Getenv(envVarName(key))
+		if val == "" {
+			continue
+		}
+
+		accepted := true
+		switch fields.Field(i).Kind() {
+		case reflect.Int:
+			_, err := strconv.Atoi(val)
+			accepted = err == nil
+		case reflect.Float64:
+			_, err := strconv.ParseFloat(val, 64)
+			accepted = err == nil
+		case reflect.Bool:
+			accepted = parseBool(val, true) == parseBool(val, false)
+		}
+		if accepted {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// configFileKeys returns the settings the config file at path contains,
+// whether or not they differ from the value they override. It is only
+// called on files loadConfigFromFile has accepted.
+func configFileKeys(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	settings := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		_, err = toml.Decode(string(data), &settings)
+	case ".json":
+		err = json.Unmarshal(data, &settings)
+	default:
+		err = yaml.Unmarshal(data, &settings)
+	}
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	for _, key := range configKeys() {
+		if _, ok := settings[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// configSource is a setting as written by --explain-config --format json.
+type configSource struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// writeConfigSources writes every setting of config with its value and
+// origin, as a JSON array when format is "json" and as aligned columns
+// otherwise.
+func writeConfigSources(w io.Writer, config Config, sources configSources, format string) error {
+	values := configValues(config)
+	settings := make([]configSource, 0, len(values))
+	for _, key := range configKeys() {
+		settings = append(settings, configSource{Key: key, Value: values[key], Source: sources[key]})
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(settings)
+	}
+
+	keyWidth, valueWidth := len("Setting"), len("Value")
+	texts := make([]string, len(settings))
+	for i, setting := range settings {
+		texts[i] = fmt.Sprint(setting.Value)
+		if text, ok := setting.Value.(string); ok {
+			texts[i] = strconv.Quote(text)
+		}
+		keyWidth = max(keyWidth, len(setting.Key))
+		valueWidth = max(valueWidth, utf8.RuneCountInString(texts[i]))
+	}
+
+	if _, err := fmt.Fprintf(w, "%-*s  %-*s  %s\n", keyWidth, "Setting", valueWidth, "Value", "Source"); err != nil {
+		return err
+	}
+	for i, setting := range settings {
+		if _, err := fmt.Fprintf(w, "%-*s  %-*s  %s\n", keyWidth, setting.Key, valueWidth, texts[i], setting.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigSources(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "pwgen.yaml")
+	// include_upper matches the default but is still set by the file
+	content := "length: 20\ninclude_upper: true\nsymbol_set: safe-url\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+	t.Setenv("PWGEN_COUNT", "3")
+	t.Setenv("PWGEN_WORDS", "many")      // Not a number, so ignored
+	t.Setenv("PWGEN_CAPITALIZE", "sure") // Not a boolean, so ignored
+
+	config, _, sources, err := loadConfigSources(configPath, "wifi")
+	if err != nil {
+		t.Fatalf("loadConfigSources() error = %v", err)
+	}
+
+	want := map[string]string{
+		"length":            "profile wifi", // The profile overrides the file
+		"include_upper":     "profile wifi",
+		"symbol_set":        configPath,
+		"count":             "env PWGEN_COUNT",
+		"words":             "default",
+		"capitalize":        "default",
+		"exclude_ambiguous": "profile wifi",
+		"min_entropy":       "default",
+	}
+	for key, origin := range want {
+		if sources[key] != origin {
+			t.Errorf("loadConfigSources() source of %s = %q, want %q", key, sources[key], origin)
+		}
+	}
+	if config.Count != 3 || config.Length != 16 || config.SymbolSet != "safe-url" {
+		t.Errorf("loadConfigSources() config = %+v, want the layers applied", config)
+	}
+	if len(sources) != len(configKeys()) {
+		t.Errorf("loadConfigSources() has %d sources, want one per setting (%d)", len(sources), len(configKeys()))
+	}
+
+	// Without a profile the file's value of the same setting shows through
+	_, _, sources, err = loadConfigSources(configPath, "")
+	if err != nil {
+		t.Fatalf("loadConfigSources() error = %v", err)
+	}
+	if sources["length"] != configPath || sources["include_upper"] != configPath {
+		t.Errorf("loadConfigSources() sources = %v, want length and include_upper from the file", sources)
+	}
+}
+
+func TestEnvConfigKeys(t *testing.T) {
+	// Every variable that loadConfigFromEnv applies must be reported, and
+	// only those
+	defaults := DefaultConfig()
+	values := configValues(defaults)
+	for _, key := range configKeys() {
+		var val string
+		switch value := values[key].(type) {
+		case int:
+			val = fmt.Sprint(value + 1)
+		case float64:
+			val = fmt.Sprint(value + 1)
+		case bool:
+			val = fmt.Sprint(!value)
+		case string:
+			val = value + "x"
+		default:
+			t.Fatalf("setting %s has unexpected type %T", key, value)
+		}
+		t.Setenv(envVarName(key), val)
+	}
+
+	config := defaults
+	loadConfigFromEnv(&config)
+	changed := configValues(config)
+	for _, key := range configKeys() {
+		if reflect.DeepEqual(changed[key], values[key]) {
+			t.Errorf("loadConfigFromEnv() ignores %s, or envVarName() names the wrong variable", envVarName(key))
+		}
+	}
+	if keys := envConfigKeys(); !slices.Equal(keys, configKeys()) {
+		t.Errorf("envConfigKeys() = %v, want every setting", keys)
+	}
+
+	t.Setenv("PWGEN_LENGTH", "long")
+	t.Setenv("PWGEN_PIN", "perhaps")
+	t.Setenv("PWGEN_MIN_ENTROPY", "")
+	keys := envConfigKeys()
+	for _, key := range []string{"length", "pin", "min_entropy"} {
+		if slices.Contains(keys, key) {
+			t.Errorf("envConfigKeys() reports %s, which loadConfigFromEnv ignores", key)
+		}
+	}
+}
+
+func TestConfigFileKeys(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml": "length: 20\nprofiles:\n  mine:\n    count: 2\n",
+		"a.toml": "length = 20\n[profiles.mine]\ncount = 2\n",
+		"a.json": `{"length": 20, "profiles": {"mine": {"count": 2}}}`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if keys := configFileKeys(path); !slices.Equal(keys, []string{"length"}) {
+			t.Errorf("configFileKeys(%s) = %v, want [length]", name, keys)
+		}
+	}
+}
+
+func TestConfigSourcesWithCommandLine(t *testing.T) {
+	loaded := DefaultConfig()
+	sources := newConfigSources()
+	sources["count"] = "env PWGEN_COUNT"
+
+	resolved := loaded
+	resolved.Length = 24
+	got := sources.withCommandLine(loaded, resolved)
+	if got["length"] != sourceCommandLine || got["count"] != "env PWGEN_COUNT" || got["words"] != sourceDefault {
+		t.Errorf("withCommandLine() = %v", got)
+	}
+	if sources["length"] != sourceDefault {
+		t.Error("withCommandLine() should leave the receiver untouched")
+	}
+}
+
+func TestWriteConfigSources(t *testing.T) {
+	config := DefaultConfig()
+	config.Length = 20
+	sources := newConfigSources()
+	sources["length"] = "/etc/pwgen.yaml"
+
+	var out bytes.Buffer
+	if err := writeConfigSources(&out, config, sources, "text"); err != nil {
+		t.Fatalf("writeConfigSources() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(configKeys())+1 || !strings.HasPrefix(lines[0], "Setting ") {
+		t.Fatalf("writeConfigSources() = %q, want a header and a line per setting", out.String())
+	}
+	if fields := strings.Fields(lines[1]); !slices.Equal(fields, []string{"length", "20", "/etc/pwgen.yaml"}) {
+		t.Errorf("writeConfigSources() first line = %q", lines[1])
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "symbol_set ") {
+			continue
+		}
+		if fields := strings.Fields(line); !slices.Equal(fields, []string{"symbol_set", `"common"`, "default"}) {
+			t.Errorf("writeConfigSources() symbol_set line = %q, want the string quoted", line)
+		}
+		if strings.Index(line, "default") != strings.Index(lines[0], "Source") {
+			t.Errorf("writeConfigSources() = %q, want the sources aligned", out.String())
+		}
+	}
+
+	out.Reset()
+	if err := writeConfigSources(&out, config, sources, "json"); err != nil {
+		t.Fatalf("writeConfigSources() error = %v", err)
+	}
+	var settings []configSource
+	if err := json.Unmarshal(out.Bytes(), &settings); err != nil {
+		t.Fatalf("writeConfigSources() JSON does not parse: %v", err)
+	}
+	if settings[0].Key != "length" || settings[0].Value != 20.0 || settings[0].Source != "/etc/pwgen.yaml" {
+		t.Errorf("writeConfigSources() JSON first setting = %+v", settings[0])
+	}
+}
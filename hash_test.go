@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestKDFHashBcrypt(t *testing.T) {
+	hash, err := kdfHash("S3cret!pass", hashOptions{Algorithm: "bcrypt", Cost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatalf("kdfHash() error = %v", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("S3cret!pass")); err != nil {
+		t.Errorf("kdfHash() = %q does not verify: %v", hash, err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != bcrypt.MinCost {
+		t.Errorf("kdfHash() cost = %d, want %d", cost, bcrypt.MinCost)
+	}
+}
+
+func TestKDFHashArgon2id(t *testing.T) {
+	options := hashOptions{Algorithm: "argon2id", ArgonTime: 1, ArgonMemory: 64, ArgonParallelism: 2}
+	hash, err := kdfHash("S3cret!pass", options)
+	if err != nil {
+		t.Fatalf("kdfHash() error = %v", err)
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" || parts[2] != fmt.Sprintf("v=%d", argon2.Version) || parts[3] != "m=64,t=1,p=2" {
+		t.Fatalf("kdfHash() = %q, want $argon2id$v=19$m=64,t=1,p=2$salt$hash", hash)
+	}
+
+	salt := decodeHashField(t, parts[4])
+	want := argon2.IDKey([]byte("S3cret!pass"), salt, 1, 64, 2, hashKeyLength)
+	if got := decodeHashField(t, parts[5]); string(got) != string(want) {
+		t.Errorf("kdfHash() = %q does not verify", hash)
+	}
+}
+
+func TestKDFHashScrypt(t *testing.T) {
+	hash, err := kdfHash("S3cret!pass", hashOptions{Algorithm: "scrypt"})
+	if err != nil {
+		t.Fatalf("kdfHash() error = %v", err)
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[1] != "scrypt" || parts[2] != fmt.Sprintf("ln=%d,r=%d,p=%d", scryptLogN, scryptR, scryptP) {
+		t.Fatalf("kdfHash() = %q, want $scrypt$ln=15,r=8,p=1$salt$hash", hash)
+	}
+
+	salt := decodeHashField(t, parts[3])
+	want, err := scrypt.Key([]byte("S3cret!pass"), salt, 1<<scryptLogN, scryptR, scryptP, hashKeyLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeHashField(t, parts[4]); string(got) != string(want) {
+		t.Errorf("kdfHash() = %q does not verify", hash)
+	}
+}
+
+func TestKDFHashUsesFreshSalt(t *testing.T) {
+	options := hashOptions{Algorithm: "argon2id", ArgonTime: 1, ArgonMemory: 64, ArgonParallelism: 1}
+	first, err := kdfHash("same", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := kdfHash("same", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Error("kdfHash() returned the same hash twice, the salt is not random")
+	}
+}
+
+func TestValidateHashOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options hashOptions
+		wantErr bool
+	}{
+		{"bcrypt", hashOptions{Algorithm: "bcrypt", Cost: bcrypt.DefaultCost}, false},
+		{"bcrypt cost too low", hashOptions{Algorithm: "bcrypt", Cost: 3}, true},
+		{"bcrypt cost too high", hashOptions{Algorithm: "bcrypt", Cost: 32}, true},
+		{"argon2id", hashOptions{Algorithm: "argon2id", ArgonTime: 3, ArgonMemory: defaultArgonMemory, ArgonParallelism: 4}, false},
+		{"argon2id zero time", hashOptions{Algorithm: "argon2id", ArgonTime: 0, ArgonMemory: 1024, ArgonParallelism: 1}, true},
+		{"argon2id zero parallelism", hashOptions{Algorithm: "argon2id", ArgonTime: 1, ArgonMemory: 1024}, true},
+		{"argon2id too little memory", hashOptions{Algorithm: "argon2id", ArgonTime: 1, ArgonMemory: 31, ArgonParallelism: 4}, true},
+		{"scrypt", hashOptions{Algorithm: "scrypt"}, false},
+		{"unknown", hashOptions{Algorithm: "md5"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHashOptions(tt.options); (err != nil) != tt.wantErr {
+				t.Errorf("validateHashOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func decodeHashField(t *testing.T, field string) []byte {
+	t.Helper()
+	data, err := base64.RawStdEncoding.DecodeString(field)
+	if err != nil {
+		t.Fatalf("invalid base64 %q: %v", field, err)
+	}
+	return data
+}
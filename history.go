@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// historySaltSize is the length in bytes of the random salt stored with each
+// history entry.
+const historySaltSize = 16
+
+// historyEntry is one line of a history file: a random salt and the SHA-256
+// of the salt followed by the password. The password itself is never stored.
+type historyEntry struct {
+	salt []byte
+	hash []byte
+}
+
+// passwordHistory is the set of previously issued passwords read from a
+// --history file. Unless readOnly, new passwords are appended to the file.
+type passwordHistory struct {
+	mu       sync.Mutex // Serializes claim for the --jobs workers
+	path     string
+	readOnly bool
+	entries  []historyEntry
+}
+
+// loadHistory reads the history file at path, one "salt:hash" entry per line
+// in hex. A file that does not exist yet is an empty history.
+func loadHistory(path string, readOnly bool) (*passwordHistory, error) {
+	history := &passwordHistory{path: path, readOnly: readOnly}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry, err := parseHistoryEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		history.entries = append(history.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func parseHistoryEntry(text string) (historyEntry, error) {
+	saltHex, hashHex, ok := strings.Cut(text, ":")
+	if !ok {
+		return historyEntry{}, errors.New("expected salt:hash")
+	}
+
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil || len(salt) == 0 {
+		return historyEntry{}, errors.New("invalid salt")
+	}
+
+	hash, err := hex.DecodeString(hashHex)
+	if err != nil || len(hash) != sha256.Size {
+		return historyEntry{}, errors.New("invalid hash")
+	}
+
+	return historyEntry{salt: salt, hash: hash}, nil
+}
+
+func hashPassword(salt []byte, password string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(password))
+	return h.Sum(nil)
+}
+
+// contains reports whether password was issued before. Each entry has its
+// own salt, so the password is hashed once per entry.
+func (h *passwordHistory) contains(password string) bool {
+	for _, entry := range h.entries {
+		if subtle.ConstantTimeCompare(hashPassword(entry.salt, password), entry.hash) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// add records password under a fresh salt, appending it to the file unless
+// the history is read-only. It is always remembered in memory so a batch
+// does not repeat itself either way.
+func (h *passwordHistory) add(password string) error {
+	salt := make([]byte, historySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	entry := historyEntry{salt: salt, hash: hashPassword(salt, password)}
+	h.entries = append(h.entries, entry)
+
+	if h.readOnly {
+		return nil
+	}
+
+	file, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(file, "%x:%x\n", entry.salt, entry.hash); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// claim adds password to the history and reports true unless it was
+// issued before. Checking and adding happen under one lock, so two --jobs
+// workers cannot both claim the same password.
+func (h *passwordHistory) claim(password string) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.contains(password) {
+		return false, nil
+	}
+	return true, h.add(password)
+}
+
+// historyGenerator returns passwords from generate, regenerating any found
+// in the history and recording each one it returns. It gives up after
+// attempts tries, pwgen.DefaultMaxAttempts when zero.
+type historyGenerator struct {
+	generate func() (string, error)
+	history  *passwordHistory
+	attempts int
+}
+
+func (g *historyGenerator) next() (string, error) {
+	for attempt := 0; attempt < attemptLimit(g.attempts); attempt++ {
+		password, err := g.generate()
+		if err != nil {
+			return "", err
+		}
+
+		claimed, err := g.history.claim(password)
+		if err != nil {
+			return "", fmt.Errorf("could not update history file: %w", err)
+		}
+		if claimed {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w after %d attempts: every password generated is already in the history, the length and charset allow too few distinct passwords", pwgen.ErrMaxAttempts, attemptLimit(g.attempts))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestPasswordHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	history, err := loadHistory(path, false)
+	if err != nil {
+		t.Fatalf("loadHistory() on a missing file error = %v", err)
+	}
+	if err := history.add("S3cret!pass"); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("history file not written: %v", err)
+	}
+	if strings.Contains(string(data), "S3cret!pass") {
+		t.Error("history file contains the plaintext password")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("history file mode = %o, want 600", perm)
+	}
+
+	reloaded, err := loadHistory(path, false)
+	if err != nil {
+		t.Fatalf("loadHistory() error = %v", err)
+	}
+	if !reloaded.contains("S3cret!pass") {
+		t.Error("contains() = false for a recorded password")
+	}
+	if reloaded.contains("other") {
+		t.Error("contains() = true for a password never recorded")
+	}
+}
+
+func TestPasswordHistoryReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	history, err := loadHistory(path, true)
+	if err != nil {
+		t.Fatalf("loadHistory() error = %v", err)
+	}
+	if err := history.add("abc"); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	if !history.contains("abc") {
+		t.Error("read-only history should still remember passwords in memory")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("read-only history should not create the file")
+	}
+}
+
+func TestLoadHistoryMalformed(t *testing.T) {
+	tests := []string{
+		"nocolon\n",
+		"zz:" + strings.Repeat("00", 32) + "\n",
+		"00:0011\n",
+	}
+
+	for _, content := range tests {
+		path := filepath.Join(t.TempDir(), "history")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadHistory(path, true); err == nil {
+			t.Errorf("loadHistory(%q) should fail", content)
+		}
+	}
+}
+
+func TestHistoryGenerator(t *testing.T) {
+	// "ab" at length 2 has exactly 4 passwords, so collisions are certain
+	config := pwgen.Config{Length: 2, Charset: "ab"}
+	generator := pwgen.NewSeededGenerator("history")
+	generate := func() (string, error) {
+		return generator.Generate(config)
+	}
+
+	path := filepath.Join(t.TempDir(), "history")
+	history, err := loadHistory(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := &historyGenerator{generate: generate, history: history}
+	for i := 0; i < 3; i++ {
+		if _, err := first.next(); err != nil {
+			t.Fatalf("next() error = %v", err)
+		}
+	}
+
+	// A later run only has the one password left that was never issued
+	history, err = loadHistory(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := &historyGenerator{generate: generate, history: history}
+	password, err := second.next()
+	if err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+	if len(history.entries) != 4 {
+		t.Errorf("history has %d entries, want 4", len(history.entries))
+	}
+
+	if _, err := second.next(); err == nil {
+		t.Errorf("next() should fail once every password is in the history, last was %q", password)
+	}
+}
+
+func TestPasswordHistoryClaim(t *testing.T) {
+	history := &passwordHistory{readOnly: true}
+	if claimed, err := history.claim("secret"); !claimed || err != nil {
+		t.Fatalf("claim() = %v, %v for a new password, want true", claimed, err)
+	}
+	if claimed, err := history.claim("secret"); claimed || err != nil {
+		t.Errorf("claim() = %v, %v for a password in the history, want false", claimed, err)
+	}
+}
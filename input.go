@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// readPasswordFromStdin reads a password from standard input. A terminal is
+// prompted without echoing the input; piped input is read up to the first
+// newline.
+func readPasswordFromStdin() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		if len(password) == 0 {
+			return "", errors.New("no password entered")
+		}
+		return string(password), nil
+	}
+
+	return readPassword(os.Stdin)
+}
+
+// readPassword returns the first line of r with only its line ending
+// removed, so leading, trailing and internal spaces are kept.
+func readPassword(r io.Reader) (string, error) {
+	return readPasswordLine(bufio.NewReader(r))
+}
+
+// readPasswordsFromStdin reads n passwords from standard input, prompting
+// a terminal for each without echoing it, or reading n lines when piped.
+func readPasswordsFromStdin(n int) ([]string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readPasswords(os.Stdin, n)
+	}
+
+	passwords := make([]string, n)
+	for i := range passwords {
+		fmt.Fprintf(os.Stderr, "Password %d: ", i+1)
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password: %w", err)
+		}
+		if len(password) == 0 {
+			return nil, errors.New("no password entered")
+		}
+		passwords[i] = string(password)
+	}
+	return passwords, nil
+}
+
+// readPasswords returns the first n lines of r, each as readPassword would.
+func readPasswords(r io.Reader, n int) ([]string, error) {
+	reader := bufio.NewReader(r)
+	passwords := make([]string, n)
+	for i := range passwords {
+		password, err := readPasswordLine(reader)
+		if err != nil {
+			return nil, fmt.Errorf("password %d: %w", i+1, err)
+		}
+		passwords[i] = password
+	}
+	return passwords, nil
+}
+
+func readPasswordLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	if line == "" {
+		return "", errors.New("no password on stdin")
+	}
+
+	return line, nil
+}
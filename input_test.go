@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "trailing newline", input: "S3cret!\n", want: "S3cret!"},
+		{name: "windows line ending", input: "S3cret!\r\n", want: "S3cret!"},
+		{name: "no newline", input: "S3cret!", want: "S3cret!"},
+		{name: "whitespace kept", input: " two words \n", want: " two words "},
+		{name: "only first line", input: "first\nsecond\n", want: "first"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "blank line", input: "\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPassword(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPasswords(t *testing.T) {
+	got, err := readPasswords(strings.NewReader("first\r\n second \nthird\n"), 2)
+	if err != nil {
+		t.Fatalf("readPasswords() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != "first" || got[1] != " second " {
+		t.Errorf("readPasswords() = %q, want [first  second ]", got)
+	}
+
+	if _, err := readPasswords(strings.NewReader("only\n"), 2); err == nil || err.Error() != "password 2: no password on stdin" {
+		t.Errorf("readPasswords() with one line error = %v, want password 2: no password on stdin", err)
+	}
+}
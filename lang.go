@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// feedbackLanguage picks the language for strength feedback. An explicit
+// --lang or PWGEN_LANG must be supported; otherwise the locale from LC_ALL,
+// LC_MESSAGES or LANG is used when it has a catalog, falling back to English.
+func feedbackLanguage(lang string) (string, error) {
+	if lang != "" {
+		code := languageFromLocale(lang)
+		if !pwgen.IsSupportedLanguage(code) {
+			return "", fmt.Errorf("unsupported language '%s' (available: %s)", lang, strings.Join(pwgen.Languages(), ", "))
+		}
+		return code, nil
+	}
+
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			if code := languageFromLocale(locale); pwgen.IsSupportedLanguage(code) {
+				return code, nil
+			}
+			break // The first locale variable set wins, like setlocale
+		}
+	}
+
+	return pwgen.DefaultLanguage, nil
+}
+
+// languageFromLocale reduces a locale such as "fr_BE.UTF-8" or "fr-BE" to
+// its language code.
+func languageFromLocale(locale string) string {
+	code := strings.ToLower(locale)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	return code
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestLanguageFromLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"fr", "fr"},
+		{"fr_BE.UTF-8", "fr"},
+		{"fr-BE", "fr"},
+		{"EN_US", "en"},
+		{"de_DE@euro", "de"},
+		{"C", "c"},
+	}
+
+	for _, tt := range tests {
+		if got := languageFromLocale(tt.locale); got != tt.want {
+			t.Errorf("languageFromLocale(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestFeedbackLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "en"},
+		{name: "explicit", lang: "fr", want: "fr"},
+		{name: "explicit locale", lang: "fr_FR.UTF-8", want: "fr"},
+		{name: "explicit unsupported", lang: "xx", wantErr: true},
+		{name: "LANG", env: map[string]string{"LANG": "fr_BE.UTF-8"}, want: "fr"},
+		{name: "LC_ALL wins over LANG", env: map[string]string{"LC_ALL": "en_US.UTF-8", "LANG": "fr_FR.UTF-8"}, want: "en"},
+		{name: "unsupported locale", env: map[string]string{"LANG": "de_DE.UTF-8"}, want: "en"},
+		{name: "explicit wins over locale", lang: "en", env: map[string]string{"LANG": "fr_FR.UTF-8"}, want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			got, err := feedbackLanguage(tt.lang)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("feedbackLanguage(%q) error = %v, wantErr %v", tt.lang, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("feedbackLanguage(%q) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
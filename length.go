@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// lengthRange is the value of --length: a single length, or an inclusive
+// range such as 12-20 from which each password picks its own length.
+type lengthRange struct {
+	min, max int
+}
+
+func (r *lengthRange) String() string {
+	if r.min == r.max {
+		return strconv.Itoa(r.min)
+	}
+	return fmt.Sprintf("%d-%d", r.min, r.max)
+}
+
+// Set parses a length or a min-max range. A single length is accepted as
+// is and validated later like before, while a range must be 1 or more and
+// in order.
+func (r *lengthRange) Set(value string) error {
+	low, high, isRange := strings.Cut(value, "-")
+	if !isRange || low == "" {
+		length, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid length %q: use a number or a range like 12-20", value)
+		}
+		r.min, r.max = length, length
+		return nil
+	}
+
+	minLength, err := strconv.Atoi(low)
+	if err != nil {
+		return fmt.Errorf("invalid length range %q: use a range like 12-20", value)
+	}
+	maxLength, err := strconv.Atoi(high)
+	if err != nil {
+		return fmt.Errorf("invalid length range %q: use a range like 12-20", value)
+	}
+
+	if minLength < 1 {
+		return fmt.Errorf("invalid length range %q: lengths must be at least 1", value)
+	}
+	if minLength > maxLength {
+		return fmt.Errorf("invalid length range %q: the minimum is more than the maximum", value)
+	}
+
+	r.min, r.max = minLength, maxLength
+	return nil
+}
+
+// isRange reports whether passwords get different lengths.
+func (r lengthRange) isRange() bool {
+	return r.max > r.min
+}
+
+// clamp fits the range within a policy's minimum and maximum length, the
+// way ApplyPolicyToConfig adjusts a single length. maxLength 0 means no
+// maximum.
+func (r lengthRange) clamp(minLength, maxLength int) lengthRange {
+	r.min = max(r.min, minLength)
+	r.max = max(r.max, r.min)
+	if maxLength > 0 {
+		r.max = min(r.max, maxLength)
+		r.min = min(r.min, r.max)
+	}
+	return r
+}
+
+// clampWarning explains how clamp changed a requested --length to fit a
+// policy, or returns "" when it did not.
+func clampWarning(requested, clamped lengthRange, policyName string, minLength, maxLength int) string {
+	if requested == clamped {
+		return ""
+	}
+
+	var reasons []string
+	if requested.min < minLength {
+		reasons = append(reasons, fmt.Sprintf("needs at least %d characters", minLength))
+	}
+	if maxLength > 0 && requested.max > maxLength {
+		reasons = append(reasons, fmt.Sprintf("allows at most %d characters", maxLength))
+	}
+	return fmt.Sprintf("Warning: --length %s changed to %s, as %s %s",
+		requested.String(), clamped.String(), policyName, strings.Join(reasons, " and "))
+}
+
+// random picks a length uniformly from the range with the random source of
+// generator, so a --seed batch repeats its lengths along with its passwords.
+func (r lengthRange) random(generator *pwgen.Generator) (int, error) {
+	n, err := generator.RandomInt(r.max - r.min + 1)
+	if err != nil {
+		return 0, err
+	}
+	return r.min + n, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/rand"
+	"slices"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestLengthRangeSet(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    lengthRange
+		wantErr bool
+	}{
+		{"16", lengthRange{16, 16}, false},
+		{"12-20", lengthRange{12, 20}, false},
+		{"8-8", lengthRange{8, 8}, false},
+		{"-5", lengthRange{-5, -5}, false}, // Rejected later by ValidateLength, as before
+		{"20-12", lengthRange{}, true},
+		{"0-4", lengthRange{}, true},
+		{"12-", lengthRange{}, true},
+		{"a-b", lengthRange{}, true},
+		{"twelve", lengthRange{}, true},
+	}
+
+	for _, tt := range tests {
+		var got lengthRange
+		err := got.Set(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Set(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLengthRangeString(t *testing.T) {
+	if got := (&lengthRange{12, 12}).String(); got != "12" {
+		t.Errorf("String() = %q, want 12", got)
+	}
+	if got := (&lengthRange{12, 20}).String(); got != "12-20" {
+		t.Errorf("String() = %q, want 12-20", got)
+	}
+}
+
+func TestLengthRangeClamp(t *testing.T) {
+	tests := []struct {
+		r                    lengthRange
+		minLength, maxLength int
+		want                 lengthRange
+	}{
+		{lengthRange{8, 20}, 12, 0, lengthRange{12, 20}},
+		{lengthRange{8, 20}, 0, 16, lengthRange{8, 16}},
+		{lengthRange{8, 10}, 12, 0, lengthRange{12, 12}},
+		{lengthRange{30, 40}, 8, 16, lengthRange{16, 16}},
+		{lengthRange{12, 12}, 8, 128, lengthRange{12, 12}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.clamp(tt.minLength, tt.maxLength); got != tt.want {
+			t.Errorf("%+v.clamp(%d, %d) = %+v, want %+v", tt.r, tt.minLength, tt.maxLength, got, tt.want)
+		}
+	}
+}
+
+func TestClampWarning(t *testing.T) {
+	tests := []struct {
+		requested            lengthRange
+		minLength, maxLength int
+		want                 string
+	}{
+		{lengthRange{16, 16}, 8, 256, ""},
+		{lengthRange{300, 300}, 8, 256, "Warning: --length 300 changed to 256, as Azure AD Policy allows at most 256 characters"},
+		{lengthRange{6, 6}, 8, 0, "Warning: --length 6 changed to 8, as Azure AD Policy needs at least 8 characters"},
+		{lengthRange{4, 300}, 8, 256, "Warning: --length 4-300 changed to 8-256, as Azure AD Policy needs at least 8 characters and allows at most 256 characters"},
+	}
+
+	for _, tt := range tests {
+		clamped := tt.requested.clamp(tt.minLength, tt.maxLength)
+		if got := clampWarning(tt.requested, clamped, "Azure AD Policy", tt.minLength, tt.maxLength); got != tt.want {
+			t.Errorf("clampWarning(%v, %v) = %q, want %q", tt.requested, clamped, got, tt.want)
+		}
+	}
+}
+
+func TestLengthRangeRandom(t *testing.T) {
+	r := lengthRange{12, 15}
+	seen := make(map[int]bool)
+
+	generator := pwgen.NewGenerator(rand.Reader)
+	for i := 0; i < 1000; i++ {
+		length, err := r.random(generator)
+		if err != nil {
+			t.Fatalf("random() error = %v", err)
+		}
+		if length < r.min || length > r.max {
+			t.Fatalf("random() = %d, outside %d-%d", length, r.min, r.max)
+		}
+		seen[length] = true
+	}
+
+	if len(seen) != 4 {
+		t.Errorf("random() produced lengths %v, want every length from 12 to 15", seen)
+	}
+}
+
+func TestLengthRangeRandomSeeded(t *testing.T) {
+	r := lengthRange{8, 20}
+	lengths := func(seed string) []int {
+		generator := pwgen.NewSeededGenerator(seed)
+		var result []int
+		for i := 0; i < 10; i++ {
+			length, err := r.random(generator)
+			if err != nil {
+				t.Fatalf("random() error = %v", err)
+			}
+			result = append(result, length)
+		}
+		return result
+	}
+
+	if first, second := lengths("abc"), lengths("abc"); !slices.Equal(first, second) {
+		t.Errorf("random() gave lengths %v then %v for the same seed, want them repeated", first, second)
+	}
+}
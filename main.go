@@ -2,37 +2,40 @@ package main
 
 import (
 	"crypto/rand"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"math/big"
+	"maps"
+	"math"
 	"os"
+	"runtime"
+	"slices"
 	"strings"
-)
-
-type PasswordConfig struct {
-	Length           int
-	IncludeUpper     bool
-	IncludeLower     bool
-	IncludeDigits    bool
-	IncludeSymbols   bool
-	ExcludeAmbiguous bool
-}
+	"unicode/utf8"
 
-const (
-	LowerCase = "abcdefghijklmnopqrstuvwxyz"
-	UpperCase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	Digits    = "0123456789"
-	Symbols   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
-	Ambiguous = "0O1lI"
+	"github.com/romdj/password-generator/pkg/pwgen"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
-	// Load configuration from files and environment
-	baseConfig, err := LoadConfig()
+	// Load configuration from files and environment. --config wins over
+	// PWGEN_CONFIG, and either replaces the default file search. Likewise
+	// --profile wins over PWGEN_PROFILE.
+	configPath := configPathFromArgs(os.Args[1:])
+	if configPath == "" {
+		configPath = os.Getenv("PWGEN_CONFIG")
+	}
+	profileName := flagValueFromArgs(os.Args[1:], "profile")
+	if profileName == "" {
+		profileName = os.Getenv("PWGEN_PROFILE")
+	}
+
+	baseConfig, profiles, sources, err := loadConfigSources(configPath, profileName)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not load config: %v\n", err)
-		baseConfig = DefaultConfig()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Convert to PasswordConfig for compatibility
@@ -40,10 +43,32 @@ func main() {
 	count := baseConfig.Count
 	showStrength := baseConfig.ShowStrength
 	policyTemplate := baseConfig.PolicyTemplate
+	passphrase := baseConfig.Passphrase
+	passphraseConfig := baseConfig.ToPassphraseConfig()
+	pronounceable := baseConfig.Pronounceable
+	hybrid := baseConfig.Hybrid
+	clipboard := baseConfig.Clipboard
+	pin := baseConfig.PIN
+	forbidTrivialPIN := baseConfig.ForbidTrivialPIN
+	guessRate := baseConfig.GuessRate
+	minEntropy := baseConfig.MinEntropy
+	pattern := baseConfig.Pattern
+	hexBytes := baseConfig.Hex
+	base64Bytes := baseConfig.Base64
+	base64URL := baseConfig.Base64URL
+	format := baseConfig.Format
+	crackTimes := baseConfig.CrackTimes
+	lang := baseConfig.Lang
+	weight := baseConfig.Weight
+	entropyModel := baseConfig.EntropyModel
+	analyzerName := baseConfig.Analyzer
+	patternPenalties := baseConfig.ToPatternPenalties()
+	wordlistPath := baseConfig.Wordlist
 
 	// Command line flags override config
-	flag.IntVar(&config.Length, "length", config.Length, "Password length")
-	flag.IntVar(&config.Length, "l", config.Length, "Password length (short)")
+	lengths := lengthRange{min: config.Length, max: config.Length}
+	flag.Var(&lengths, "length", "Password `length`, or a range like 12-20 to give each password a random length in it")
+	flag.Var(&lengths, "l", "Password `length` or range (short)")
 	flag.BoolVar(&config.IncludeUpper, "upper", config.IncludeUpper, "Include uppercase letters")
 	flag.BoolVar(&config.IncludeUpper, "u", config.IncludeUpper, "Include uppercase letters (short)")
 	flag.BoolVar(&config.IncludeLower, "lower", config.IncludeLower, "Include lowercase letters")
@@ -52,32 +77,246 @@ func main() {
 	flag.BoolVar(&config.IncludeDigits, "d", config.IncludeDigits, "Include digits (short)")
 	flag.BoolVar(&config.IncludeSymbols, "symbols", config.IncludeSymbols, "Include symbols")
 	flag.BoolVar(&config.IncludeSymbols, "s", config.IncludeSymbols, "Include symbols (short)")
-	flag.BoolVar(&config.ExcludeAmbiguous, "no-ambiguous", config.ExcludeAmbiguous, "Exclude ambiguous characters (0, O, 1, l, I)")
+	flag.BoolVar(&config.IncludeSpace, "include-space", config.IncludeSpace, "Include the space character, counted as a symbol")
+	flag.BoolVar(&config.ExcludeAmbiguous, "no-ambiguous", config.ExcludeAmbiguous, "Exclude ambiguous characters (see --ambiguous-chars)")
 	flag.BoolVar(&config.ExcludeAmbiguous, "n", config.ExcludeAmbiguous, "Exclude ambiguous characters (short)")
+	flag.StringVar(&config.AmbiguousChars, "ambiguous-chars", config.AmbiguousChars, "Characters treated as ambiguous by --no-ambiguous")
+	flag.StringVar(&config.SymbolSet, "symbol-set", config.SymbolSet, "Symbols used by --symbols: "+strings.Join(pwgen.ListSymbolSets(), ", "))
+	flag.StringVar(&config.ExcludeChars, "exclude-chars", config.ExcludeChars, "Characters to remove from the charset")
+	flag.StringVar(&config.Charset, "charset", config.Charset, "Generate from exactly these characters instead of the character type flags")
+	flag.BoolVar(&config.ASCIIOnly, "ascii-only", config.ASCIIOnly, "Keep only printable ASCII characters (0x21-0x7E) in the charset, e.g. to filter --charset")
+	flag.BoolVar(&config.AllowControl, "allow-control", config.AllowControl, "Allow control and other non-printable characters in --charset instead of rejecting them")
+	flag.IntVar(&config.MinUpper, "min-upper", config.MinUpper, "Minimum number of uppercase letters")
+	flag.IntVar(&config.MinLower, "min-lower", config.MinLower, "Minimum number of lowercase letters")
+	flag.IntVar(&config.MinDigits, "min-digits", config.MinDigits, "Minimum number of digits")
+	flag.IntVar(&config.MinSymbols, "min-symbols", config.MinSymbols, "Minimum number of symbols")
+	flag.IntVar(&config.MaxUpper, "max-upper", config.MaxUpper, "Maximum number of uppercase letters (0 for no limit)")
+	flag.IntVar(&config.MaxLower, "max-lower", config.MaxLower, "Maximum number of lowercase letters (0 for no limit)")
+	flag.IntVar(&config.MaxDigits, "max-digits", config.MaxDigits, "Maximum number of digits (0 for no limit)")
+	flag.IntVar(&config.MaxSymbols, "max-symbols", config.MaxSymbols, "Maximum number of symbols (0 for no limit)")
+	flag.IntVar(&config.MaxRepeat, "max-repeat", config.MaxRepeat, "Maximum times a character may repeat consecutively (0 for no limit)")
+	flag.IntVar(&config.MaxClassRun, "max-class-run", config.MaxClassRun, "Maximum characters of the same class in a row, e.g. 3 forbids \"1234\" (0 for no limit)")
+	flag.IntVar(&config.MinDistinct, "min-distinct", config.MinDistinct, "Regenerate until the password uses at least this many different characters")
+	flag.IntVar(&config.MaxAttempts, "max-attempts", config.MaxAttempts, "Give up after this many regenerations of a password that misses a constraint, such as --min-entropy, --max-repeat, --avoid-common, --unique, --unique-prefix or --history")
+	flag.BoolVar(&config.AvoidCommon, "avoid-common", config.AvoidCommon, "Regenerate passwords containing a common password such as admin or qwerty")
+	flag.BoolVar(&config.AlternateClasses, "alternate-classes", config.AlternateClasses, "Never place two characters of the same class next to each other")
+	flag.BoolVar(&config.NoRepeat, "no-repeat", config.NoRepeat, "Never use a character twice, sampling without replacement from the charset")
+	flag.StringVar(&weight, "weight", weight, "Pick each character's class by weight first, e.g. upper=1,lower=1,digit=2,symbol=3 (classes left out weigh 1)")
 
 	flag.IntVar(&count, "count", count, "Number of passwords to generate")
-	countShort := flag.Int("c", count, "Number of passwords to generate (short)")
+	flag.IntVar(&count, "c", count, "Number of passwords to generate (short)")
 	flag.BoolVar(&showStrength, "strength", showStrength, "Show password strength analysis")
 	flag.BoolVar(&showStrength, "S", showStrength, "Show password strength analysis (short)")
-	flag.StringVar(&policyTemplate, "policy", policyTemplate, "Apply password policy template")
+	flag.StringVar(&policyTemplate, "policy", policyTemplate, "Apply password policy template; a comma-separated list must satisfy every policy")
 	flag.StringVar(&policyTemplate, "p", policyTemplate, "Apply password policy template (short)")
+	flag.Float64Var(&minEntropy, "min-entropy", minEntropy, "Regenerate until the password has at least this many bits of entropy, in every mode")
+	flag.BoolVar(&crackTimes, "crack-times", crackTimes, "Show time to crack for several attacker models (implies --strength)")
+	policyAware := flag.Bool("policy-aware-entropy", false, "With --policy, also show the entropy against an attacker who knows the policy and skips non-compliant candidates (implies --strength)")
+	flag.StringVar(&lang, "lang", lang, "Language for strength feedback: en or fr (default from LC_ALL, LC_MESSAGES or LANG)")
+	passphraseDictSize := flag.Int("passphrase-dict-size", 0, "Wordlist size used to estimate the entropy of passphrases, e.g. 7776 for a Diceware list (default: the built-in EFF list)")
+	flag.StringVar(&guessRate, "guess-rate", guessRate, "Attacker guesses per second for time to crack: a number or online, offline-slow-hash, offline-gpu")
+	flag.StringVar(&entropyModel, "entropy-model", entropyModel, "How entropy is estimated: uniform (random over the character space, for generated passwords) or shannon (observed character frequencies, for auditing chosen ones)")
+	flag.StringVar(&analyzerName, "analyzer", analyzerName, "Strength engine scoring passwords: "+strings.Join(pwgen.Analyzers, ", "))
+	flag.Float64Var(&patternPenalties.Repeated, "entropy-penalty-repeated", patternPenalties.Repeated, "Entropy multiplier, in (0, 1], for a character repeated 3 or more times in a row")
+	flag.Float64Var(&patternPenalties.Sequential, "entropy-penalty-sequential", patternPenalties.Sequential, "Entropy multiplier, in (0, 1], for a run like abc, 123 or qwe")
+	flag.Float64Var(&patternPenalties.Substrings, "entropy-penalty-substrings", patternPenalties.Substrings, "Entropy multiplier, in (0, 1], for a group of characters repeated back-to-back")
+	flag.Float64Var(&patternPenalties.Common, "entropy-penalty-common", patternPenalties.Common, "Entropy multiplier, in (0, 1], for a common password like password or letmein")
+	flag.Float64Var(&patternPenalties.Date, "entropy-penalty-date", patternPenalties.Date, "Entropy multiplier, in (0, 1], for a year, date or month name")
+	flag.Float64Var(&patternPenalties.Distinct, "entropy-penalty-distinct", patternPenalties.Distinct, "Entropy multiplier, in (0, 1], for few different characters, as in abababab")
+
+	flag.BoolVar(&passphrase, "passphrase", passphrase, "Generate a passphrase of random words instead of a password")
+	flag.IntVar(&passphraseConfig.Words, "words", passphraseConfig.Words, "Number of words in a passphrase")
+	flag.StringVar(&passphraseConfig.Separator, "separator", passphraseConfig.Separator, "Separator between passphrase words")
+	flag.BoolVar(&passphraseConfig.Capitalize, "capitalize", passphraseConfig.Capitalize, "Capitalize each passphrase word")
+	flag.StringVar(&wordlistPath, "wordlist", wordlistPath, "Draw passphrase words from this file, one word per line, instead of the built-in EFF list")
+	flag.StringVar(&passphraseConfig.Separators, "word-separators", passphraseConfig.Separators, "Pick each separator between passphrase words at random from these characters, e.g. 0123456789, instead of --separator")
+
+	flag.BoolVar(&pronounceable, "pronounceable", pronounceable, "Generate a pronounceable password of alternating consonants and vowels")
+	flag.BoolVar(&hybrid, "hybrid", hybrid, "Generate a memorable password of 3 capitalized words with 2 digits and a symbol inserted at random, e.g. Falcon7$Grove2Mint")
+
+	flag.BoolVar(&pin, "pin", pin, "Generate a numeric PIN of the given length")
+	flag.BoolVar(&forbidTrivialPIN, "forbid-trivial-pin", forbidTrivialPIN, "Reject PINs with repeated digits or runs like 123 or 987")
+	flag.StringVar(&pattern, "pattern", pattern, "Generate from a template: A=upper, a=lower, 9=digit, s=symbol, \\ escapes, anything else is literal")
+	flag.IntVar(&hexBytes, "hex", hexBytes, "Print this many random bytes as hex instead of a password")
+	flag.IntVar(&base64Bytes, "base64", base64Bytes, "Print this many random bytes as base64 instead of a password")
+	flag.BoolVar(&base64URL, "base64-url", base64URL, "With --base64, use the URL-safe alphabet without padding")
+	flag.BoolVar(&clipboard, "clipboard", clipboard, "Copy the password to the clipboard instead of printing it")
+	flag.StringVar(&format, "format", format, "Output format: "+strings.Join(outputFormats, ", ")+"; csv columns are index,password,score,entropy,time_to_crack")
+	noHeader := flag.Bool("no-header", false, "Omit the header row from --format csv output")
+	hashAlgorithm := flag.String("hash", "", "Print a hash of each password for storage instead of the password: "+strings.Join(hashAlgorithms, ", "))
+	withPassword := flag.Bool("with-password", false, "With --hash, print the password before its hash, separated by a tab")
+	bcryptCost := flag.Int("cost", bcrypt.DefaultCost, "bcrypt cost for --hash bcrypt")
+	argonTime := flag.Uint("argon-time", defaultArgonTime, "argon2id iterations for --hash argon2id")
+	argonMemory := flag.Uint("argon-memory", defaultArgonMemory, "argon2id memory in KiB for --hash argon2id")
+	argonParallelism := flag.Uint("argon-parallelism", defaultArgonParallelism, "argon2id threads for --hash argon2id")
+	outputPath := flag.String("output", "", "Write passwords to this file, created with 0600 permissions, instead of stdout")
+	force := flag.Bool("force", false, "Overwrite the --output file if it already exists")
 
+	showVersion := flag.Bool("version", false, "Print the version, git commit, build date and Go version, then exit")
 	listPolicies := flag.Bool("list-policies", false, "List available password policy templates")
-	validateOnly := flag.String("validate", "", "Validate a password against policy without generating")
+	listProfiles := flag.Bool("list-profiles", false, "List available profiles, builtin and from config files")
+	policyDir := flag.String("policy-dir", "", "Load every .yaml and .yml policy file in this directory, selected with --policy by file name")
+	policyURL := flag.String("policy-url", "", "Fetch a policy file over HTTPS, selected with --policy by the last segment of its path without .yaml")
+	policyURLTimeout := flag.Duration("policy-url-timeout", defaultPolicyURLTimeout, "Give up on a --policy-url request after this long")
+	policyURLCA := flag.String("policy-url-ca", "", "PEM file of CA certificates to trust for --policy-url, on top of the system ones")
+	policyCache := flag.String("policy-cache", "", "Save the --policy-url policy to this file, and use it when the URL cannot be reached")
+	validateOnly := flag.String("validate", "", "Validate a password against policy without generating (- reads it from stdin)")
+	compare := flag.Bool("compare", false, "Compare the strength of two passwords, given as arguments or read from stdin")
+	analyzeFile := flag.String("analyze-file", "", "Analyze the strength of each password in a file, one per line (- reads stdin); --format text, csv or json")
+	validateFile := flag.String("validate-file", "", "Check each password in a file, one per line (- reads stdin), against --policy and report the violations, masked unless --show; --format text, csv or json")
+	flag.String("config", configPath, "Load settings from exactly this config file instead of searching the default locations (also PWGEN_CONFIG)")
+	flag.String("profile", profileName, "Apply a named bundle of settings over the config files, see --list-profiles (also PWGEN_PROFILE)")
+	dryRun := flag.Bool("dry-run", false, "Print the resolved settings after config files, environment, flags and --policy, without generating (--format json for JSON)")
+	flag.BoolVar(dryRun, "show-config", false, "Same as --dry-run")
+	explainConfig := flag.Bool("explain-config", false, "Print each resolved setting with where it came from: default, a config file, a profile, an environment variable or the command line (--format json for JSON)")
+	showCharset := flag.Bool("show-charset", false, "Print the resolved charset, its size and the keyspace size^length, without generating")
 	saveConfig := flag.String("save-config", "", "Save example configuration to file")
+	savePolicy := flag.String("save-policy", "", "Save a commented example custom policy to file")
+	historyPath := flag.String("history", "", "Never reissue a password whose salted hash is in this file, and append the hash of each new one")
+	noHistoryWrite := flag.Bool("no-history-write", false, "Check --history without appending new passwords to it")
+	unique := flag.Bool("unique", false, "Never repeat a password within the batch, failing if too few distinct passwords are possible")
+	uniquePrefix := flag.Int("unique-prefix", 0, "Never repeat the first `K` characters of a password within the batch, e.g. for shard keys; implies --unique")
+	jobs := flag.Int("jobs", runtime.GOMAXPROCS(0), "Generate the batch with `N` workers in parallel; --seed and --extra-entropy always use one")
+	ordered := flag.Bool("ordered", false, "With --jobs, print passwords in the order they were started rather than finished")
+	countByPolicy := flag.Bool("count-by-policy", false, "Regenerate each password until it meets the --policy, exiting with status 1 if any cannot")
+	strict := flag.Bool("strict", false, "Exit with status 1 if any generated password, or any password of --validate-file, violates the --policy")
+	print0 := flag.Bool("print0", false, "End each password with a NUL byte instead of a newline, for xargs -0")
+	mask := flag.Bool("mask", false, "Show passwords with all but the first and last character replaced by *")
+	show := flag.Bool("show", false, "Print passwords to a terminal even when require_show is set in the config")
+	extraEntropy := flag.String("extra-entropy", "", "Mix this text into the random source with HKDF; output stays at least as random as crypto/rand")
+	seed := flag.String("seed", "", "INSECURE, for tests only: derive every password deterministically from this seed")
+	insecure := flag.Bool("insecure", false, "Allow an insecure random source, such as --seed")
+	showRNGInfo := flag.Bool("rng-info", false, "Report the random source and FIPS 140-3 mode on stderr before generating")
+	noColor := flag.Bool("no-color", false, "Disable colored output (also disabled by NO_COLOR or when stdout is not a terminal)")
+	verbose := flag.Bool("verbose", false, "Explain how the entropy is calculated (implies --strength)")
+	annotate := flag.Bool("annotate", false, "Color each character of the password by its class (lower, upper, digit, symbol), or mark it with a letter beneath it without color; for a --count of at most 20")
+	bar := flag.Bool("bar", false, "Draw each score as a colored bar like ████████░░ 80/100 (implies --strength)")
+	showStats := flag.Bool("stats", false, "After the batch, print the average score, entropy range, strength levels and, with --policy, how many passed")
+	prefix := flag.String("prefix", "", "Print this text before each password, with {i} replaced by its index zero-padded to the width of --count, e.g. \"user{i}: \"")
+	suffix := flag.String("suffix", "", "Print this text after each password, with {i} replaced as for --prefix")
+	outputTemplate := flag.String("output-template", "", "Go text/template for each password and its strength, or @file to read it from a file (implies --strength)")
+	minLevelName := flag.String("min-level", "", "Exit with status 1 if any generated or validated password is below this strength level: "+strings.Join(pwgen.StrengthLevelNames, ", "))
+	quiet := flag.Bool("quiet", false, "Only print the policy verdict with --validate, without strength analysis")
+
+	flag.Usage = usage
+
+	// The completion subcommand needs the flags defined above but none parsed,
+	// and the policy and serve subcommands parse their own
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		if len(os.Args) != 3 {
+			fmt.Fprintf(os.Stderr, "Usage: %s completion %s\n", os.Args[0], strings.Join(completionShells, "|"))
+			os.Exit(2)
+		}
+		if err := writeCompletion(os.Stdout, os.Args[2], completionFlags(flag.CommandLine)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "policy" {
+		os.Exit(runPolicyCommand(os.Stdout, os.Stderr, os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServeCommand(os.Stderr, os.Args[2:]))
+	}
 
 	flag.Parse()
+	config.Length = lengths.min
+
+	if *showVersion {
+		fmt.Println(currentBuild())
+		return
+	}
+
+	color := colorEnabled(*noColor)
+
+	if crackTimes || *verbose || *bar || *outputTemplate != "" || *policyAware {
+		showStrength = true
+	}
+
+	if *policyAware && policyTemplate == "" {
+		fmt.Fprintf(os.Stderr, "Error: --policy-aware-entropy requires --policy\n")
+		os.Exit(1)
+	}
+
+	if err := pwgen.ValidateEntropyModel(entropyModel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --entropy-model: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := pwgen.ValidateAnalyzer(analyzerName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --analyzer: %v\n", err)
+		os.Exit(1)
+	}
+
+	// newAnalyzer returns the --analyzer engine for opts, the name having
+	// been checked above
+	newAnalyzer := func(opts pwgen.AnalysisOptions) pwgen.Analyzer {
+		analyzer, _ := pwgen.NewAnalyzer(analyzerName, opts)
+		return analyzer
+	}
+
+	if err := checkPatternPenalties(patternPenalties); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if weight != "" {
+		config.ClassWeights, err = pwgen.ParseClassWeights(weight)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --weight: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var minLevel pwgen.StrengthLevel
+	if *minLevelName != "" {
+		minLevel, err = pwgen.ParseStrengthLevel(*minLevelName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --min-level: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *policyDir != "" {
+		if err := registerPolicyDir(os.Stderr, *policyDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --policy-dir: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *policyURL != "" {
+		if *policyURLTimeout <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --policy-url-timeout must be positive\n")
+			os.Exit(1)
+		}
+		opts := policyURLOptions{Timeout: *policyURLTimeout, CAFile: *policyURLCA, CachePath: *policyCache}
+		if err := registerPolicyURL(os.Stderr, *policyURL, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --policy-url: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Handle special commands
 	if *listPolicies {
 		fmt.Println("Available password policy templates:")
-		for _, name := range ListPolicies() {
-			policy, _ := GetPolicy(name)
+		for _, name := range pwgen.ListPolicies() {
+			policy, _ := pwgen.GetPolicy(name)
 			fmt.Printf("  %-15s - %s\n", name, policy.Description)
 		}
 		return
 	}
 
+	if *listProfiles {
+		fmt.Println("Available profiles:")
+		for _, name := range slices.Sorted(maps.Keys(profiles)) {
+			fmt.Printf("  %-15s - %s\n", name, profiles[name].summary())
+		}
+		return
+	}
+
 	if *saveConfig != "" {
 		if err := SaveConfigExample(*saveConfig); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
@@ -87,153 +326,1062 @@ func main() {
 		return
 	}
 
-	if *validateOnly != "" {
+	if *savePolicy != "" {
+		if err := SavePolicyExample(*savePolicy); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving policy: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Example policy saved to %s\n", *savePolicy)
+		return
+	}
+
+	if *analyzeFile != "" {
+		if err := validateFormat(format, analysisFormats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		input := os.Stdin
+		if *analyzeFile != "-" {
+			input, err = os.Open(*analyzeFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer input.Close()
+		}
+
+		if _, err := analyzePasswords(input, os.Stdout, format, newAnalyzer(pwgen.AnalysisOptions{EntropyModel: entropyModel, PatternPenalties: patternPenalties}), entropyModel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not analyze %s: %v\n", *analyzeFile, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *validateFile != "" {
+		if err := validateFormat(format, analysisFormats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		if policyTemplate == "" {
-			fmt.Fprintf(os.Stderr, "Error: --policy required when using --validate\n")
+			fmt.Fprintf(os.Stderr, "Error: --validate-file needs a policy: pass --policy, or set policy_template in the config or PWGEN_POLICY_TEMPLATE\n")
+			os.Exit(1)
+		}
+
+		// A comma-separated list must be met as a whole, as when generating
+		var names []string
+		for _, name := range strings.Split(policyTemplate, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+		policy, err := pwgen.MergePolicies(names)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Available policies: %s\n", strings.Join(pwgen.ListPolicies(), ", "))
+			os.Exit(1)
+		}
+
+		input := os.Stdin
+		if *validateFile != "-" {
+			input, err = os.Open(*validateFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer input.Close()
+		}
+
+		summary, err := validatePasswords(input, os.Stdout, format, policy, *show)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not validate %s: %v\n", *validateFile, err)
+			os.Exit(1)
+		}
+		if *strict && summary.Failed > 0 {
+			fmt.Fprintf(os.Stderr, "Error: %d of %d passwords violate the %s policy\n", summary.Failed, summary.Total, policy.Name)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *compare {
+		passwords := flag.Args()
+		switch len(passwords) {
+		case 0:
+			// Reading from stdin keeps the passwords out of shell history and ps
+			passwords, err = readPasswordsFromStdin(2)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		case 2:
+			fmt.Fprintln(os.Stderr, "Warning: passwords given as arguments are visible in ps and your shell history; run --compare without arguments to read them from stdin")
+		default:
+			fmt.Fprintf(os.Stderr, "Error: --compare needs two passwords, or none to read them from stdin\n")
 			os.Exit(1)
 		}
 
-		policy, err := GetPolicy(policyTemplate)
+		compareLang, err := feedbackLanguage(lang)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		analyzer := newAnalyzer(pwgen.AnalysisOptions{EntropyModel: entropyModel, PatternPenalties: patternPenalties})
+		writeComparison(os.Stdout, analyzer.Analyze(passwords[0]), analyzer.Analyze(passwords[1]), compareLang)
+		return
+	}
+
+	if *validateOnly != "" {
+		if policyTemplate == "" {
+			fmt.Fprintf(os.Stderr, "Error: --validate needs a policy: pass --policy, or set policy_template in the config or PWGEN_POLICY_TEMPLATE\n")
+			os.Exit(1)
+		}
 
-		violations := ValidatePasswordAgainstPolicy(*validateOnly, policy)
-		if len(violations) == 0 {
-			fmt.Printf("✓ Password meets %s policy requirements\n", policy.Name)
+		// A comma-separated list checks several policies at once
+		var names []string
+		for _, name := range strings.Split(policyTemplate, ",") {
+			name = strings.TrimSpace(name)
+			if _, err := pwgen.GetPolicy(name); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Available policies: %s\n", strings.Join(pwgen.ListPolicies(), ", "))
+				os.Exit(1)
+			}
+			names = append(names, name)
+		}
+
+		// Reading from stdin keeps the password out of shell history and ps
+		password := *validateOnly
+		if password == "-" {
+			password, err = readPasswordFromStdin()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		// Invisible characters are easy to paste by accident and cannot be
+		// typed back, whatever the policy says about them
+		if invisible := pwgen.InvisibleChars(password); len(invisible) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: the password contains invisible characters %U, which are easy to paste by accident and hard to type\n", invisible)
+		}
+
+		results := pwgen.ValidateAgainstPolicies(password, names)
+		if len(names) == 1 {
+			policy, _ := pwgen.GetPolicy(names[0])
+			if violations := results[names[0]]; len(violations) == 0 {
+				fmt.Printf("✓ Password meets %s policy requirements\n", policy.Name)
+			} else {
+				fmt.Printf("✗ Password violates %s policy:\n", policy.Name)
+				for _, violation := range violations {
+					fmt.Printf("  - %s\n", violation.Description)
+				}
+			}
 		} else {
-			fmt.Printf("✗ Password violates %s policy:\n", policy.Name)
-			for _, violation := range violations {
-				fmt.Printf("  - %s\n", violation.Description)
+			writePolicyMatrix(os.Stdout, names, results)
+		}
+
+		analysisOptions := pwgen.AnalysisOptions{CrackTimes: crackTimes, PassphraseDictSize: *passphraseDictSize, EntropyModel: entropyModel, PatternPenalties: patternPenalties}
+		if guessRate != "" {
+			analysisOptions.GuessRate, err = pwgen.ParseGuessRate(guessRate)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *policyAware {
+			merged, err := pwgen.MergePolicies(names)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			analysisOptions.Policy = &merged
+		}
+		strength := newAnalyzer(analysisOptions).Analyze(password)
+
+		if !*quiet {
+			fmt.Printf("Strength: %s, Score: %d/100, Entropy: %.1f bits%s, Time to crack: %s\n",
+				formatLevel(strength.Level, color),
+				strength.Score,
+				strength.Entropy,
+				entropyModelLabel(strength.EntropyModel),
+				strength.TimeToCrack,
+			)
+
+			if *bar {
+				fmt.Printf("Score: %s\n", formatBar(strength.Score, strength.Level, color))
+			}
+
+			if crackTimes {
+				fmt.Printf("Crack times: %s\n", formatCrackTimes(strength.CrackTimes))
+			}
+
+			if *policyAware {
+				fmt.Printf("Policy-aware entropy: %.1f bits, Time to crack: %s\n", strength.PolicyAwareEntropy, strength.PolicyAwareTimeToCrack)
+			}
+
+			if *verbose {
+				fmt.Println("Entropy breakdown:")
+				for _, step := range entropyBreakdownLines(pwgen.ExplainEntropy(password, analysisOptions)) {
+					fmt.Printf("  %s\n", step)
+				}
+			}
+		}
+
+		if *minLevelName != "" && strength.Level < minLevel {
+			fmt.Fprintf(os.Stderr, "Error: password strength %s is below the --min-level %s\n", strength.Level, minLevel)
+			os.Exit(1)
+		}
+
+		for _, violations := range results {
+			if len(violations) > 0 {
+				os.Exit(1)
 			}
 		}
 		return
 	}
 
-	// Apply policy template if specified
-	var policy PasswordPolicy
+	// Apply policy template if specified. Several comma-separated policies
+	// are merged into one that satisfies all of them.
+	var policy pwgen.PasswordPolicy
 	if policyTemplate != "" {
-		p, err := GetPolicy(policyTemplate)
+		var names []string
+		for _, name := range strings.Split(policyTemplate, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+
+		p, err := pwgen.MergePolicies(names)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Available policies: %s\n", strings.Join(ListPolicies(), ", "))
+			fmt.Fprintf(os.Stderr, "Available policies: %s\n", strings.Join(pwgen.ListPolicies(), ", "))
 			os.Exit(1)
 		}
 		policy = p
-		ApplyPolicyToConfig(policy, &config)
+		pwgen.ApplyPolicyToConfig(policy, &config)
+
+		// Only a --length given on the command line is worth a warning; the
+		// default length silently follows the policy
+		requested := lengths
+		lengths = lengths.clamp(policy.MinLength, policy.MaxLength)
+		if flagSet("length", "l") {
+			if warning := clampWarning(requested, lengths, policy.Name, policy.MinLength, policy.MaxLength); warning != "" {
+				fmt.Fprintln(os.Stderr, warning)
+			}
+		}
+
+		// Regenerate until the policy's entropy floor is met as well
+		if policy.MinEntropy > minEntropy {
+			minEntropy = policy.MinEntropy
+		}
 	}
 
-	// Use short flag if set
-	if *countShort != count {
-		count = *countShort
+	if *dryRun || *explainConfig {
+		if err := validateFormat(format, configFormats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		resolved := baseConfig.withPasswordConfig(config, passphraseConfig)
+		resolved.Count = count
+		resolved.ShowStrength = showStrength
+		resolved.PolicyTemplate = policyTemplate
+		resolved.GuessRate = guessRate
+		resolved.Weight = weight
+		resolved.Passphrase = passphrase
+		resolved.Pronounceable = pronounceable
+		resolved.Hybrid = hybrid
+		resolved.Clipboard = clipboard
+		resolved.PIN = pin
+		resolved.ForbidTrivialPIN = forbidTrivialPIN
+		resolved.MinEntropy = minEntropy
+		resolved.Pattern = pattern
+		resolved.Hex = hexBytes
+		resolved.Base64 = base64Bytes
+		resolved.Base64URL = base64URL
+		resolved.Format = format
+		resolved.CrackTimes = crackTimes
+		resolved.Lang = lang
+		resolved.EntropyModel = entropyModel
+		resolved.EntropyPenaltyRepeated = patternPenalties.Repeated
+		resolved.EntropyPenaltySequential = patternPenalties.Sequential
+		resolved.EntropyPenaltySubstrings = patternPenalties.Substrings
+		resolved.EntropyPenaltyCommon = patternPenalties.Common
+		resolved.EntropyPenaltyDate = patternPenalties.Date
+		resolved.EntropyPenaltyDistinct = patternPenalties.Distinct
+		resolved.Analyzer = analyzerName
+		resolved.Wordlist = wordlistPath
+
+		write := writeConfig
+		if *explainConfig {
+			write = func(w io.Writer, config Config, format string) error {
+				return writeConfigSources(w, config, sources.withCommandLine(baseConfig, config), format)
+			}
+		}
+		if err := write(os.Stdout, resolved, format); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	if err := validateConfig(config); err != nil {
+	// --hex and --base64 encode random bytes instead of building a password
+	rawBytes := hexBytes + base64Bytes
+
+	switch {
+	case hexBytes != 0 && base64Bytes != 0:
+		err = errors.New("--hex cannot be combined with --base64")
+	case rawBytes != 0:
+		err = pwgen.ValidateRawBytes(rawBytes)
+	case pattern != "":
+		err = pwgen.ValidatePattern(pattern)
+	case passphrase:
+		if wordlistPath != "" {
+			passphraseConfig.Wordlist, err = pwgen.LoadWordlist(wordlistPath)
+		}
+		if err == nil {
+			err = pwgen.ValidatePassphraseConfig(passphraseConfig)
+		}
+	case hybrid:
+		err = pwgen.ValidateHybridConfig(pwgen.DefaultHybridConfig)
+	case pronounceable, pin:
+		err = pwgen.ValidateLength(config.Length)
+	default:
+		err = pwgen.ValidateConfig(config)
+
+		// Minimum counts are hardest to fit in the shortest length and
+		// maximum counts in the longest, so check both ends of a range
+		if err == nil && lengths.isRange() {
+			longest := config
+			longest.Length = lengths.max
+			err = pwgen.ValidateConfig(longest)
+		}
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *showCharset {
+		charset := pwgen.Charset(config)
+		switch {
+		case pin:
+			charset = pwgen.Digits
+		case rawBytes > 0 || pattern != "" || passphrase || hybrid || pronounceable:
+			fmt.Fprintf(os.Stderr, "Error: --show-charset only applies to passwords drawn from a charset, not --hex, --base64, --pattern, --passphrase, --hybrid or --pronounceable\n")
+			os.Exit(1)
+		}
+		writeCharset(os.Stdout, charset, lengths.min, lengths.max)
+		return
+	}
+
+	if minEntropy < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --min-entropy must not be negative\n")
+		os.Exit(1)
+	}
+
+	analysisOptions := pwgen.AnalysisOptions{CrackTimes: crackTimes, PassphraseDictSize: *passphraseDictSize, EntropyModel: entropyModel, PatternPenalties: patternPenalties}
+	if guessRate != "" {
+		analysisOptions.GuessRate, err = pwgen.ParseGuessRate(guessRate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Random bytes, a pattern, passphrase settings, custom charset or
+	// symbol set tells the analysis the exact character space
+	switch {
+	case rawBytes > 0:
+		analysisOptions.RawBytes = rawBytes
+	case pattern != "":
+		analysisOptions.Pattern = pattern
+	case passphrase:
+		analysisOptions.Passphrase = &passphraseConfig
+	case hybrid:
+		analysisOptions.Hybrid = &pwgen.DefaultHybridConfig
+	case pwgen.HasExactCharset(config) && !passphrase && !pronounceable && !pin:
+		analysisOptions.Charset = pwgen.Charset(config)
+	}
+	if *policyAware {
+		analysisOptions.Policy = &policy
+	}
+	charsetMode := rawBytes == 0 && pattern == "" && !passphrase && !hybrid && !pronounceable && !pin
+	if charsetMode {
+		analysisOptions.AlternateClasses = config.AlternateClasses && pwgen.CanAlternateClasses(config)
+		analysisOptions.ClassWeights = config.ClassWeights
+		analysisOptions.NoRepeat = config.NoRepeat
+	}
+
+	feedbackLang, err := feedbackLanguage(lang)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := validateFormat(format, outputFormats); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if format != "text" && clipboard {
+		fmt.Fprintf(os.Stderr, "Error: --clipboard cannot be combined with --format %s\n", format)
+		os.Exit(1)
+	}
+
+	// A summary line would break the one row per password of CSV
+	if format == "csv" && *showStats {
+		fmt.Fprintf(os.Stderr, "Error: --stats cannot be combined with --format csv\n")
+		os.Exit(1)
+	}
+
+	if *outputPath != "" && clipboard {
+		fmt.Fprintf(os.Stderr, "Error: --clipboard cannot be combined with --output\n")
+		os.Exit(1)
+	}
+
+	// NUL-separated output holds the passwords alone, without annotations
+	if *print0 {
+		switch {
+		case format != "text":
+			fmt.Fprintf(os.Stderr, "Error: --print0 cannot be combined with --format %s\n", format)
+			os.Exit(1)
+		case showStrength:
+			fmt.Fprintf(os.Stderr, "Error: --print0 cannot be combined with --strength, --verbose, --crack-times or --output-template\n")
+			os.Exit(1)
+		case *showStats:
+			fmt.Fprintf(os.Stderr, "Error: --print0 cannot be combined with --stats\n")
+			os.Exit(1)
+		case clipboard:
+			fmt.Fprintf(os.Stderr, "Error: --print0 cannot be combined with --clipboard\n")
+			os.Exit(1)
+		}
+	}
+
+	if *prefix != "" || *suffix != "" {
+		switch {
+		case format != "text":
+			fmt.Fprintf(os.Stderr, "Error: --prefix and --suffix cannot be combined with --format %s\n", format)
+			os.Exit(1)
+		case *outputTemplate != "":
+			fmt.Fprintf(os.Stderr, "Error: --prefix and --suffix cannot be combined with --output-template, which can add the text itself\n")
+			os.Exit(1)
+		case clipboard:
+			fmt.Fprintf(os.Stderr, "Error: --prefix and --suffix cannot be combined with --clipboard\n")
+			os.Exit(1)
+		}
+	}
+
+	if *bar {
+		switch {
+		case format != "text":
+			fmt.Fprintf(os.Stderr, "Error: --bar cannot be combined with --format %s\n", format)
+			os.Exit(1)
+		case *outputTemplate != "":
+			fmt.Fprintf(os.Stderr, "Error: --bar cannot be combined with --output-template, which can draw the bar itself with {{bar .Score .Level}}\n")
+			os.Exit(1)
+		}
+	}
+
+	// Annotated passwords are for reading a few at a time, so anything that
+	// hides, replaces or reformats the password is left out
+	if *annotate {
+		switch {
+		case count > annotateLimit:
+			fmt.Fprintf(os.Stderr, "Error: --annotate is for reading a few passwords, at most %d, got --count %d\n", annotateLimit, count)
+			os.Exit(1)
+		case format != "text":
+			fmt.Fprintf(os.Stderr, "Error: --annotate cannot be combined with --format %s\n", format)
+			os.Exit(1)
+		case *outputTemplate != "":
+			fmt.Fprintf(os.Stderr, "Error: --annotate cannot be combined with --output-template\n")
+			os.Exit(1)
+		case *print0 || clipboard || *mask || *hashAlgorithm != "":
+			fmt.Fprintf(os.Stderr, "Error: --annotate cannot be combined with --print0, --clipboard, --mask or --hash\n")
+			os.Exit(1)
+		}
+	}
+
+	templateText := defaultOutputTemplate
+	if *outputTemplate != "" {
+		if format != "text" {
+			fmt.Fprintf(os.Stderr, "Error: --output-template cannot be combined with --format %s\n", format)
+			os.Exit(1)
+		}
+		templateText = *outputTemplate
+	}
+
+	lineTemplate, err := parseOutputTemplate(templateText, color)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --output-template: %v\n", err)
+		os.Exit(1)
+	}
+
+	var hashOpts hashOptions
+	if *hashAlgorithm != "" {
+		if format != "text" {
+			fmt.Fprintf(os.Stderr, "Error: --hash cannot be combined with --format %s\n", format)
+			os.Exit(1)
+		}
+
+		if *argonTime > math.MaxUint32 || *argonMemory > math.MaxUint32 || *argonParallelism > math.MaxUint8 {
+			fmt.Fprintf(os.Stderr, "Error: --argon-time, --argon-memory or --argon-parallelism is too large\n")
+			os.Exit(1)
+		}
+
+		hashOpts = hashOptions{
+			Algorithm:        *hashAlgorithm,
+			Cost:             *bcryptCost,
+			ArgonTime:        uint32(*argonTime),
+			ArgonMemory:      uint32(*argonMemory),
+			ArgonParallelism: uint8(*argonParallelism),
+		}
+		if err := validateHashOptions(hashOpts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --hash: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *withPassword {
+		fmt.Fprintf(os.Stderr, "Error: --with-password needs --hash\n")
+		os.Exit(1)
+	}
+
+	if *passphraseDictSize < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --passphrase-dict-size must not be negative\n")
+		os.Exit(1)
+	}
+
+	if *jobs < 1 {
+		fmt.Fprintf(os.Stderr, "Error: --jobs must be at least 1\n")
+		os.Exit(1)
+	}
+	if *uniquePrefix < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --unique-prefix must not be negative\n")
+		os.Exit(1)
+	}
+	if *uniquePrefix > 0 {
+		*unique = true
+	}
+
+	if *extraEntropy != "" && *seed != "" {
+		fmt.Fprintf(os.Stderr, "Error: --extra-entropy cannot be combined with --seed\n")
+		os.Exit(1)
+	}
+
+	if *countByPolicy && policyTemplate == "" {
+		fmt.Fprintf(os.Stderr, "Error: --count-by-policy needs --policy\n")
+		os.Exit(1)
+	}
+
+	if *noHistoryWrite && *historyPath == "" {
+		fmt.Fprintf(os.Stderr, "Error: --no-history-write needs --history\n")
+		os.Exit(1)
+	}
+
+	var random io.Reader = rand.Reader
+	if *extraEntropy != "" {
+		random = pwgen.NewMixedReader(random, []byte(*extraEntropy))
+	}
+
+	generator := pwgen.NewGenerator(random)
+	if *seed != "" {
+		generator = pwgen.NewSeededGenerator(*seed)
+	}
+
+	// Only crypto/rand, alone or with --extra-entropy, runs without --insecure
+	source := generator.Source()
+	if !source.Secure() && !*insecure {
+		fmt.Fprintf(os.Stderr, "Error: the %s random source is insecure, pass --insecure to use it for testing\n", source)
+		os.Exit(1)
+	}
+	if *seed != "" {
+		fmt.Fprintln(os.Stderr, "WARNING: --seed is INSECURE and for testing only. Anyone who knows the seed can reproduce these passwords.")
+	}
+	if *showRNGInfo {
+		fmt.Fprintln(os.Stderr, describeSource(source))
+	}
+
+	var out io.Writer = os.Stdout
+	var outputFile *os.File
+	if *outputPath != "" {
+		outputFile, err = createOutputFile(*outputPath, *force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not create output file: %v\n", err)
+			os.Exit(1)
+		}
+		out = outputFile
+	}
+
+	// With require_show, passwords bound for the terminal are left out of
+	// text output and masked in CSV and JSON, unless --show is given
+	hidden := !clipboard && outputFile == nil && (hashOpts.Algorithm == "" || *withPassword) &&
+		passwordsHidden(baseConfig.RequireShow, *show)
+
+	// --annotate classes each character within the charset it was drawn
+	// from; passwords not drawn from one are classed by character alone.
+	// Colors would be escape codes in a file, so --output gets the letters.
+	var annotateCharset string
+	annotateColor := color && outputFile == nil
+	if *annotate && !hidden {
+		if rawBytes == 0 && pattern == "" && !passphrase && !hybrid && !pronounceable && !pin {
+			annotateCharset = pwgen.Charset(config)
+		}
+		fmt.Fprintln(out, annotateLegend(annotateColor))
+	}
+
+	var csvOut *csvOutput
+	var jsonOut *jsonOutput
+	var markdownOut *markdownOutput
+	switch format {
+	case "csv":
+		csvOut, err = newCSVOutput(out, !*noHeader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write CSV: %v\n", err)
+			os.Exit(1)
+		}
+	case "json":
+		jsonOut = &jsonOutput{w: out}
+	case "markdown":
+		markdownOut, err = newMarkdownOutput(out, policyTemplate != "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write Markdown: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var stats *batchStats
+	if *showStats {
+		stats = newBatchStats(policyTemplate != "")
+	}
+
+	// Locate the clipboard utility up front so nothing is generated for nothing
+	var clipboardCmd clipboardCommand
+	if clipboard {
+		clipboardCmd, err = findClipboardCommand()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --clipboard unavailable: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// newGenerate returns a function generating passwords with its own copy
+	// of config, whose length varies with a --length range, so each --jobs
+	// worker can have one. Its buffer holds the current password when it is
+	// generated as bytes, and is zeroed once the password has been printed
+	// or discarded; wipes zero the last ones.
+	var wipes []func()
+	newGenerate := func() func() (string, error) {
+		config := config
+		var buffer []byte
+		wipes = append(wipes, func() { pwgen.Zero(buffer) })
+
+		return func() (string, error) {
+			pwgen.Zero(buffer)
+			buffer = nil
+
+			// Each password of a --length range gets its own length
+			if lengths.isRange() {
+				length, err := lengths.random(generator)
+				if err != nil {
+					return "", err
+				}
+				config.Length = length
+			}
+
+			switch {
+			case hexBytes > 0:
+				return generator.GenerateHex(hexBytes)
+			case base64Bytes > 0:
+				return generator.GenerateBase64(base64Bytes, base64URL)
+			case pattern != "":
+				return generator.GenerateFromPattern(pattern)
+			case passphrase:
+				return generator.GeneratePassphrase(passphraseConfig)
+			case hybrid:
+				return generator.GenerateHybrid(pwgen.DefaultHybridConfig)
+			case pronounceable:
+				return generator.GeneratePronounceable(config.Length)
+			case pin:
+				return generator.GeneratePIN(config.Length, forbidTrivialPIN)
+			case minEntropy > 0:
+				return generator.GenerateWithMinEntropy(config, minEntropy)
+			default:
+				var err error
+				buffer, err = generator.GenerateBytes(config)
+				return string(buffer), err
+			}
+		}
+	}
+
+	// --unique regenerates any password already in the batch, or with
+	// --unique-prefix any whose prefix is. Where the number of distinct
+	// passwords or prefixes is known, a batch that cannot fit is rejected
+	// up front, sizing a --length range by its longest length; otherwise
+	// the bounded retries catch it.
+	if *unique {
+		chars := lengths.max
+		if *uniquePrefix > 0 {
+			chars = min(*uniquePrefix, lengths.max)
+		}
+
+		var bits float64
+		switch {
+		case rawBytes > 0:
+			bits = float64(rawBytes) * 8
+			// A hex digit holds 4 bits and a base64 character 6
+			if *uniquePrefix > 0 {
+				perChar := 6.0
+				if hexBytes > 0 {
+					perChar = 4
+				}
+				bits = min(bits, float64(*uniquePrefix)*perChar)
+			}
+		case pattern != "":
+			// The whole pattern bounds the prefixes too
+			bits, err = pwgen.PatternEntropy(pattern)
+		case pin:
+			bits = float64(chars) * math.Log2(10)
+		case !passphrase && !hybrid && !pronounceable:
+			bits = float64(chars) * math.Log2(float64(utf8.RuneCountInString(pwgen.Charset(config))))
+		default:
+			bits = math.Inf(1)
+		}
+		if err == nil {
+			err = checkKeyspace(count, bits, *uniquePrefix)
+		}
+		if err != nil {
+			name := "--unique"
+			if *uniquePrefix > 0 {
+				name = "--unique-prefix"
+			}
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+			os.Exit(1)
+		}
+
+	}
+
+	// --history regenerates any password issued by an earlier run
+	var history *passwordHistory
+	if *historyPath != "" {
+		history, err = loadHistory(*historyPath, *noHistoryWrite)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not read history file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// The other modes have no generator for --min-entropy, so their
+	// passwords are checked against the same estimate, with the default
+	// entropy model and pattern penalties
+	entropyOptions := analysisOptions
+	entropyOptions.EntropyModel = ""
+	entropyOptions.PatternPenalties = pwgen.PatternPenalties{}
+
+	// newNext chains a generator for a worker. --min-entropy outside the
+	// charset mode, then --count-by-policy, regenerate any password that
+	// falls short before --unique and --history see it. The --unique set
+	// and the history are shared by every worker, so they hold for the
+	// whole batch.
+	seen := newUniqueSet()
+	newNext := func() func() (string, error) {
+		next := newGenerate()
+		if minEntropy > 0 && !charsetMode {
+			next = (&entropyGenerator{generate: next, analyzer: pwgen.BuiltinAnalyzer{Options: entropyOptions}, minEntropy: minEntropy, attempts: config.MaxAttempts}).next
+		}
+		if *countByPolicy {
+			next = (&policyGenerator{generate: next, policy: policy, attempts: config.MaxAttempts}).next
+		}
+		if *unique {
+			next = newUniqueGenerator(next, seen, *uniquePrefix, config.MaxAttempts).next
+		}
+		if history != nil {
+			next = (&historyGenerator{generate: next, history: history, attempts: config.MaxAttempts}).next
+		}
+		return next
+	}
+
+	// With --jobs the batch is generated by a pool of workers. Only
+	// crypto/rand is safe to share between them, and a --seed must give the
+	// same passwords every run, so any other source generates one at a time.
+	var next func() (string, error)
+	if *jobs > 1 && count > 1 && generator.Source() == pwgen.SourceCryptoRand {
+		next = newParallelGenerator(newNext, count, *jobs, *ordered).next
+	} else {
+		next = newNext()
+	}
+
+	analyzer := newAnalyzer(analysisOptions)
+	var lastPassword string
+	violating, skipped, belowLevel := 0, 0, 0
 	for i := 0; i < count; i++ {
-		password, err := generatePassword(config)
+		password, err := next()
+		if errors.Is(err, errPolicyAttempts) {
+			fmt.Fprintf(os.Stderr, "Warning: password %d skipped: %v\n", i+1, err)
+			skipped++
+			continue
+		}
 		if err != nil {
 			log.Fatalf("Failed to generate password: %v", err)
 		}
 
-		fmt.Print(password)
+		lastPassword = password
+
+		var violations []pwgen.PolicyViolation
+		if policyTemplate != "" {
+			violations = pwgen.ValidatePasswordAgainstPolicy(password, policy)
+			if len(violations) > 0 {
+				violating++
+			}
+		}
+
+		// Analysis always runs on the real password, only the display is masked
+		displayed := password
+		if *mask || (hidden && format != "text") {
+			displayed = maskPassword(password)
+		}
+
+		var strength pwgen.PasswordStrength
+		if showStrength || format != "text" || stats != nil || *minLevelName != "" {
+			strength = analyzer.Analyze(password)
+		}
+		if *minLevelName != "" && strength.Level < minLevel {
+			belowLevel++
+		}
+		if stats != nil {
+			stats.add(strength, violations)
+		}
+
+		if csvOut != nil {
+			if err := csvOut.write(i+1, displayed, strength); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write CSV: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		if jsonOut != nil {
+			if err := jsonOut.write(i+1, displayed, strength, violations); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write JSON: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		if markdownOut != nil {
+			if err := markdownOut.write(i+1, displayed, strength, violations); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write Markdown: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		// With --hash the hash takes the password's place. It is printed even
+		// in clipboard mode, so the password can be pasted and the hash stored.
+		fields := outputLine{Index: i + 1}
+		if hashOpts.Algorithm != "" {
+			fields.Hash, err = kdfHash(password, hashOpts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --hash: %v\n", err)
+				os.Exit(1)
+			}
+
+			if *withPassword && !clipboard && !hidden {
+				fields.Password = displayed
+			}
+		} else if !clipboard && !hidden {
+			fields.Password = displayed
+		}
+
+		var letters string
+		if *annotate && fields.Password != "" {
+			if annotateColor {
+				fields.Password = colorClasses(password, annotateCharset)
+			} else {
+				letters = classLetters(password, annotateCharset)
+			}
+		}
+
+		// --prefix and --suffix wrap the password, or its hash, and the
+		// strength summary on its line
+		wrapped := fields.Password != "" || fields.Hash != ""
+		var line strings.Builder
+		if wrapped {
+			line.WriteString(expandIndex(*prefix, i+1, count))
+		}
+
+		if !showStrength {
+			line.WriteString(fields.Password)
+			if fields.Password != "" && fields.Hash != "" {
+				line.WriteString("\t")
+			}
+			line.WriteString(fields.Hash)
+		}
 
 		// Show strength analysis if requested
 		if showStrength {
-			strength := AnalyzePasswordStrength(password)
-			fmt.Printf(" [%s%s\033[0m, Score: %d/100, Entropy: %.1f bits, Time to crack: %s]",
-				strength.Level.Color(),
-				strength.Level.String(),
-				strength.Score,
-				strength.Entropy,
-				strength.TimeToCrack,
-			)
+			fields.PasswordStrength = strength
+			fields.Feedback = pwgen.LocalizeFeedback(strength.Messages, feedbackLang)
+			fields.Violations = violations
+			rendered, err := renderOutputLine(lineTemplate, fields)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			line.WriteString(rendered)
+		}
 
-			if len(strength.Feedback) > 0 {
-				fmt.Printf("\n  Feedback: %s", strings.Join(strength.Feedback, "; "))
+		if wrapped {
+			line.WriteString(expandIndex(*suffix, i+1, count))
+		}
+
+		// Without color the class letters go on the next line, beneath the
+		// password
+		if letters != "" {
+			indent := utf8.RuneCountInString(expandIndex(*prefix, i+1, count))
+			fmt.Fprintf(&line, "\n%s%s", strings.Repeat(" ", indent), letters)
+		}
+
+		// A custom template replaces the detail lines below along with the
+		// summary, since it can show the same fields itself
+		if showStrength && *outputTemplate == "" {
+			if *bar {
+				fmt.Fprintf(&line, "\n  Score: %s", formatBar(strength.Score, strength.Level, color))
+			}
+
+			if crackTimes {
+				fmt.Fprintf(&line, "\n  Crack times: %s", formatCrackTimes(fields.CrackTimes))
+			}
+
+			if *policyAware {
+				fmt.Fprintf(&line, "\n  Policy-aware entropy: %.1f bits, Time to crack: %s", strength.PolicyAwareEntropy, strength.PolicyAwareTimeToCrack)
+			}
+
+			if *verbose {
+				fmt.Fprintf(&line, "\n  Entropy breakdown:")
+				for _, step := range entropyBreakdownLines(pwgen.ExplainEntropy(password, analysisOptions)) {
+					fmt.Fprintf(&line, "\n    %s", step)
+				}
+			}
+
+			if len(fields.Feedback) > 0 {
+				fmt.Fprintf(&line, "\n  Feedback: %s", strings.Join(fields.Feedback, "; "))
 			}
 		}
 
-		// Validate against policy if specified
-		if policyTemplate != "" {
-			violations := ValidatePasswordAgainstPolicy(password, policy)
+		// Validate against policy if specified. --print0 output leaves the
+		// count out, as --strict does not need it
+		if policyTemplate != "" && *outputTemplate == "" && !*print0 {
 			if len(violations) > 0 {
-				fmt.Printf(" [Policy violations: %d]", len(violations))
+				fmt.Fprintf(&line, " [Policy violations: %d]", len(violations))
 				if showStrength {
-					fmt.Printf("\n  Violations:")
+					fmt.Fprintf(&line, "\n  Violations:")
 					for _, violation := range violations {
-						fmt.Printf("\n    - %s", violation.Description)
+						fmt.Fprintf(&line, "\n    - %s", violation.Description)
 					}
 				}
 			}
 		}
 
-		fmt.Println()
-	}
-}
+		// In clipboard mode only the analysis is printed, never the password
+		output := line.String()
+		if clipboard || hidden {
+			output = strings.TrimPrefix(output, " ")
+			if output == "" {
+				continue
+			}
+		}
 
-func validateConfig(config PasswordConfig) error {
-	if config.Length < 1 {
-		return fmt.Errorf("password length must be at least 1")
+		writeRecord(out, output, *print0)
 	}
 
-	if !config.IncludeUpper && !config.IncludeLower && !config.IncludeDigits && !config.IncludeSymbols {
-		return fmt.Errorf("at least one character type must be enabled")
+	for _, wipe := range wipes {
+		wipe()
 	}
 
-	return nil
-}
-
-func generatePassword(config PasswordConfig) (string, error) {
-	charset := buildCharset(config)
-
-	if len(charset) == 0 {
-		return "", fmt.Errorf("no valid characters available for password generation")
+	if csvOut != nil {
+		if err := csvOut.flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write CSV: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	password := make([]byte, config.Length)
-
-	for i := 0; i < config.Length; i++ {
-		randomIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random number: %w", err)
+	switch {
+	case jsonOut != nil:
+		if err := jsonOut.finish(stats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write JSON: %v\n", err)
+			os.Exit(1)
 		}
-		password[i] = charset[randomIndex.Int64()]
+	case markdownOut != nil:
+		if err := markdownOut.finish(stats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write Markdown: %v\n", err)
+			os.Exit(1)
+		}
+	case stats != nil:
+		fmt.Fprintln(out, stats)
 	}
 
-	return string(password), nil
-}
+	if outputFile != nil {
+		if err := closeOutputFile(outputFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write output file: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-func buildCharset(config PasswordConfig) string {
-	var charset strings.Builder
+	if clipboard {
+		if count > 1 {
+			fmt.Fprintf(os.Stderr, "Warning: generated %d passwords, only the last one was copied to the clipboard\n", count)
+		}
 
-	if config.IncludeLower {
-		charset.WriteString(LowerCase)
+		if err := copyToClipboard(clipboardCmd, lastPassword); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not copy to clipboard: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "Password copied to clipboard")
 	}
 
-	if config.IncludeUpper {
-		charset.WriteString(UpperCase)
+	if hidden {
+		fmt.Fprintln(os.Stderr, "Password not shown because require_show is set and stdout is a terminal: add --show to print it, or use --clipboard to copy it")
 	}
 
-	if config.IncludeDigits {
-		charset.WriteString(Digits)
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d of %d passwords could not be generated to meet the %s policy\n", skipped, count, policy.Name)
+		os.Exit(1)
 	}
 
-	if config.IncludeSymbols {
-		charset.WriteString(Symbols)
+	if *strict && violating > 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d of %d generated passwords violate the %s policy\n", violating, count, policy.Name)
+		os.Exit(1)
 	}
 
-	result := charset.String()
+	if belowLevel > 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d of %d generated passwords are below the --min-level %s\n", belowLevel, count, minLevel)
+		os.Exit(1)
+	}
+}
 
-	if config.ExcludeAmbiguous {
-		for _, char := range Ambiguous {
-			result = strings.ReplaceAll(result, string(char), "")
+// flagSet reports whether any of names was given on the command line.
+func flagSet(names ...string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if slices.Contains(names, f.Name) {
+			set = true
 		}
-	}
+	})
+	return set
+}
 
-	return result
+func usage() {
+	output := flag.CommandLine.Output()
+	fmt.Fprintf(output, "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(output, "  %s [flags]\n", os.Args[0])
+	fmt.Fprintf(output, "  %s completion bash|zsh|fish\n", os.Args[0])
+	fmt.Fprintf(output, "  %s policy validate path.yaml...\n", os.Args[0])
+	fmt.Fprintf(output, "  %s serve [--addr :8080] [--max-length 256] [--max-count 100]\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprint(output, `
+Exit codes:
+  0  success; with --validate, the password meets the policy
+  1  an error occurred, --validate found policy violations,
+     --strict is set and a generated password, or a password of
+     --validate-file, violates the policy,
+     --count-by-policy could not generate a compliant password,
+     a password is below --min-level, or
+     policy validate found problems in a policy file
+  2  invalid command-line flags
+`)
 }
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+	"golang.org/x/term"
+)
+
+// outputFormats lists the values accepted by --format when generating,
+// analysisFormats those accepted with --analyze-file and configFormats
+// those accepted with --dry-run, where text means YAML.
+var (
+	outputFormats   = []string{"text", "csv", "json", "markdown"}
+	analysisFormats = []string{"text", "csv", "json"}
+	configFormats   = []string{"text", "json"}
+)
+
+func validateFormat(format string, formats []string) error {
+	for _, known := range formats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(formats, ", "))
+}
+
+// csvOutput writes generated passwords and their strength analysis as CSV
+// rows, quoting passwords containing commas, quotes, line breaks or
+// leading or trailing spaces. encoding/csv leaves trailing spaces unquoted,
+// which some spreadsheets then drop, so the quoting is done here.
+type csvOutput struct {
+	writer *bufio.Writer
+}
+
+func newCSVOutput(w io.Writer, header bool) (*csvOutput, error) {
+	output := &csvOutput{writer: bufio.NewWriter(w)}
+
+	if header {
+		if err := output.writeRow("index", "password", "score", "entropy", "time_to_crack"); err != nil {
+			return nil, err
+		}
+	}
+
+	return output, nil
+}
+
+func (o *csvOutput) write(index int, password string, strength pwgen.PasswordStrength) error {
+	return o.writeRow(
+		strconv.Itoa(index),
+		csvText(password),
+		strconv.Itoa(strength.Score),
+		strconv.FormatFloat(strength.Entropy, 'f', 1, 64),
+		strength.TimeToCrack,
+	)
+}
+
+func (o *csvOutput) writeRow(fields ...string) error {
+	for i, field := range fields {
+		if i > 0 {
+			o.writer.WriteByte(',')
+		}
+		o.writer.WriteString(csvQuote(field))
+	}
+	return o.writer.WriteByte('\n')
+}
+
+func (o *csvOutput) flush() error {
+	return o.writer.Flush()
+}
+
+// csvQuote returns field as a CSV field, in double quotes when it contains
+// a comma, a quote or a line break or starts or ends with whitespace.
+func csvQuote(field string) string {
+	if !strings.ContainsAny(field, ",\"\r\n") && strings.TrimSpace(field) == field {
+		return field
+	}
+	return `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+}
+
+// csvText returns field with a ' in front when it starts with =, +, -, @,
+// a tab or a carriage return, which spreadsheets would otherwise run as a
+// formula, and as is otherwise. Spreadsheets hide the ', but other readers
+// of the CSV must strip it from such passwords.
+func csvText(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+// markdownOutput writes generated passwords and their strength analysis as
+// a Markdown table for --format markdown, with a policy column when a
+// policy is set.
+type markdownOutput struct {
+	writer *bufio.Writer
+	policy bool
+}
+
+func newMarkdownOutput(w io.Writer, policy bool) (*markdownOutput, error) {
+	output := &markdownOutput{writer: bufio.NewWriter(w), policy: policy}
+
+	header := []string{"#", "Password", "Score", "Level", "Entropy (bits)"}
+	if policy {
+		header = append(header, "Policy")
+	}
+	separators := make([]string, len(header))
+	for i, title := range header {
+		separators[i] = strings.Repeat("-", max(len(title), 3))
+	}
+
+	if err := output.writeRow(header...); err != nil {
+		return nil, err
+	}
+	if err := output.writeRow(separators...); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (o *markdownOutput) write(index int, password string, strength pwgen.PasswordStrength, violations []pwgen.PolicyViolation) error {
+	row := []string{
+		strconv.Itoa(index),
+		markdownCode(password),
+		strconv.Itoa(strength.Score),
+		strength.Level.String(),
+		strconv.FormatFloat(strength.Entropy, 'f', 1, 64),
+	}
+
+	if o.policy {
+		result := "Pass"
+		if len(violations) > 0 {
+			descriptions := make([]string, len(violations))
+			for i, violation := range violations {
+				descriptions[i] = violation.Description
+			}
+			result = "Fail: " + strings.Join(descriptions, "; ")
+		}
+		row = append(row, markdownEscape(result))
+	}
+
+	return o.writeRow(row...)
+}
+
+func (o *markdownOutput) writeRow(cells ...string) error {
+	o.writer.WriteString("|")
+	for _, cell := range cells {
+		o.writer.WriteString(" " + cell + " |")
+	}
+	return o.writer.WriteByte('\n')
+}
+
+// finish adds stats below the table when it is not nil, separated by a
+// blank line so it is not read as another row.
+func (o *markdownOutput) finish(stats *batchStats) error {
+	if stats != nil {
+		fmt.Fprintf(o.writer, "\n%s\n", stats)
+	}
+	return o.writer.Flush()
+}
+
+// markdownCode returns password as a code span for a table cell, so it is
+// shown literally. The fence has one more backtick than the longest run
+// in password, spaces keep a leading or trailing backtick or space intact,
+// and pipes are escaped so they do not end the cell.
+func markdownCode(password string) string {
+	longest, run := 0, 0
+	for _, char := range password {
+		if char == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+
+	padding := ""
+	if strings.HasPrefix(password, "`") || strings.HasSuffix(password, "`") || strings.HasPrefix(password, " ") || strings.HasSuffix(password, " ") {
+		padding = " "
+	}
+
+	return fence + padding + strings.ReplaceAll(password, "|", `\|`) + padding + fence
+}
+
+// markdownEscape escapes the backslashes and pipes of text, so it stays in
+// one table cell.
+func markdownEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`).Replace(text)
+}
+
+// jsonPassword is one generated password in --format json output.
+type jsonPassword struct {
+	Index       int      `json:"index"`
+	Password    string   `json:"password"`
+	Level       string   `json:"level"`
+	Score       int      `json:"score"`
+	Entropy     float64  `json:"entropy"`
+	TimeToCrack string   `json:"time_to_crack"`
+	Violations  []string `json:"violations,omitempty"`
+	// PolicyAwareEntropy is set with --policy-aware-entropy
+	PolicyAwareEntropy float64 `json:"policy_aware_entropy,omitempty"`
+	// EntropyModel names the model Entropy was estimated with
+	EntropyModel string `json:"entropy_model,omitempty"`
+}
+
+// jsonOutput writes {"passwords": [...]} for --format json, emitting each
+// password as soon as it is generated, with a "stats" object after them
+// for --stats.
+type jsonOutput struct {
+	w     io.Writer
+	count int
+}
+
+func (o *jsonOutput) write(index int, password string, strength pwgen.PasswordStrength, violations []pwgen.PolicyViolation) error {
+	entry := jsonPassword{
+		Index:       index,
+		Password:    password,
+		Level:       strength.Level.String(),
+		Score:       strength.Score,
+		Entropy:     strength.Entropy,
+		TimeToCrack: strength.TimeToCrack,
+
+		PolicyAwareEntropy: strength.PolicyAwareEntropy,
+		EntropyModel:       strength.EntropyModel,
+	}
+	for _, violation := range violations {
+		entry.Violations = append(entry.Violations, violation.Description)
+	}
+
+	// Passwords are printed as generated, without escaping <, > and &
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(entry); err != nil {
+		return err
+	}
+
+	prefix := ",\n    "
+	if o.count == 0 {
+		prefix = "{\n  \"passwords\": [\n    "
+	}
+	o.count++
+
+	_, err := fmt.Fprintf(o.w, "%s%s", prefix, bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+	return err
+}
+
+// finish closes the passwords array and adds stats when it is not nil.
+func (o *jsonOutput) finish(stats *batchStats) error {
+	if o.count == 0 {
+		if _, err := fmt.Fprint(o.w, "{\n  \"passwords\": ["); err != nil {
+			return err
+		}
+	} else {
+		if _, err := fmt.Fprint(o.w, "\n  "); err != nil {
+			return err
+		}
+	}
+
+	if stats == nil {
+		_, err := fmt.Fprint(o.w, "]\n}\n")
+		return err
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.w, "],\n  \"stats\": %s\n}\n", data)
+	return err
+}
+
+// createOutputFile creates path for --output, readable and writable only by
+// the owner. An existing file is an error unless force is set, in which case
+// it is truncated and its permissions are tightened to 0600 as well.
+func createOutputFile(path string, force bool) (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("%s already exists (use --force to overwrite)", path)
+		}
+		return nil, err
+	}
+
+	// OpenFile keeps the mode of a file that already existed
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// closeOutputFile flushes file to disk before closing it, so the passwords
+// are durable once pwgen exits successfully.
+func closeOutputFile(file *os.File) error {
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeRecord writes one line of text output, ended by a NUL for --print0
+// so passwords containing spaces or line breaks survive xargs -0, and by a
+// newline otherwise.
+func writeRecord(w io.Writer, line string, print0 bool) error {
+	terminator := "\n"
+	if print0 {
+		terminator = "\x00"
+	}
+	_, err := io.WriteString(w, line+terminator)
+	return err
+}
+
+// formatCrackTimes lists the crack time for each attacker model, in the
+// order of pwgen.AttackScenarios.
+func formatCrackTimes(crackTimes map[string]string) string {
+	var parts []string
+	for _, scenario := range pwgen.AttackScenarios {
+		if crackTime, ok := crackTimes[scenario.Name]; ok {
+			parts = append(parts, fmt.Sprintf("%s %s", scenario.Name, crackTime))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// entropyBreakdownLines explains how an entropy estimate was derived, one
+// step per line, for --verbose.
+func entropyBreakdownLines(breakdown pwgen.EntropyBreakdown) []string {
+	var lines []string
+	switch breakdown.Method {
+	case "bytes":
+		bytes := int(breakdown.RawEntropy) / 8
+		return []string{
+			fmt.Sprintf("Random bytes: %d", bytes),
+			fmt.Sprintf("Entropy: %d × 8 = %.1f bits", bytes, breakdown.Entropy),
+		}
+	case "pattern":
+		lines = append(lines, "Character space: per position, from the pattern")
+	case "pronounceable":
+		lines = append(lines, "Character space: per position, alternating consonants and vowels")
+	case "charset":
+		lines = append(lines, fmt.Sprintf("Character space: %d (the generation charset)", breakdown.CharSpace))
+	case "passphrase", "hybrid":
+		lines = append(lines, fmt.Sprintf("Word list size: %d", breakdown.CharSpace))
+	case "shannon":
+		lines = append(lines, fmt.Sprintf("Distinct characters: %d (Shannon entropy of their observed frequencies)", breakdown.CharSpace))
+	default:
+		lines = append(lines, fmt.Sprintf("Character space: %d (character classes present)", breakdown.CharSpace))
+	}
+
+	if breakdown.Method == "passphrase" {
+		lines = append(lines, fmt.Sprintf("Words: %d", breakdown.Words))
+		if breakdown.Separators > 1 {
+			lines = append(lines, fmt.Sprintf("Separator choices: %d", breakdown.Separators))
+			lines = append(lines, fmt.Sprintf("Raw entropy: %d × log2(%d) + %d × log2(%d) = %.1f bits", breakdown.Words, breakdown.CharSpace, breakdown.Words-1, breakdown.Separators, breakdown.RawEntropy))
+		} else {
+			lines = append(lines, fmt.Sprintf("Raw entropy: %d × log2(%d) = %.1f bits", breakdown.Words, breakdown.CharSpace, breakdown.RawEntropy))
+		}
+		return append(lines, fmt.Sprintf("Entropy: %.1f bits", breakdown.Entropy))
+	}
+
+	if breakdown.Method == "hybrid" {
+		lines = append(lines, fmt.Sprintf("Words: %d", breakdown.Words))
+		lines = append(lines, fmt.Sprintf("Length: %d", breakdown.Length))
+		lines = append(lines, fmt.Sprintf("Raw entropy: %d × log2(%d) + %.1f bits of inserted digits and symbols = %.1f bits", breakdown.Words, breakdown.CharSpace, breakdown.InsertionEntropy, breakdown.RawEntropy))
+		return append(lines, fmt.Sprintf("Entropy: %.1f bits", breakdown.Entropy))
+	}
+
+	lines = append(lines, fmt.Sprintf("Length: %d", breakdown.Length))
+
+	switch {
+	case breakdown.Method == "shannon" && breakdown.Length > 0:
+		lines = append(lines, fmt.Sprintf("Raw entropy: %d × %.2f bits per character = %.1f bits", breakdown.Length, breakdown.RawEntropy/float64(breakdown.Length), breakdown.RawEntropy))
+	case breakdown.CharSpace > 0:
+		lines = append(lines, fmt.Sprintf("Raw entropy: %d × log2(%d) = %.1f bits", breakdown.Length, breakdown.CharSpace, breakdown.RawEntropy))
+	case len(breakdown.PositionSpaces) > 0:
+		lines = append(lines, fmt.Sprintf("Raw entropy: %s = %.1f bits", positionSpaceTerms(breakdown.PositionSpaces), breakdown.RawEntropy))
+	default:
+		lines = append(lines, fmt.Sprintf("Raw entropy: %.1f bits", breakdown.RawEntropy))
+	}
+
+	for _, penalty := range breakdown.Penalties {
+		lines = append(lines, fmt.Sprintf("Penalty: × %.2g for %s", penalty.Multiplier, penalty.Name))
+	}
+
+	return append(lines, fmt.Sprintf("Entropy: %.1f bits", breakdown.Entropy))
+}
+
+// positionSpaceTerms sums the entropy of each pattern position, grouping
+// runs of the same size: "4 × log2(26) + log2(16)".
+func positionSpaceTerms(spaces []int) string {
+	var terms []string
+	for i := 0; i < len(spaces); {
+		run := 1
+		for i+run < len(spaces) && spaces[i+run] == spaces[i] {
+			run++
+		}
+		if run == 1 {
+			terms = append(terms, fmt.Sprintf("log2(%d)", spaces[i]))
+		} else {
+			terms = append(terms, fmt.Sprintf("%d × log2(%d)", run, spaces[i]))
+		}
+		i += run
+	}
+	return strings.Join(terms, " + ")
+}
+
+// writePolicyMatrix prints one pass/fail row per policy, in the given order.
+func writePolicyMatrix(w io.Writer, names []string, results map[string][]pwgen.PolicyViolation) {
+	width := len("Policy")
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	fmt.Fprintf(w, "%-*s  Result\n", width, "Policy")
+	for _, name := range names {
+		violations := results[name]
+		switch len(violations) {
+		case 0:
+			fmt.Fprintf(w, "%-*s  ✓ pass\n", width, name)
+		case 1:
+			fmt.Fprintf(w, "%-*s  ✗ fail (1 violation)\n", width, name)
+		default:
+			fmt.Fprintf(w, "%-*s  ✗ fail (%d violations)\n", width, name, len(violations))
+		}
+	}
+}
+
+// entropyModelLabel is appended to an entropy in bits to name the model
+// that produced it, " (shannon)" for instance. The default uniform model
+// is left unlabeled, so the usual output does not change.
+func entropyModelLabel(model string) string {
+	if model == "" || model == pwgen.EntropyModelUniform {
+		return ""
+	}
+	return " (" + model + ")"
+}
+
+// expandIndex replaces every {i} in text with index, zero-padded to the
+// number of digits in count, so "user{i}" gives user001 to user100 for a
+// count of 100.
+func expandIndex(text string, index, count int) string {
+	if !strings.Contains(text, "{i}") {
+		return text
+	}
+	return strings.ReplaceAll(text, "{i}", fmt.Sprintf("%0*d", len(strconv.Itoa(count)), index))
+}
+
+// maskPassword hides all but the first and last character of password, so
+// "Cor3ct!Horsed" becomes "C***********d". Passwords of one or two
+// characters are hidden entirely.
+func maskPassword(password string) string {
+	runes := []rune(password)
+	if len(runes) <= 2 {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+}
+
+// passwordsHidden reports whether passwords are kept off the screen for
+// require_show: it is set, --show is not given and stdout is a terminal.
+// Piped output is printed as usual so scripts keep working.
+func passwordsHidden(requireShow, show bool) bool {
+	return shouldHidePasswords(requireShow, show, term.IsTerminal(int(os.Stdout.Fd())))
+}
+
+func shouldHidePasswords(requireShow, show, isTerminal bool) bool {
+	return requireShow && !show && isTerminal
+}
+
+// writeCharset prints charset for --show-charset, quoted so spaces and
+// other blanks show, with its size and the keyspace size^length of
+// passwords from minLength to maxLength characters.
+func writeCharset(w io.Writer, charset string, minLength, maxLength int) {
+	size := utf8.RuneCountInString(charset)
+	fmt.Fprintf(w, "Charset: %q\n", charset)
+	fmt.Fprintf(w, "Size: %d\n", size)
+
+	bits := func(length int) float64 { return float64(length) * math.Log2(float64(size)) }
+	if minLength == maxLength {
+		fmt.Fprintf(w, "Keyspace: %d^%d = %s (%.1f bits)\n", size, minLength, powerNotation(size, minLength), bits(minLength))
+		return
+	}
+	fmt.Fprintf(w, "Keyspace: %d^%d to %d^%d = %s to %s (%.1f to %.1f bits)\n",
+		size, minLength, size, maxLength,
+		powerNotation(size, minLength), powerNotation(size, maxLength),
+		bits(minLength), bits(maxLength))
+}
+
+// powerNotation formats size^length in scientific notation. It works from
+// logarithms, so keyspaces beyond the range of float64 print too.
+func powerNotation(size, length int) string {
+	if size <= 1 {
+		return strconv.Itoa(size)
+	}
+
+	exponent := float64(length) * math.Log10(float64(size))
+	whole := math.Floor(exponent)
+	mantissa := math.Pow(10, exponent-whole)
+	if mantissa >= 9.995 { // Would round up to 10.00
+		mantissa /= 10
+		whole++
+	}
+	return fmt.Sprintf("%.2fe+%02d", mantissa, int(whole))
+}
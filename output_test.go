@@ -0,0 +1,593 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestCSVOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	output, err := newCSVOutput(&buf, true)
+	if err != nil {
+		t.Fatalf("newCSVOutput() error = %v", err)
+	}
+
+	passwords := []string{`a,b"c`, "plain"}
+	for i, password := range passwords {
+		strength := pwgen.PasswordStrength{Score: 42, Entropy: 31.25, TimeToCrack: "2 hours"}
+		if err := output.write(i+1, password, strength); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+
+	if err := output.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v\n%s", err, buf.String())
+	}
+
+	want := [][]string{
+		{"index", "password", "score", "entropy", "time_to_crack"},
+		{"1", `a,b"c`, "42", "31.2", "2 hours"},
+		{"2", "plain", "42", "31.2", "2 hours"},
+	}
+
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		for j := range want[i] {
+			if records[i][j] != want[i][j] {
+				t.Errorf("record %d field %d = %q, want %q", i, j, records[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestCSVOutputQuotesSpaces(t *testing.T) {
+	var buf bytes.Buffer
+
+	output, err := newCSVOutput(&buf, false)
+	if err != nil {
+		t.Fatalf("newCSVOutput() error = %v", err)
+	}
+	passwords := []string{" lead", "trail ", "in side"}
+	for i, password := range passwords {
+		if err := output.write(i+1, password, pwgen.PasswordStrength{}); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	if err := output.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	for _, want := range []string{`1," lead",`, `2,"trail ",`, `3,in side,`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV output =\n%s\nwant it to contain %s", buf.String(), want)
+		}
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	for i, password := range passwords {
+		if records[i][1] != password {
+			t.Errorf("record %d password = %q, want %q", i, records[i][1], password)
+		}
+	}
+}
+
+func TestCSVOutputEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+
+	output, err := newCSVOutput(&buf, false)
+	if err != nil {
+		t.Fatalf("newCSVOutput() error = %v", err)
+	}
+	passwords := []string{"=1+2", "+x", "-x", "@SUM(A1)", "\tx", "a=b", "'x"}
+	for i, password := range passwords {
+		if err := output.write(i+1, password, pwgen.PasswordStrength{}); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	if err := output.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	want := []string{"'=1+2", "'+x", "'-x", "'@SUM(A1)", "'\tx", "a=b", "'x"}
+	for i := range want {
+		if records[i][1] != want[i] {
+			t.Errorf("record %d password = %q, want %q", i, records[i][1], want[i])
+		}
+	}
+}
+
+func TestCSVOutputNoHeader(t *testing.T) {
+	var buf bytes.Buffer
+
+	output, err := newCSVOutput(&buf, false)
+	if err != nil {
+		t.Fatalf("newCSVOutput() error = %v", err)
+	}
+	if err := output.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("newCSVOutput() without header wrote %q", buf.String())
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{"text", "csv", "json"} {
+		if err := validateFormat(format, outputFormats); err != nil {
+			t.Errorf("validateFormat(%q) error = %v", format, err)
+		}
+	}
+
+	if err := validateFormat("xml", outputFormats); err == nil {
+		t.Error("validateFormat() should reject unknown formats")
+	}
+
+	if err := validateFormat("json", analysisFormats); err != nil {
+		t.Errorf("validateFormat(json) error = %v with --analyze-file", err)
+	}
+}
+
+func TestMarkdownOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	output, err := newMarkdownOutput(&buf, true)
+	if err != nil {
+		t.Fatalf("newMarkdownOutput() error = %v", err)
+	}
+
+	strength := pwgen.PasswordStrength{Level: pwgen.Good, Score: 70, Entropy: 71.45}
+	if err := output.write(1, "a|b", strength, nil); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := output.write(2, "plain", strength, []pwgen.PolicyViolation{{Description: "Too short"}, {Description: "No a|b"}}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := output.finish(nil); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+
+	want := "| # | Password | Score | Level | Entropy (bits) | Policy |\n" +
+		"| --- | -------- | ----- | ----- | -------------- | ------ |\n" +
+		"| 1 | `a\\|b` | 70 | Good | 71.5 | Pass |\n" +
+		"| 2 | `plain` | 70 | Good | 71.5 | Fail: Too short; No a\\|b |\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// Every row has as many unescaped pipes as the header
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if got := strings.Count(line, "|") - strings.Count(line, `\|`); got != 7 {
+			t.Errorf("row %q has %d cell separators, want 7", line, got)
+		}
+	}
+}
+
+func TestMarkdownOutputWithoutPolicy(t *testing.T) {
+	var buf bytes.Buffer
+
+	output, err := newMarkdownOutput(&buf, false)
+	if err != nil {
+		t.Fatalf("newMarkdownOutput() error = %v", err)
+	}
+	if err := output.write(1, "plain", pwgen.PasswordStrength{Level: pwgen.Weak}, []pwgen.PolicyViolation{{Description: "Ignored"}}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	stats := newBatchStats(false)
+	stats.add(pwgen.PasswordStrength{Level: pwgen.Weak}, nil)
+	if err := output.finish(stats); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "| # | Password | Score | Level | Entropy (bits) |" || strings.Contains(buf.String(), "Ignored") {
+		t.Errorf("output has a policy column:\n%s", buf.String())
+	}
+	if lines[3] != "" || !strings.HasPrefix(lines[4], "Stats:") {
+		t.Errorf("output does not end with the stats after a blank line:\n%s", buf.String())
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"plain", "`plain`"},
+		{"a|b|", "`a\\|b\\|`"},
+		{"a`b", "``a`b``"},
+		{"``x", "``` ``x ```"},
+		{" ab ", "`  ab  `"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownCode(tt.password); got != tt.want {
+			t.Errorf("markdownCode(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	var buf bytes.Buffer
+	output := &jsonOutput{w: &buf}
+
+	strength := pwgen.PasswordStrength{Level: pwgen.Good, Score: 70, Entropy: 71.5, TimeToCrack: "51 years"}
+	if err := output.write(1, `a<b&"c`, strength, nil); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := output.write(2, "plain", strength, []pwgen.PolicyViolation{{Description: "Too short"}}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	stats := newBatchStats(false)
+	stats.add(strength, nil)
+	if err := output.finish(stats); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"password":"a<b&\"c"`) {
+		t.Errorf("output escapes the password:\n%s", buf.String())
+	}
+
+	var decoded struct {
+		Passwords []jsonPassword `json:"passwords"`
+		Stats     batchStats     `json:"stats"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if len(decoded.Passwords) != 2 {
+		t.Fatalf("got %d passwords, want 2", len(decoded.Passwords))
+	}
+	if got := decoded.Passwords[0]; got.Index != 1 || got.Password != `a<b&"c` || got.Level != "Good" || got.Score != 70 {
+		t.Errorf("first password = %+v", got)
+	}
+	if got := decoded.Passwords[1].Violations; len(got) != 1 || got[0] != "Too short" {
+		t.Errorf("second password violations = %v, want [Too short]", got)
+	}
+	if decoded.Stats.Count != 1 {
+		t.Errorf("stats count = %d, want 1", decoded.Stats.Count)
+	}
+}
+
+func TestJSONOutputEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	output := &jsonOutput{w: &buf}
+	if err := output.finish(nil); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if _, ok := decoded["stats"]; ok {
+		t.Errorf("output = %s, want no stats without --stats", buf.String())
+	}
+}
+
+func TestFormatCrackTimes(t *testing.T) {
+	crackTimes := map[string]string{
+		"offline-fast-hash": "3 seconds",
+		"online-throttled":  "95 years",
+	}
+
+	want := "online-throttled 95 years; offline-fast-hash 3 seconds"
+	if got := formatCrackTimes(crackTimes); got != want {
+		t.Errorf("formatCrackTimes() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteRecord(t *testing.T) {
+	var buf bytes.Buffer
+	for _, line := range []string{"a b", "c\nd"} {
+		if err := writeRecord(&buf, line, true); err != nil {
+			t.Fatalf("writeRecord() error = %v", err)
+		}
+	}
+	if got, want := buf.String(), "a b\x00c\nd\x00"; got != want {
+		t.Errorf("writeRecord(print0) wrote %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := writeRecord(&buf, "a b", false); err != nil {
+		t.Fatalf("writeRecord() error = %v", err)
+	}
+	if got, want := buf.String(), "a b\n"; got != want {
+		t.Errorf("writeRecord() wrote %q, want %q", got, want)
+	}
+}
+
+func TestWritePolicyMatrix(t *testing.T) {
+	results := map[string][]pwgen.PolicyViolation{
+		"aws":           nil,
+		"high-security": {{Rule: "MinLength"}, {Rule: "MinSymbols"}},
+	}
+
+	var buf bytes.Buffer
+	writePolicyMatrix(&buf, []string{"aws", "high-security"}, results)
+
+	want := "Policy         Result\n" +
+		"aws            ✓ pass\n" +
+		"high-security  ✗ fail (2 violations)\n"
+	if buf.String() != want {
+		t.Errorf("writePolicyMatrix() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestExpandIndex(t *testing.T) {
+	tests := []struct {
+		text         string
+		index, count int
+		want         string
+	}{
+		{"user{i}: ", 7, 100, "user007: "},
+		{"user{i}: ", 100, 100, "user100: "},
+		{"{i}-{i}", 3, 12, "03-03"},
+		{"{i}", 1, 1, "1"},
+		{"no index", 5, 10, "no index"},
+	}
+
+	for _, tt := range tests {
+		if got := expandIndex(tt.text, tt.index, tt.count); got != tt.want {
+			t.Errorf("expandIndex(%q, %d, %d) = %q, want %q", tt.text, tt.index, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestMaskPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"C0mpl3x!Pd", "C********d"},
+		{"abc", "a*c"},
+		{"ab", "**"},
+		{"a", "*"},
+		{"", ""},
+		{"héllo", "h***o"},
+	}
+
+	for _, tt := range tests {
+		if got := maskPassword(tt.password); got != tt.want {
+			t.Errorf("maskPassword(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestShouldHidePasswords(t *testing.T) {
+	tests := []struct {
+		name        string
+		requireShow bool
+		show        bool
+		isTerminal  bool
+		want        bool
+	}{
+		{name: "terminal", requireShow: true, isTerminal: true, want: true},
+		{name: "--show", requireShow: true, show: true, isTerminal: true, want: false},
+		{name: "piped", requireShow: true, isTerminal: false, want: false},
+		{name: "not required", isTerminal: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldHidePasswords(tt.requireShow, tt.show, tt.isTerminal); got != tt.want {
+				t.Errorf("shouldHidePasswords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+
+	file, err := createOutputFile(path, false)
+	if err != nil {
+		t.Fatalf("createOutputFile() error = %v", err)
+	}
+	if _, err := file.WriteString("secret\n"); err != nil {
+		t.Fatalf("WriteString() error = %v", err)
+	}
+	if err := closeOutputFile(file); err != nil {
+		t.Fatalf("closeOutputFile() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("createOutputFile() permissions = %o, want 600", perm)
+	}
+
+	if _, err := createOutputFile(path, false); err == nil {
+		t.Error("createOutputFile() should refuse to overwrite an existing file without force")
+	}
+}
+
+func TestCreateOutputFileForce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	if err := os.WriteFile(path, []byte("old contents\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	file, err := createOutputFile(path, true)
+	if err != nil {
+		t.Fatalf("createOutputFile() error = %v", err)
+	}
+	if _, err := file.WriteString("new\n"); err != nil {
+		t.Fatalf("WriteString() error = %v", err)
+	}
+	if err := closeOutputFile(file); err != nil {
+		t.Fatalf("closeOutputFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("createOutputFile() with force left %q, want the file truncated", data)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("createOutputFile() with force permissions = %o, want 600", perm)
+	}
+}
+
+func TestEntropyBreakdownLines(t *testing.T) {
+	breakdown := pwgen.EntropyBreakdown{
+		Method:     "classes",
+		CharSpace:  36,
+		Length:     9,
+		RawEntropy: 46.53,
+		Penalties:  []pwgen.EntropyPenalty{{Name: "repeated characters", Multiplier: 0.8}},
+		Entropy:    37.22,
+	}
+
+	want := []string{
+		"Character space: 36 (character classes present)",
+		"Length: 9",
+		"Raw entropy: 9 × log2(36) = 46.5 bits",
+		"Penalty: × 0.8 for repeated characters",
+		"Entropy: 37.2 bits",
+	}
+	if got := entropyBreakdownLines(breakdown); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entropyBreakdownLines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Pattern estimates have no single character space
+	pattern := entropyBreakdownLines(pwgen.EntropyBreakdown{Method: "pattern", Length: 8, RawEntropy: 27.4, Entropy: 27.4})
+	if pattern[2] != "Raw entropy: 27.4 bits" {
+		t.Errorf("entropyBreakdownLines() raw entropy line = %q for a pattern", pattern[2])
+	}
+	pattern = entropyBreakdownLines(pwgen.ExplainEntropy("ABCD-1234-e", pwgen.AnalysisOptions{Pattern: "A{4}-9{4}-[a-f]"}))
+	if want := "Raw entropy: 4 × log2(26) + 4 × log2(10) + log2(6) = 34.7 bits"; pattern[2] != want {
+		t.Errorf("entropyBreakdownLines() raw entropy line = %q, want %q", pattern[2], want)
+	}
+
+	// Encoded random bytes count 8 bits each
+	raw := entropyBreakdownLines(pwgen.ExplainEntropy("00ff00ff", pwgen.AnalysisOptions{RawBytes: 4}))
+	if want := "Random bytes: 4\nEntropy: 4 × 8 = 32.0 bits"; strings.Join(raw, "\n") != want {
+		t.Errorf("entropyBreakdownLines() =\n%s\nwant\n%s", strings.Join(raw, "\n"), want)
+	}
+
+	// Passphrases count words instead of characters
+	passphrase := entropyBreakdownLines(pwgen.ExplainEntropy("correct-horse-battery-staple", pwgen.AnalysisOptions{}))
+	want = []string{
+		"Word list size: 7776",
+		"Words: 4",
+		"Raw entropy: 4 × log2(7776) = 51.7 bits",
+		"Entropy: 51.7 bits",
+	}
+	if strings.Join(passphrase, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entropyBreakdownLines() =\n%s\nwant\n%s", strings.Join(passphrase, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Random separators add their own choices
+	separated := entropyBreakdownLines(pwgen.EntropyBreakdown{Method: "passphrase", CharSpace: 7776, Words: 4, Separators: 10, RawEntropy: 61.66, Entropy: 61.66})
+	if want := "Raw entropy: 4 × log2(7776) + 3 × log2(10) = 61.7 bits"; separated[3] != want {
+		t.Errorf("entropyBreakdownLines() raw entropy line = %q, want %q", separated[3], want)
+	}
+
+	// Hybrid passwords count words and the inserted digits and symbols
+	hybrid := entropyBreakdownLines(pwgen.EntropyBreakdown{Method: "hybrid", CharSpace: 4973, Words: 3, Length: 24, InsertionEntropy: 23.31, RawEntropy: 60.13, Entropy: 60.13})
+	want = []string{
+		"Word list size: 4973",
+		"Words: 3",
+		"Length: 24",
+		"Raw entropy: 3 × log2(4973) + 23.3 bits of inserted digits and symbols = 60.1 bits",
+		"Entropy: 60.1 bits",
+	}
+	if strings.Join(hybrid, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entropyBreakdownLines() =\n%s\nwant\n%s", strings.Join(hybrid, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The Shannon model counts distinct characters and their frequencies
+	shannon := entropyBreakdownLines(pwgen.ExplainEntropy("aabb", pwgen.AnalysisOptions{EntropyModel: pwgen.EntropyModelShannon}))
+	want = []string{
+		"Distinct characters: 2 (Shannon entropy of their observed frequencies)",
+		"Length: 4",
+		"Raw entropy: 4 × 1.00 bits per character = 4.0 bits",
+		"Entropy: 4.0 bits",
+	}
+	if strings.Join(shannon, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entropyBreakdownLines() =\n%s\nwant\n%s", strings.Join(shannon, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEntropyModelLabel(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		pwgen.EntropyModelUniform: "",
+		pwgen.EntropyModelShannon: " (shannon)",
+	}
+	for model, want := range tests {
+		if got := entropyModelLabel(model); got != want {
+			t.Errorf("entropyModelLabel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestWriteCharset(t *testing.T) {
+	var out bytes.Buffer
+	writeCharset(&out, "ab c", 8, 8)
+	want := "Charset: \"ab c\"\nSize: 4\nKeyspace: 4^8 = 6.55e+04 (16.0 bits)\n"
+	if out.String() != want {
+		t.Errorf("writeCharset() = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	writeCharset(&out, pwgen.Digits, 4, 6)
+	if !strings.Contains(out.String(), "Keyspace: 10^4 to 10^6 = 1.00e+04 to 1.00e+06 (13.3 to 19.9 bits)") {
+		t.Errorf("writeCharset() with a length range = %q", out.String())
+	}
+}
+
+func TestPowerNotation(t *testing.T) {
+	tests := []struct {
+		size, length int
+		want         string
+	}{
+		{10, 4, "1.00e+04"},
+		{62, 16, "4.77e+28"},
+		{99, 1, "9.90e+01"},
+		{9999, 1, "1.00e+04"}, // 9.999e+03 rounds up a power of ten
+		{94, 1000, "1.34e+1973"},
+		{1, 20, "1"},
+	}
+
+	for _, tt := range tests {
+		if got := powerNotation(tt.size, tt.length); got != tt.want {
+			t.Errorf("powerNotation(%d, %d) = %q, want %q", tt.size, tt.length, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "sync/atomic"
+
+// generated is a password, or the error generating it, from the worker
+// that took the ticket index of the batch.
+type generated struct {
+	index    int
+	password string
+	err      error
+}
+
+// parallelGenerator spreads the count passwords of a batch over jobs
+// workers for --jobs. Each worker calls its own function from newNext, so
+// no state is shared between them beyond what newNext shares on purpose.
+// With ordered set next returns the passwords in ticket order, as a single
+// generator would; otherwise in the order they are finished.
+type parallelGenerator struct {
+	results chan generated
+	ordered bool
+	pending map[int]generated // Finished ahead of want, for ordered
+	want    int
+}
+
+func newParallelGenerator(newNext func() func() (string, error), count, jobs int, ordered bool) *parallelGenerator {
+	var tickets atomic.Int64
+	g := &parallelGenerator{
+		results: make(chan generated, jobs),
+		ordered: ordered,
+		pending: make(map[int]generated),
+	}
+	for range min(jobs, count) {
+		next := newNext()
+		go func() {
+			for {
+				index := int(tickets.Add(1) - 1)
+				if index >= count {
+					return
+				}
+				password, err := next()
+				g.results <- generated{index: index, password: password, err: err}
+			}
+		}()
+	}
+	return g
+}
+
+// next returns the next password of the batch. It must be called exactly
+// count times.
+func (g *parallelGenerator) next() (string, error) {
+	if !g.ordered {
+		result := <-g.results
+		return result.password, result.err
+	}
+
+	for {
+		if result, ok := g.pending[g.want]; ok {
+			delete(g.pending, g.want)
+			g.want++
+			return result.password, result.err
+		}
+		result := <-g.results
+		g.pending[result.index] = result
+	}
+}
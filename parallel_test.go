@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestParallelGenerator(t *testing.T) {
+	// Every ticket is generated exactly once, whichever worker takes it
+	var calls atomic.Int64
+	newNext := func() func() (string, error) {
+		return func() (string, error) {
+			return strconv.Itoa(int(calls.Add(1))), nil
+		}
+	}
+
+	const count = 200
+	for _, ordered := range []bool{false, true} {
+		calls.Store(0)
+		parallel := newParallelGenerator(newNext, count, 8, ordered)
+		seen := make(map[string]bool)
+		for i := 0; i < count; i++ {
+			password, err := parallel.next()
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+			if seen[password] {
+				t.Fatalf("next() returned %q twice", password)
+			}
+			seen[password] = true
+		}
+		if calls.Load() != count {
+			t.Errorf("ordered %v: workers generated %d passwords, want exactly %d", ordered, calls.Load(), count)
+		}
+	}
+}
+
+func TestParallelGeneratorOrdered(t *testing.T) {
+	// Results that finish out of order are held back until their turn
+	parallel := &parallelGenerator{results: make(chan generated, 3), ordered: true, pending: make(map[int]generated)}
+	parallel.results <- generated{index: 2, password: "c"}
+	parallel.results <- generated{index: 0, password: "a"}
+	parallel.results <- generated{index: 1, password: "b"}
+
+	for _, want := range []string{"a", "b", "c"} {
+		if password, _ := parallel.next(); password != want {
+			t.Errorf("next() = %q, want %q", password, want)
+		}
+	}
+	if len(parallel.pending) != 0 {
+		t.Errorf("next() left %d results pending", len(parallel.pending))
+	}
+}
+
+func TestParallelGeneratorErrors(t *testing.T) {
+	// A failed password is returned as its own result, not dropped
+	errFailed := errors.New("failed")
+	var calls atomic.Int64
+	newNext := func() func() (string, error) {
+		return func() (string, error) {
+			if calls.Add(1)%3 == 0 {
+				return "", errFailed
+			}
+			return "password", nil
+		}
+	}
+
+	failed := 0
+	parallel := newParallelGenerator(newNext, 30, 4, false)
+	for i := 0; i < 30; i++ {
+		if _, err := parallel.next(); errors.Is(err, errFailed) {
+			failed++
+		}
+	}
+	if failed != 10 {
+		t.Errorf("next() returned %d errors, want 10", failed)
+	}
+}
+
+func TestParallelGeneratorUnique(t *testing.T) {
+	// "abc" at length 3 has exactly 27 passwords, so the workers collide
+	// constantly and the shared set must still keep the batch distinct
+	config := pwgen.Config{Length: 3, Charset: "abc"}
+	seen := newUniqueSet()
+	newNext := func() func() (string, error) {
+		return newUniqueGenerator(func() (string, error) {
+			return pwgen.Generate(config)
+		}, seen, 0, 0).next
+	}
+
+	parallel := newParallelGenerator(newNext, 27, 8, false)
+	passwords := make(map[string]bool)
+	for i := 0; i < 27; i++ {
+		password, err := parallel.next()
+		if err != nil {
+			t.Fatalf("next() error = %v after %d passwords", err, i)
+		}
+		if passwords[password] {
+			t.Fatalf("next() returned duplicate %q", password)
+		}
+		passwords[password] = true
+	}
+	if seen.size() != 27 {
+		t.Errorf("unique set holds %d passwords, want 27", seen.size())
+	}
+}
+
+func BenchmarkParallelGenerator(b *testing.B) {
+	config := pwgen.Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, IncludeSymbols: true}
+	newNext := func() func() (string, error) {
+		return func() (string, error) {
+			return pwgen.Generate(config)
+		}
+	}
+
+	const count = 10000
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for b.Loop() {
+				parallel := newParallelGenerator(newNext, count, jobs, true)
+				for range count {
+					if _, err := parallel.next(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package pwgen
+
+import (
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// CanAlternateClasses reports whether passwords generated with
+// config.AlternateClasses actually alternate: the charset must span at
+// least two classes, and no class minimum may need more than every other
+// position. Otherwise generation falls back to unconstrained passwords.
+func CanAlternateClasses(config Config) bool {
+	present := 0
+	for _, class := range classLimits(config) {
+		if class.chars != "" {
+			present++
+		}
+		if class.min > (config.Length+1)/2 {
+			return false
+		}
+	}
+	return present >= 2
+}
+
+// generateAlternating draws each character uniformly among the charset
+// characters outside the class of the one before, so no two neighbours
+// share a class, and retries until the class limits of config hold. It
+// returns nil without an error when no attempt met the limits, leaving the
+// caller to fall back to unconstrained generation.
+func generateAlternating(config Config, random io.Reader) ([]rune, error) {
+	classes := classLimits(config)
+
+	// allowed[c] holds every character outside class c
+	allowed := make([][]rune, len(classes))
+	owners := make([][]int, len(classes))
+	for c := range classes {
+		for i, class := range classes {
+			if i == c {
+				continue
+			}
+			for _, char := range class.chars {
+				allowed[c] = append(allowed[c], char)
+				owners[c] = append(owners[c], i)
+			}
+		}
+	}
+	charset := []rune(buildCharset(config))
+
+	for attempt := 0; attempt < maxAttempts(config); attempt++ {
+		password := make([]rune, 0, config.Length)
+
+		previous := -1
+		for len(password) < config.Length {
+			choices := charset
+			if previous >= 0 {
+				choices = allowed[previous]
+			}
+
+			index, err := randomInt(random, len(choices))
+			if err != nil {
+				clear(password)
+				return nil, err
+			}
+			password = append(password, choices[index])
+
+			if previous >= 0 {
+				previous = owners[previous][index]
+			} else {
+				previous = classOf(classes, choices[index])
+			}
+		}
+
+		if meetsLimits(config, password) {
+			return password, nil
+		}
+		clear(password)
+	}
+
+	return nil, nil
+}
+
+// classOf returns the index of the class in classes that holds char.
+func classOf(classes []classLimit, char rune) int {
+	for i, class := range classes {
+		if strings.ContainsRune(class.chars, char) {
+			return i
+		}
+	}
+	return -1
+}
+
+// alternatingEntropy is the entropy in bits of length characters drawn as
+// generateAlternating does, given the size of each character class: the
+// first from all of them, and each next one from the classes other than
+// the previous character's. It tracks the probability of each class at
+// every position, since a character from a small class leaves more
+// choices for the next one.
+func alternatingEntropy(sizes []int, length int) float64 {
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	if total == 0 || length < 1 {
+		return 0
+	}
+
+	probs := make([]float64, len(sizes))
+	for c, size := range sizes {
+		probs[c] = float64(size) / float64(total)
+	}
+
+	entropy := math.Log2(float64(total))
+	for position := 1; position < length; position++ {
+		next := make([]float64, len(sizes))
+		for c, p := range probs {
+			others := total - sizes[c]
+			if p == 0 || others == 0 {
+				continue
+			}
+
+			entropy += p * math.Log2(float64(others))
+			for j, size := range sizes {
+				if j != c {
+					next[j] += p * float64(size) / float64(others)
+				}
+			}
+		}
+		probs = next
+	}
+
+	return entropy
+}
+
+// alternationMultiplier is the fraction of the entropy of length freely
+// drawn characters that remains when neighbours must differ in class. It
+// is 1 when fewer than two classes are present, as nothing is constrained.
+func alternationMultiplier(sizes []int, length int) float64 {
+	total, present := 0, 0
+	for _, size := range sizes {
+		total += size
+		if size > 0 {
+			present++
+		}
+	}
+	if present < 2 || length < 1 {
+		return 1
+	}
+
+	return alternatingEntropy(sizes, length) / (float64(length) * math.Log2(float64(total)))
+}
+
+// alternationPenalty is the entropy lost to Config.AlternateClasses for
+// password, drawn from charset or, when it is empty, from the full classes
+// present in password as classCharSpace counts them.
+func alternationPenalty(password, charset string) (EntropyPenalty, bool) {
+	var sizes []int
+	if charset != "" {
+		sizes = classSizes(charset)
+	} else {
+		sizes = classSizes(password)
+		for c, size := range []int{26, 26, 10, 32} {
+			if sizes[c] > 0 {
+				sizes[c] = size
+			}
+		}
+	}
+
+	multiplier := alternationMultiplier(sizes, utf8.RuneCountInString(password))
+	if multiplier == 1 {
+		return EntropyPenalty{}, false
+	}
+	return EntropyPenalty{Name: "alternating character classes", Multiplier: multiplier}, true
+}
+
+// classSizes counts the characters of charset in each class, in the order
+// of classLimits.
+func classSizes(charset string) []int {
+	sizes := make([]int, 4)
+	for _, char := range uniqueRunes(charset) {
+		sizes[runeClass(char)]++
+	}
+	return sizes
+}
@@ -0,0 +1,120 @@
+package pwgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGenerateAlternateClasses(t *testing.T) {
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, IncludeSymbols: true, AlternateClasses: true}
+	classes := classLimits(config)
+	generator := NewSeededGenerator("alternate-classes")
+
+	for i := 0; i < 200; i++ {
+		password, err := generator.Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+
+		runes := []rune(password)
+		for j := 1; j < len(runes); j++ {
+			if classOf(classes, runes[j]) == classOf(classes, runes[j-1]) {
+				t.Fatalf("Generate() = %q, %q and %q are adjacent and of the same class", password, runes[j-1], runes[j])
+			}
+		}
+	}
+}
+
+func TestGenerateAlternateClassesMeetsMinimums(t *testing.T) {
+	config := Config{Length: 8, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, MinDigits: 4, AlternateClasses: true}
+	generator := NewSeededGenerator("alternate-minimums")
+
+	for i := 0; i < 100; i++ {
+		password, err := generator.Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		digits := 0
+		for _, char := range password {
+			if isDigit(char) {
+				digits++
+			}
+		}
+		if digits < 4 {
+			t.Fatalf("Generate() = %q, want at least 4 digits", password)
+		}
+	}
+}
+
+func TestGenerateAlternateClassesFallsBack(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"single class", Config{Length: 12, IncludeLower: true, AlternateClasses: true}},
+		{"minimum above half", Config{Length: 6, IncludeLower: true, IncludeDigits: true, MinDigits: 4, AlternateClasses: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if CanAlternateClasses(tt.config) {
+				t.Fatal("CanAlternateClasses() = true, want false")
+			}
+
+			password, err := NewSeededGenerator("alternate-fallback").Generate(tt.config)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if len(password) != tt.config.Length {
+				t.Errorf("Generate() = %q, want %d characters", password, tt.config.Length)
+			}
+		})
+	}
+}
+
+func TestAlternatingEntropy(t *testing.T) {
+	// With two classes of 10 the first character has 20 choices and every
+	// later one the 10 of the other class
+	want := math.Log2(20) + 7*math.Log2(10)
+	if got := alternatingEntropy([]int{10, 10, 0, 0}, 8); math.Abs(got-want) > 1e-9 {
+		t.Errorf("alternatingEntropy() = %.4f, want %.4f", got, want)
+	}
+
+	if got := alternationMultiplier([]int{0, 26, 0, 0}, 8); got != 1 {
+		t.Errorf("alternationMultiplier() for one class = %v, want 1", got)
+	}
+	if got := alternationMultiplier([]int{26, 26, 10, 0}, 16); got >= 1 || got <= 0.8 {
+		t.Errorf("alternationMultiplier() for letters and digits = %.3f, want between 0.8 and 1", got)
+	}
+}
+
+func TestExplainEntropyAlternateClasses(t *testing.T) {
+	charset := "abcdefghij0123456789"
+	password := "a1b2c3d4"
+
+	plain := ExplainEntropy(password, AnalysisOptions{Charset: charset})
+	breakdown := ExplainEntropy(password, AnalysisOptions{Charset: charset, AlternateClasses: true})
+
+	if len(breakdown.Penalties) != len(plain.Penalties)+1 {
+		t.Fatalf("ExplainEntropy() penalties = %v, want one more than %v", breakdown.Penalties, plain.Penalties)
+	}
+	penalty := breakdown.Penalties[len(breakdown.Penalties)-1]
+	if penalty.Name != "alternating character classes" {
+		t.Errorf("ExplainEntropy() penalty = %q, want alternating character classes", penalty.Name)
+	}
+
+	want := math.Log2(20) + 7*math.Log2(10)
+	if math.Abs(breakdown.RawEntropy*penalty.Multiplier-want) > 1e-9 {
+		t.Errorf("ExplainEntropy() alternating entropy = %.4f, want %.4f", breakdown.RawEntropy*penalty.Multiplier, want)
+	}
+}
+
+func TestMaxEntropyAlternateClasses(t *testing.T) {
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true}
+	plain := maxEntropy(config)
+
+	config.AlternateClasses = true
+	if got := maxEntropy(config); got >= plain {
+		t.Errorf("maxEntropy() with AlternateClasses = %.1f, want less than %.1f", got, plain)
+	}
+}
@@ -0,0 +1,58 @@
+package pwgen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Analyzer estimates the strength of a password. BuiltinAnalyzer is the
+// heuristic scorer of AnalyzePasswordStrength; implementing Analyzer lets
+// callers swap in another engine, such as a zxcvbn binding, wherever the
+// strength is needed.
+type Analyzer interface {
+	Analyze(password string) PasswordStrength
+}
+
+// AnalyzerFunc adapts a function to Analyzer.
+type AnalyzerFunc func(password string) PasswordStrength
+
+// Analyze returns f(password).
+func (f AnalyzerFunc) Analyze(password string) PasswordStrength {
+	return f(password)
+}
+
+// BuiltinAnalyzer is the default Analyzer, scoring with the heuristics of
+// AnalyzePasswordStrengthWithOptions given Options.
+type BuiltinAnalyzer struct {
+	Options AnalysisOptions
+}
+
+// Analyze returns AnalyzePasswordStrengthWithOptions(password, a.Options).
+func (a BuiltinAnalyzer) Analyze(password string) PasswordStrength {
+	return AnalyzePasswordStrengthWithOptions(password, a.Options)
+}
+
+// AnalyzerBuiltin names BuiltinAnalyzer for NewAnalyzer.
+const AnalyzerBuiltin = "builtin"
+
+// Analyzers lists the names NewAnalyzer accepts.
+var Analyzers = []string{AnalyzerBuiltin}
+
+// ValidateAnalyzer reports an error for a name other than the empty string,
+// which means AnalyzerBuiltin, and those in Analyzers.
+func ValidateAnalyzer(name string) error {
+	if name != "" && !slices.Contains(Analyzers, name) {
+		return fmt.Errorf("unknown analyzer '%s' (available: %s)", name, strings.Join(Analyzers, ", "))
+	}
+	return nil
+}
+
+// NewAnalyzer returns the Analyzer called name in Analyzers, configured
+// with opts. An empty name means AnalyzerBuiltin.
+func NewAnalyzer(name string, opts AnalysisOptions) (Analyzer, error) {
+	if err := ValidateAnalyzer(name); err != nil {
+		return nil, err
+	}
+	return BuiltinAnalyzer{Options: opts}, nil
+}
@@ -0,0 +1,56 @@
+package pwgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinAnalyzer(t *testing.T) {
+	opts := AnalysisOptions{CrackTimes: true, GuessRate: 1e4}
+	analyzer, err := NewAnalyzer(AnalyzerBuiltin, opts)
+	if err != nil {
+		t.Fatalf("NewAnalyzer() error = %v", err)
+	}
+
+	for _, password := range []string{"password", "Tr0ub4dor&3", "correct-horse-battery-staple"} {
+		got, want := analyzer.Analyze(password), AnalyzePasswordStrengthWithOptions(password, opts)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Analyze(%q) = %+v, want the AnalyzePasswordStrengthWithOptions result %+v", password, got, want)
+		}
+	}
+
+	if got := (BuiltinAnalyzer{}).Analyze("password"); !reflect.DeepEqual(got, AnalyzePasswordStrength("password")) {
+		t.Errorf("zero BuiltinAnalyzer.Analyze() = %+v, want AnalyzePasswordStrength", got)
+	}
+}
+
+func TestAnalyzerStub(t *testing.T) {
+	// A stub engine stands in wherever an Analyzer is taken
+	var analyzed []string
+	var analyzer Analyzer = AnalyzerFunc(func(password string) PasswordStrength {
+		analyzed = append(analyzed, password)
+		return PasswordStrength{Score: 42, Level: Fair, Entropy: 1}
+	})
+
+	if got := analyzer.Analyze("anything"); got.Score != 42 || got.Level != Fair {
+		t.Errorf("Analyze() = %+v, want the stub's result", got)
+	}
+	if len(analyzed) != 1 || analyzed[0] != "anything" {
+		t.Errorf("stub saw %v, want [anything]", analyzed)
+	}
+}
+
+func TestNewAnalyzer(t *testing.T) {
+	if analyzer, err := NewAnalyzer("", AnalysisOptions{}); err != nil || analyzer == nil {
+		t.Errorf("NewAnalyzer(\"\") = %v, %v, want the builtin analyzer", analyzer, err)
+	}
+
+	_, err := NewAnalyzer("zxcvbn", AnalysisOptions{})
+	if err == nil || !strings.Contains(err.Error(), "available: builtin") {
+		t.Errorf("NewAnalyzer(\"zxcvbn\") error = %v, want the available analyzers listed", err)
+	}
+	if err := ValidateAnalyzer(AnalyzerBuiltin); err != nil {
+		t.Errorf("ValidateAnalyzer(%q) error = %v", AnalyzerBuiltin, err)
+	}
+}
@@ -0,0 +1,61 @@
+package pwgen
+
+import "strings"
+
+// CharClass is the class of a password character, as counted by the class
+// limits of Config such as MinUpper and MaxSymbols.
+type CharClass int
+
+const (
+	ClassLower CharClass = iota
+	ClassUpper
+	ClassDigit
+	ClassSymbol
+	// ClassOther is a character outside the charset it is classed against.
+	ClassOther
+)
+
+// String returns the name of the class, such as "lowercase".
+func (c CharClass) String() string {
+	switch c {
+	case ClassLower:
+		return "lowercase"
+	case ClassUpper:
+		return "uppercase"
+	case ClassDigit:
+		return "digit"
+	case ClassSymbol:
+		return "symbol"
+	default:
+		return "other"
+	}
+}
+
+// CharClasses returns the class of each character of password within
+// charset, such as the Charset of the Config that generated it. Like the
+// class limits, anything but an ASCII letter or digit in charset counts as
+// a symbol, and a character charset does not hold is ClassOther. With an
+// empty charset every character is classed by itself, for passwords not
+// drawn from one, such as passphrases.
+func CharClasses(password, charset string) []CharClass {
+	var classes []CharClass
+	for _, char := range password {
+		classes = append(classes, charClass(char, charset))
+	}
+	return classes
+}
+
+func charClass(char rune, charset string) CharClass {
+	switch {
+	case charset != "" && !strings.ContainsRune(charset, char):
+		return ClassOther
+	case isLower(char):
+		return ClassLower
+	case isUpper(char):
+		return ClassUpper
+	case isDigit(char):
+		return ClassDigit
+	default:
+		return ClassSymbol
+	}
+}
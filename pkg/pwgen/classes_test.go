@@ -0,0 +1,38 @@
+package pwgen
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCharClasses(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		charset  string
+		want     []CharClass
+	}{
+		{"every class", "aB3$", Charset(Config{IncludeLower: true, IncludeUpper: true, IncludeDigits: true, IncludeSymbols: true}), []CharClass{ClassLower, ClassUpper, ClassDigit, ClassSymbol}},
+		{"space and non-ASCII are symbols", "a €", "a €", []CharClass{ClassLower, ClassSymbol, ClassSymbol}},
+		{"outside the charset", "ab-", "ab", []CharClass{ClassLower, ClassLower, ClassOther}},
+		{"no charset", "Tree-9", "", []CharClass{ClassUpper, ClassLower, ClassLower, ClassLower, ClassSymbol, ClassDigit}},
+		{"empty", "", "abc", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CharClasses(tt.password, tt.charset); !slices.Equal(got, tt.want) {
+				t.Errorf("CharClasses(%q, %q) = %v, want %v", tt.password, tt.charset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharClassString(t *testing.T) {
+	want := []string{"lowercase", "uppercase", "digit", "symbol", "other"}
+	for class := ClassLower; class <= ClassOther; class++ {
+		if got := class.String(); got != want[class] {
+			t.Errorf("CharClass(%d).String() = %q, want %q", class, got, want[class])
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package pwgen
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// runeClass returns the class of char in the order of classLimits:
+// uppercase, lowercase, digits and symbols.
+func runeClass(char rune) int {
+	switch {
+	case isUpper(char):
+		return 0
+	case isLower(char):
+		return 1
+	case isDigit(char):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// longestClassRun returns the length of the longest run of characters of
+// the same class, e.g. 4 for "abcd12!".
+func longestClassRun(password string) int {
+	longest, run := 0, 0
+	previous := -1
+	for _, char := range password {
+		class := runeClass(char)
+		if class == previous {
+			run++
+		} else {
+			run = 1
+		}
+		previous = class
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// limitClassRuns re-picks, in place, every character that would extend a
+// run of one class past maxRun, choosing uniformly among the charset
+// characters of the other classes, and among those not yet in password
+// when config.NoRepeat is set.
+func limitClassRuns(random io.Reader, password []rune, config Config, maxRun int) error {
+	charset := []rune(buildCharset(config))
+
+	run := 1
+	for i := 1; i < len(password); i++ {
+		class := runeClass(password[i-1])
+		if runeClass(password[i]) != class {
+			run = 1
+			continue
+		}
+
+		run++
+		if run <= maxRun {
+			continue
+		}
+
+		var others []rune
+		for _, char := range charset {
+			if runeClass(char) != class && !(config.NoRepeat && slices.Contains(password, char)) {
+				others = append(others, char)
+			}
+		}
+		if len(others) == 0 {
+			return fmt.Errorf("a charset of a single class cannot avoid class runs longer than %d", maxRun)
+		}
+
+		index, err := randomInt(random, len(others))
+		if err != nil {
+			return err
+		}
+		password[i] = others[index]
+		run = 1
+	}
+
+	return nil
+}
+
+// singleClass reports whether every character of charset is of one class.
+func singleClass(charset string) bool {
+	first := -1
+	for _, char := range charset {
+		if first < 0 {
+			first = runeClass(char)
+		} else if runeClass(char) != first {
+			return false
+		}
+	}
+	return true
+}
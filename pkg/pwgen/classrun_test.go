@@ -0,0 +1,114 @@
+package pwgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLongestClassRun(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd1234!", 4},
+		{"aB3$cD9!", 1},
+		{"AB12cd!?", 2},
+		{"x!@#$%", 5},
+		{"äöü1", 3}, // Non-ASCII letters count as symbols, as in policy validation
+	}
+
+	for _, tt := range tests {
+		if got := longestClassRun(tt.password); got != tt.want {
+			t.Errorf("longestClassRun(%q) = %d, want %d", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyMaxSameClassRun(t *testing.T) {
+	policy := PasswordPolicy{Name: "Runs", MaxSameClassRun: 3}
+
+	for _, password := range []string{"aB3$cD9!", "abc123XYZ!?", "Ab1!Ab1!"} {
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) != 0 {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want no violations", password, violations)
+		}
+	}
+	for _, password := range []string{"abcd1234!", "Xy12345!", "Ab1!@#$%"} {
+		violations := ValidatePasswordAgainstPolicy(password, policy)
+		if len(violations) != 1 || violations[0].Rule != "MaxSameClassRun" {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want a MaxSameClassRun violation", password, violations)
+		}
+	}
+
+	var config Config
+	ApplyPolicyToConfig(policy, &config)
+	if config.MaxClassRun != 3 {
+		t.Errorf("ApplyPolicyToConfig() MaxClassRun = %d, want 3", config.MaxClassRun)
+	}
+
+	problems := CheckPolicy(PasswordPolicy{Name: "Runs", MaxLength: 8, MinDigits: 7, MaxSameClassRun: 2})
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "needs 10 characters") {
+		t.Errorf("CheckPolicy() = %v, want the digit minimum reported", problems)
+	}
+	if problems := CheckPolicy(PasswordPolicy{Name: "Runs", MaxSameClassRun: -1}); len(problems) != 1 {
+		t.Errorf("CheckPolicy() = %v, want the negative run reported", problems)
+	}
+}
+
+func TestGenerateMaxClassRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"letters and digits", Config{Length: 32, IncludeLower: true, IncludeDigits: true, MaxClassRun: 2}},
+		{"every class", Config{Length: 24, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, IncludeSymbols: true, MaxClassRun: 1}},
+		{"with minimums", Config{Length: 12, IncludeLower: true, IncludeDigits: true, MinDigits: 6, MaxClassRun: 3}},
+		{"with max repeat", Config{Length: 20, Charset: "ab12", MaxRepeat: 1, MaxClassRun: 2}},
+		{"without repeats", Config{Length: 12, IncludeUpper: true, IncludeDigits: true, NoRepeat: true, MaxClassRun: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := NewSeededGenerator(tt.name)
+			for i := 0; i < 50; i++ {
+				password, err := generator.Generate(tt.config)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+
+				if run := longestClassRun(password); run > tt.config.MaxClassRun {
+					t.Fatalf("Generate() = %q, has a class run of %d, want at most %d", password, run, tt.config.MaxClassRun)
+				}
+				if !meetsLimits(tt.config, []rune(password)) || !meetsRunLimits(tt.config, []rune(password)) {
+					t.Fatalf("Generate() = %q, does not meet the limits", password)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigMaxClassRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"negative", Config{Length: 8, IncludeLower: true, MaxClassRun: -1}, "must not be negative"},
+		{"single class", Config{Length: 8, IncludeDigits: true, MaxClassRun: 3}, "single class cannot avoid class runs longer than 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	// Short enough to fit in one run
+	if err := ValidateConfig(Config{Length: 3, IncludeDigits: true, MaxClassRun: 3}); err != nil {
+		t.Errorf("ValidateConfig() error = %v, want none", err)
+	}
+}
@@ -0,0 +1,208 @@
+package pwgen
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswordsData and englishWordsData are frequency-ordered lists of
+// 4+ character entries derived from the zxcvbn password and English lists.
+var (
+	//go:embed common_passwords.txt
+	commonPasswordsData string
+
+	//go:embed english_words.txt
+	englishWordsData string
+)
+
+type dictionary struct {
+	name  string
+	words map[string]bool
+	// minLength is the shortest word reported as a match. Short English
+	// words turn up by chance in random strings, so they are skipped.
+	minLength int
+	// basePenalty is deducted once when any word from this dictionary
+	// matches, plus coveragePenalty scaled by the fraction of the password
+	// its words cover.
+	basePenalty     float64
+	coveragePenalty float64
+}
+
+// englishDictionary is shared by the strength analysis and the
+// ForbidDictionaryWords policy rule.
+var englishDictionary = loadDictionary("english", englishWordsData, 5, 0, 12)
+
+// dictionaries are searched in order, so a word listed as a common password
+// is reported as such even if it is also an English word.
+var dictionaries = []dictionary{
+	loadDictionary("passwords", commonPasswordsData, 4, 10, 30),
+	englishDictionary,
+}
+
+var maxDictionaryWordLength = longestDictionaryWord()
+
+func loadDictionary(name, data string, minLength int, basePenalty, coveragePenalty float64) dictionary {
+	d := dictionary{
+		name:            name,
+		words:           make(map[string]bool),
+		minLength:       minLength,
+		basePenalty:     basePenalty,
+		coveragePenalty: coveragePenalty,
+	}
+
+	for _, word := range strings.Fields(data) {
+		d.words[word] = true
+	}
+
+	return d
+}
+
+func longestDictionaryWord() int {
+	longest := 0
+	for _, d := range dictionaries {
+		for word := range d.words {
+			if len(word) > longest {
+				longest = len(word)
+			}
+		}
+	}
+	return longest
+}
+
+// Match is a dictionary word found inside a password.
+type Match struct {
+	Word       string // Dictionary word that matched
+	Token      string // Matching part of the password as written
+	Dictionary string // Name of the dictionary the word came from
+	Start      int    // Byte offset where the match starts
+	End        int    // Byte offset just past the match
+	Leet       bool   // Whether l33t substitutions were needed to match
+}
+
+// findDictionaryMatches scans the password left to right and reports the
+// longest dictionary word starting at each position, case-insensitively and
+// with l33t substitutions undone. Matches never overlap.
+func findDictionaryMatches(password string) []Match {
+	var matches []Match
+
+	lower, offsets := foldCase(password)
+	normalized := leetNormalize(lower)
+
+	for start := 0; start < len(lower); {
+		match, found := longestDictionaryWordAt(password, lower, normalized, offsets, start)
+		if !found {
+			start++
+			continue
+		}
+
+		matches = append(matches, match)
+		start += len(match.Word)
+	}
+
+	return matches
+}
+
+// foldCase returns strings.ToLower(password) and, for each byte offset of
+// it up to its length, the byte offset of the same character in password.
+// Lowercasing changes the length of some characters, e.g. "Ⱥ" takes 2
+// bytes and "ⱥ" 3, so offsets in the lowercase copy cannot slice password.
+func foldCase(password string) (string, []int) {
+	var lower strings.Builder
+	offsets := make([]int, 0, len(password)+1)
+	for i, char := range password {
+		n, _ := lower.WriteRune(unicode.ToLower(char))
+		for range n {
+			offsets = append(offsets, i)
+		}
+	}
+	return lower.String(), append(offsets, len(password))
+}
+
+func longestDictionaryWordAt(password, lower, normalized string, offsets []int, start int) (Match, bool) {
+	maxEnd := start + maxDictionaryWordLength
+	if maxEnd > len(lower) {
+		maxEnd = len(lower)
+	}
+
+	for end := maxEnd; end > start; end-- {
+		for _, d := range dictionaries {
+			if end-start < d.minLength {
+				continue
+			}
+
+			from, to := offsets[start], offsets[end]
+			if d.words[lower[start:end]] {
+				return Match{Word: lower[start:end], Token: password[from:to], Dictionary: d.name, Start: from, End: to}, true
+			}
+
+			if d.words[normalized[start:end]] {
+				return Match{Word: normalized[start:end], Token: password[from:to], Dictionary: d.name, Start: from, End: to, Leet: true}, true
+			}
+		}
+	}
+
+	return Match{}, false
+}
+
+// findEnglishWords returns the English words of at least minLength letters
+// embedded in password, case-insensitively and with l33t substitutions
+// undone. Like findDictionaryMatches it takes the longest word at each
+// position and never reports overlapping words. The wordlist only holds
+// words of 4 letters or more, so smaller minimums act as 4.
+func findEnglishWords(password string, minLength int) []string {
+	var words []string
+
+	lower := strings.ToLower(password)
+	normalized := leetNormalize(lower)
+
+	for start := 0; start < len(lower); {
+		word := longestEnglishWordAt(lower, normalized, start, max(minLength, 1))
+		if word == "" {
+			start++
+			continue
+		}
+
+		words = append(words, word)
+		start += len(word)
+	}
+
+	return words
+}
+
+func longestEnglishWordAt(lower, normalized string, start, minLength int) string {
+	for end := min(start+maxDictionaryWordLength, len(lower)); end-start >= minLength; end-- {
+		if englishDictionary.words[lower[start:end]] {
+			return lower[start:end]
+		}
+		if englishDictionary.words[normalized[start:end]] {
+			return normalized[start:end]
+		}
+	}
+	return ""
+}
+
+// dictionaryPenalty returns the score deduction for the given matches,
+// weighted by how much of the password each one covers.
+func dictionaryPenalty(password string, matches []Match) int {
+	if len(password) == 0 {
+		return 0
+	}
+
+	penalty := 0.0
+	for _, d := range dictionaries {
+		covered := 0
+		for _, match := range matches {
+			if match.Dictionary == d.name {
+				covered += match.End - match.Start
+			}
+		}
+
+		if covered > 0 {
+			penalty += d.basePenalty + d.coveragePenalty*float64(covered)/float64(len(password))
+		}
+	}
+
+	return int(math.Round(penalty))
+}
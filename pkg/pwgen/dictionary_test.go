@@ -0,0 +1,92 @@
+package pwgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindDictionaryMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     []Match
+	}{
+		{
+			name:     "common password",
+			password: "Password",
+			want:     []Match{{Word: "password", Token: "Password", Dictionary: "passwords", Start: 0, End: 8}},
+		},
+		{
+			name:     "leet substitutions",
+			password: "X!p@ssw0rd",
+			want:     []Match{{Word: "password", Token: "p@ssw0rd", Dictionary: "passwords", Start: 2, End: 10, Leet: true}},
+		},
+		{
+			name:     "english word",
+			password: "lowercase123!",
+			want:     []Match{{Word: "lower", Token: "lower", Dictionary: "english", Start: 0, End: 5}},
+		},
+		{
+			name:     "short english words are ignored",
+			password: "AllTypes123!",
+			want:     nil,
+		},
+		{
+			// Lowercase Ⱥ takes 3 bytes instead of 2, and İ becomes a 1-byte i,
+			// yet offsets and tokens stay those of the password as written
+			name:     "lowercase changes the length",
+			password: "ȺȺȺȺȺpassword",
+			want:     []Match{{Word: "password", Token: "password", Dictionary: "passwords", Start: 10, End: 18}},
+		},
+		{
+			name:     "lowercase shortens the password",
+			password: "İİİP@SSWORD",
+			want:     []Match{{Word: "password", Token: "P@SSWORD", Dictionary: "passwords", Start: 6, End: 14, Leet: true}},
+		},
+		{
+			name:     "random characters",
+			password: "Rx7!kNm9@pQz",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findDictionaryMatches(tt.password)
+			if len(got) != len(tt.want) {
+				t.Fatalf("findDictionaryMatches(%q) = %+v, want %+v", tt.password, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("findDictionaryMatches(%q)[%d] = %+v, want %+v", tt.password, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDictionaryPenaltyScalesWithCoverage(t *testing.T) {
+	lowCoverage := "password!Rx7kNm9@pQzTb4$"
+	highCoverage := "password!"
+
+	lowPenalty := dictionaryPenalty(lowCoverage, findDictionaryMatches(lowCoverage))
+	highPenalty := dictionaryPenalty(highCoverage, findDictionaryMatches(highCoverage))
+
+	if lowPenalty == 0 {
+		t.Fatalf("dictionaryPenalty(%q) = 0, want a penalty", lowCoverage)
+	}
+	if highPenalty <= lowPenalty {
+		t.Errorf("dictionaryPenalty(%q) = %d, want more than %d for %q", highCoverage, highPenalty, lowPenalty, lowCoverage)
+	}
+}
+
+func TestAnalyzePasswordStrengthNamesDictionaryWords(t *testing.T) {
+	result := AnalyzePasswordStrength("C0mpl3x!P@ssw0rd#2024")
+
+	for _, item := range result.Feedback {
+		if strings.Contains(item, "password") {
+			return
+		}
+	}
+	t.Errorf("feedback %v does not name the matched word %q", result.Feedback, "password")
+}
@@ -0,0 +1,22 @@
+package pwgen
+
+import "unicode/utf8"
+
+// fewDistinctLength caps the length hasFewDistinctChars scales with, so
+// long passwords from a small charset, such as a 40-digit code using all
+// ten digits, are not penalized for it.
+const fewDistinctLength = 12
+
+// distinctChars returns the number of different characters in password,
+// e.g. 2 for "abababab".
+func distinctChars(password string) int {
+	return utf8.RuneCountInString(uniqueRunes(password))
+}
+
+// hasFewDistinctChars reports whether password uses fewer different
+// characters than a third of its length, counting at most
+// fewDistinctLength characters: "abababab" and "abcabcabcabcabc" do, a
+// random password practically never does.
+func hasFewDistinctChars(password string) bool {
+	return 3*distinctChars(password) < min(utf8.RuneCountInString(password), fewDistinctLength)
+}
@@ -0,0 +1,115 @@
+package pwgen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHasFewDistinctChars(t *testing.T) {
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"", false},
+		{"aa", false},
+		{"abababab", true},
+		{"aabbccdd", false},
+		{"abcabcabcabcabc", true},
+		{"Kx7!mQ#z8305", false},
+		{"4815162342108815", false}, // 8 digits over 16 characters
+		{"éàéàéàé", true},           // Counted in characters, not bytes
+	}
+
+	for _, tt := range tests {
+		if got := hasFewDistinctChars(tt.password); got != tt.want {
+			t.Errorf("hasFewDistinctChars(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyMinDistinctChars(t *testing.T) {
+	policy := PasswordPolicy{Name: "Distinct", MinDistinctChars: 6}
+
+	violations := ValidatePasswordAgainstPolicy("abababab", policy)
+	if len(violations) != 1 || violations[0].Rule != "MinDistinctChars" || !strings.Contains(violations[0].Description, "found 2") {
+		t.Errorf("ValidatePasswordAgainstPolicy(\"abababab\") = %v, want a MinDistinctChars violation", violations)
+	}
+	for _, password := range []string{"abcdef", "Kx7!mQ#z", "aabbccddeeff"} {
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) != 0 {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want no violations", password, violations)
+		}
+	}
+
+	var config Config
+	ApplyPolicyToConfig(policy, &config)
+	if config.MinDistinct != 6 {
+		t.Errorf("ApplyPolicyToConfig() MinDistinct = %d, want 6", config.MinDistinct)
+	}
+
+	BuiltinPolicies["test-distinct"] = policy
+	t.Cleanup(func() { delete(BuiltinPolicies, "test-distinct") })
+	merged, err := MergePolicies([]string{"test-distinct", "corporate"})
+	if err != nil || merged.MinDistinctChars != 6 {
+		t.Errorf("MergePolicies() MinDistinctChars = %d, %v, want 6", merged.MinDistinctChars, err)
+	}
+
+	problems := CheckPolicy(PasswordPolicy{Name: "Distinct", MaxLength: 8, MinDistinctChars: 9})
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "min_distinct_chars 9 exceeds max_length 8") {
+		t.Errorf("CheckPolicy() = %v, want the unreachable minimum reported", problems)
+	}
+	if problems := CheckPolicy(PasswordPolicy{Name: "Distinct", MinDistinctChars: -1}); len(problems) != 1 {
+		t.Errorf("CheckPolicy() = %v, want the negative minimum reported", problems)
+	}
+}
+
+func TestGenerateMinDistinct(t *testing.T) {
+	// Six digits drawn from ten rarely use six different ones
+	config := Config{Length: 6, IncludeDigits: true, MinDistinct: 6}
+	generator := NewSeededGenerator("min-distinct")
+	for i := 0; i < 50; i++ {
+		password, err := generator.Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if distinct := distinctChars(password); distinct < config.MinDistinct {
+			t.Fatalf("Generate() = %q, uses %d different characters, want at least %d", password, distinct, config.MinDistinct)
+		}
+	}
+}
+
+func TestValidateConfigMinDistinct(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"negative", Config{Length: 8, IncludeLower: true, MinDistinct: -1}, "must not be negative"},
+		{"longer than the password", Config{Length: 8, IncludeLower: true, MinDistinct: 9}, "8 characters cannot use 9 different characters"},
+		{"larger than the charset", Config{Length: 12, Charset: "abcd", MinDistinct: 5}, "exceeds the 4 characters available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzePasswordStrengthDistinct(t *testing.T) {
+	few := AnalyzePasswordStrength("xQxQxQxQxQxQ")
+	if !slices.Contains(few.Feedback, "Use more different characters") {
+		t.Errorf("feedback %v does not ask for more different characters", few.Feedback)
+	}
+	if penalties := ExplainEntropy("xQxQxQxQxQxQ", AnalysisOptions{}).Penalties; !slices.Contains(penalties, EntropyPenalty{"few distinct characters", DefaultPatternPenalties.Distinct}) {
+		t.Errorf("ExplainEntropy() penalties = %+v, want few distinct characters", penalties)
+	}
+
+	plain := AnalyzePasswordStrength("Kx7!mQ#z8305")
+	if slices.Contains(plain.Feedback, "Use more different characters") {
+		t.Errorf("feedback %v asks for more different characters for a varied password", plain.Feedback)
+	}
+}
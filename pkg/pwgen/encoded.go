@@ -0,0 +1,62 @@
+package pwgen
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// MaxRawBytes is the most random bytes GenerateHex and GenerateBase64 read.
+const MaxRawBytes = 1024
+
+// ValidateRawBytes checks the number of random bytes for GenerateHex and
+// GenerateBase64.
+func ValidateRawBytes(n int) error {
+	if n < 1 || n > MaxRawBytes {
+		return fmt.Errorf("number of random bytes must be between 1 and %d", MaxRawBytes)
+	}
+	return nil
+}
+
+// GenerateHex returns n random bytes encoded as lowercase hex, so twice as
+// many characters holding exactly n*8 bits of entropy.
+func GenerateHex(n int) (string, error) {
+	return defaultGenerator.GenerateHex(n)
+}
+
+// GenerateHex is like the package-level GenerateHex, drawing from g's source.
+func (g *Generator) GenerateHex(n int) (string, error) {
+	return generateEncoded(g.random, n, hex.EncodeToString)
+}
+
+// GenerateBase64 returns n random bytes encoded as padded standard base64,
+// or when urlSafe is set as unpadded base64url (RFC 4648 section 5), which
+// can go in URLs and file names as is.
+func GenerateBase64(n int, urlSafe bool) (string, error) {
+	return defaultGenerator.GenerateBase64(n, urlSafe)
+}
+
+// GenerateBase64 is like the package-level GenerateBase64, drawing from g's
+// source.
+func (g *Generator) GenerateBase64(n int, urlSafe bool) (string, error) {
+	encoding := base64.StdEncoding
+	if urlSafe {
+		encoding = base64.RawURLEncoding
+	}
+	return generateEncoded(g.random, n, encoding.EncodeToString)
+}
+
+func generateEncoded(random io.Reader, n int, encode func([]byte) string) (string, error) {
+	if err := ValidateRawBytes(n); err != nil {
+		return "", err
+	}
+
+	data := make([]byte, n)
+	defer Zero(data)
+	if _, err := io.ReadFull(random, data); err != nil {
+		return "", err
+	}
+
+	return encode(data), nil
+}
@@ -0,0 +1,70 @@
+package pwgen
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHex(t *testing.T) {
+	password, err := GenerateHex(16)
+	if err != nil {
+		t.Fatalf("GenerateHex() error = %v", err)
+	}
+
+	data, err := hex.DecodeString(password)
+	if err != nil || len(data) != 16 || password != strings.ToLower(password) {
+		t.Errorf("GenerateHex(16) = %q, want 16 bytes of lowercase hex", password)
+	}
+
+	for _, n := range []int{0, -1, MaxRawBytes + 1} {
+		if _, err := GenerateHex(n); err == nil {
+			t.Errorf("GenerateHex(%d) should return an error", n)
+		}
+	}
+}
+
+func TestGenerateBase64(t *testing.T) {
+	tests := []struct {
+		urlSafe  bool
+		encoding *base64.Encoding
+	}{
+		{false, base64.StdEncoding},
+		{true, base64.RawURLEncoding},
+	}
+
+	for _, tt := range tests {
+		// 31 bytes leave a partial group, padded only by the standard encoding
+		password, err := NewSeededGenerator("base64").GenerateBase64(31, tt.urlSafe)
+		if err != nil {
+			t.Fatalf("GenerateBase64(31, %v) error = %v", tt.urlSafe, err)
+		}
+
+		data, err := tt.encoding.DecodeString(password)
+		if err != nil || len(data) != 31 {
+			t.Errorf("GenerateBase64(31, %v) = %q, want 31 bytes in its encoding", tt.urlSafe, password)
+		}
+		if tt.urlSafe && strings.ContainsAny(password, "+/=") {
+			t.Errorf("GenerateBase64(31, true) = %q, want no +, / or padding", password)
+		}
+	}
+}
+
+func TestRawBytesEntropy(t *testing.T) {
+	password, err := GenerateBase64(32, true)
+	if err != nil {
+		t.Fatalf("GenerateBase64() error = %v", err)
+	}
+
+	// Exactly 8 bits per byte, not the estimate from the characters present
+	strength := AnalyzePasswordStrengthWithOptions(password, AnalysisOptions{RawBytes: 32})
+	if strength.Entropy != 256 {
+		t.Errorf("Entropy = %.1f, want 256 bits for 32 random bytes", strength.Entropy)
+	}
+
+	breakdown := ExplainEntropy("aaaaaaaa", AnalysisOptions{RawBytes: 4})
+	if breakdown.Method != "bytes" || breakdown.Entropy != 32 || len(breakdown.Penalties) > 0 {
+		t.Errorf("ExplainEntropy() = %+v, want 32 bits without penalties", breakdown)
+	}
+}
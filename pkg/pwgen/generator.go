@@ -0,0 +1,1135 @@
+// Package pwgen generates passwords, passphrases and pronounceable
+// passwords, and analyzes them for strength and policy compliance.
+package pwgen
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Config struct {
+	// Length is counted in characters (runes), not bytes, so multi-byte
+	// characters from Charset each count once.
+	Length           int
+	IncludeUpper     bool
+	IncludeLower     bool
+	IncludeDigits    bool
+	IncludeSymbols   bool
+	ExcludeAmbiguous bool
+	// IncludeSpace adds the space character, which counts as a symbol.
+	// It is not ambiguous, so ExcludeAmbiguous keeps it unless it is
+	// listed in AmbiguousChars.
+	IncludeSpace bool
+	// Charset, when set, replaces the character type toggles and is used
+	// verbatim (de-duplicated) as the generation alphabet. It may contain
+	// any Unicode characters; each code point is one character, so use
+	// precomposed forms such as "é" rather than "e" plus a combining accent.
+	Charset string
+	// ExcludeChars lists characters removed from the charset after it is
+	// assembled, e.g. quotes rejected by a web form.
+	ExcludeChars string
+	// ASCIIOnly removes every character outside printable ASCII, 0x21 to
+	// 0x7E, from the charset, e.g. to filter a custom Charset. This drops
+	// the space of IncludeSpace too.
+	ASCIIOnly bool
+	// AllowControl permits control characters and other non-printable
+	// characters in the charset, which ValidateConfig otherwise rejects.
+	AllowControl bool
+	// AmbiguousChars replaces the Ambiguous set removed by
+	// ExcludeAmbiguous, for fonts that confuse other glyphs (5S, 2Z, 8B).
+	AmbiguousChars string
+	// MaxRepeat, when positive, caps how many times in a row the same
+	// character may appear; 1 means no two adjacent characters are equal.
+	MaxRepeat int
+	// MinUpper, MinLower, MinDigits and MinSymbols guarantee at least that
+	// many characters of each class. Symbols are any characters other than
+	// letters and digits, as in policy validation.
+	MinUpper   int
+	MinLower   int
+	MinDigits  int
+	MinSymbols int
+	// MaxUpper, MaxLower, MaxDigits and MaxSymbols, when positive, cap how
+	// many characters of each class a password may contain.
+	MaxUpper   int
+	MaxLower   int
+	MaxDigits  int
+	MaxSymbols int
+	// SymbolSet names the entry of SymbolSets used for IncludeSymbols;
+	// empty means DefaultSymbolSet.
+	SymbolSet string
+	// AllowedSymbols, when set, removes every other symbol from the
+	// charset, e.g. for a site that only accepts some special characters.
+	// ApplyPolicyToConfig narrows it to the symbols the policy allows too,
+	// or uses the policy's own when they share none.
+	AllowedSymbols string
+	// AvoidCommon regenerates passwords containing a common password such
+	// as "admin", the patterns the strength analysis penalizes.
+	AvoidCommon bool
+	// ForbiddenPatterns regenerates passwords containing any of these
+	// strings, compared case-insensitively as in policy validation.
+	ForbiddenPatterns []string
+	// ForbidDictionaryWords, when positive, regenerates passwords containing
+	// an English word of at least this many letters.
+	ForbidDictionaryWords int
+	// ForbidDates regenerates passwords containing a year, a date or a
+	// month name, the patterns the strength analysis penalizes.
+	ForbidDates bool
+	// AlternateClasses avoids placing two characters of the same class
+	// next to each other, e.g. "aB3$cD" but never "ab". It costs entropy,
+	// since each character after the first has fewer choices. Generation
+	// falls back to unconstrained passwords when the charset has a single
+	// class or the class minimums cannot alternate.
+	AlternateClasses bool
+	// ClassWeights, when set, picks each character's class by weight
+	// before the character itself, instead of uniformly over the charset.
+	// It cannot be combined with AlternateClasses.
+	ClassWeights ClassWeights
+	// NoRepeat never uses a character twice, sampling without replacement
+	// from the charset, e.g. for short codes. Length must not exceed the
+	// number of characters available. It costs entropy, and cannot be
+	// combined with AlternateClasses or ClassWeights.
+	NoRepeat bool
+	// MaxAttempts bounds every loop that regenerates a password until it
+	// meets a constraint, such as MaxRepeat, AvoidCommon or a minimum
+	// entropy, so constraints that cannot be met fail with ErrMaxAttempts
+	// instead of spinning. Zero means DefaultMaxAttempts.
+	MaxAttempts int
+	// MaxClassRun, when positive, caps how many characters of the same
+	// class may appear in a row, e.g. 3 forbids "1234" but allows "123a4".
+	MaxClassRun int
+	// ForbidRepeatedSubstrings regenerates passwords containing a group of
+	// characters repeated back-to-back, such as "abcabc", the pattern the
+	// strength analysis penalizes.
+	ForbidRepeatedSubstrings bool
+	// MinEntropy regenerates passwords whose entropy, as
+	// ValidatePasswordAgainstPolicy measures it, is below this many bits,
+	// so a password generated for a policy meets its MinEntropy rule.
+	// Unlike GenerateWithMinEntropy, it credits each character class
+	// present in full, whatever the charset.
+	MinEntropy float64
+	// MinDistinct regenerates passwords using fewer than this many
+	// different characters, e.g. "abababab" for anything above 2.
+	MinDistinct int
+}
+
+type PassphraseConfig struct {
+	Words      int
+	Separator  string
+	Capitalize bool
+	// Wordlist is the words to draw from, see ParseWordlist. Nil means the
+	// built-in EFF large wordlist.
+	Wordlist []string
+	// Separators, when set, are the characters each separator between
+	// words is drawn from at random, e.g. Digits, adding entropy that
+	// Separator does not. Separator is then ignored.
+	Separators string
+}
+
+const (
+	LowerCase = "abcdefghijklmnopqrstuvwxyz"
+	UpperCase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits    = "0123456789"
+	Symbols   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+	Ambiguous = "0O1lI"
+
+	// DefaultSymbolSet is the SymbolSets entry used when Config.SymbolSet
+	// is empty.
+	DefaultSymbolSet = "common"
+
+	// Consonants and Vowels are the syllable sets used by pronounceable mode.
+	// Hard-to-pronounce consonants (q, x, y) are left out on purpose.
+	Consonants = "bcdfghjklmnprstvwz"
+	Vowels     = "aeiou"
+)
+
+// DefaultMaxAttempts bounds every regenerate-until-valid loop when
+// Config.MaxAttempts is zero.
+const DefaultMaxAttempts = 1000
+
+// ErrMaxAttempts is wrapped by the error of every generation that gives up
+// after Config.MaxAttempts candidates, so callers can tell constraints that
+// cannot be met from other failures.
+var ErrMaxAttempts = errors.New("could not satisfy constraints")
+
+// maxAttempts returns the bound on regenerate-until-valid loops for config.
+func maxAttempts(config Config) int {
+	if config.MaxAttempts > 0 {
+		return config.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// wordlistData is the EFF large wordlist used for passphrase generation.
+//
+//go:embed wordlist.txt
+var wordlistData string
+
+var wordlist = strings.Fields(wordlistData)
+
+// wordlistWords holds the wordlist for lookups when analyzing passphrases.
+var wordlistWords = func() map[string]bool {
+	words := make(map[string]bool, len(wordlist))
+	for _, word := range wordlist {
+		words[word] = true
+	}
+	return words
+}()
+
+// SymbolSets are the named sets of symbols selectable with Config.SymbolSet,
+// for sites that only accept some special characters.
+var SymbolSets = map[string]string{
+	"all":        "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", // Every ASCII punctuation character
+	"common":     Symbols,
+	"safe-url":   "-._~",      // Unreserved in URLs, never percent-encoded
+	"shell-safe": "%+,-./:@_", // No quotes, backticks, $, globs or other shell metacharacters
+}
+
+// ListSymbolSets returns the names of SymbolSets, sorted.
+func ListSymbolSets() []string {
+	var names []string
+	for name := range SymbolSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func symbolSet(name string) string {
+	if name == "" {
+		name = DefaultSymbolSet
+	}
+	return SymbolSets[name]
+}
+
+// HasExactCharset reports whether the strength of passwords generated from
+// config should be estimated from Charset(config) instead of inferred from
+// the character classes present: with a custom charset, or a symbol set
+// other than the default.
+func HasExactCharset(config Config) bool {
+	if config.Charset != "" {
+		return true
+	}
+	if config.IncludeSymbols && config.AllowedSymbols != "" {
+		return true
+	}
+	if config.IncludeSpace {
+		return true
+	}
+	return config.IncludeSymbols && config.SymbolSet != "" && config.SymbolSet != DefaultSymbolSet
+}
+
+func ValidateConfig(config Config) error {
+	if err := ValidateLength(config.Length); err != nil {
+		return err
+	}
+
+	if _, exists := SymbolSets[config.SymbolSet]; config.SymbolSet != "" && !exists {
+		return fmt.Errorf("unknown symbol set '%s' (available: %s)", config.SymbolSet, strings.Join(ListSymbolSets(), ", "))
+	}
+
+	if !utf8.ValidString(config.Charset) {
+		return fmt.Errorf("charset must be valid UTF-8")
+	}
+
+	if config.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative")
+	}
+
+	if config.Charset == "" && !config.IncludeUpper && !config.IncludeLower && !config.IncludeDigits && !config.IncludeSymbols && !config.IncludeSpace {
+		return fmt.Errorf("at least one character type must be enabled")
+	}
+
+	if buildCharset(config) == "" {
+		return fmt.Errorf("charset is empty after removing excluded characters")
+	}
+
+	if !config.AllowControl {
+		for _, char := range buildCharset(config) {
+			if !unicode.IsPrint(char) {
+				return fmt.Errorf("charset contains the non-printable character %U", char)
+			}
+		}
+	}
+
+	if !config.ClassWeights.IsZero() {
+		if config.AlternateClasses {
+			return fmt.Errorf("class weights cannot be combined with alternating classes")
+		}
+		for _, weight := range config.ClassWeights.list() {
+			if weight < 0 {
+				return fmt.Errorf("class weights must not be negative")
+			}
+		}
+	}
+
+	if config.NoRepeat {
+		if config.AlternateClasses || !config.ClassWeights.IsZero() {
+			return fmt.Errorf("no repeated characters cannot be combined with alternating classes or class weights")
+		}
+		if size := utf8.RuneCountInString(buildCharset(config)); config.Length > size {
+			return fmt.Errorf("a password of length %d without repeated characters needs at least %d different characters, but the charset has %d", config.Length, config.Length, size)
+		}
+	}
+
+	weights := config.ClassWeights.list()
+	required, capacity := 0, 0
+	for i, class := range classLimits(config) {
+		if class.min < 0 {
+			return fmt.Errorf("minimum number of %s must not be negative", class.name)
+		}
+		if class.max < 0 {
+			return fmt.Errorf("maximum number of %s must not be negative", class.name)
+		}
+		if class.min > 0 && class.chars == "" {
+			return fmt.Errorf("a minimum of %d %s requires %s in the charset", class.min, class.name, class.name)
+		}
+		if class.max > 0 && class.min > class.max {
+			return fmt.Errorf("minimum of %d %s is more than the maximum of %d", class.min, class.name, class.max)
+		}
+		size := utf8.RuneCountInString(class.chars)
+		if config.NoRepeat && class.min > size {
+			return fmt.Errorf("a minimum of %d %s without repeated characters needs %d different %s, but the charset has %d", class.min, class.name, class.min, class.name, size)
+		}
+		required += class.min
+
+		// A class weighted 0 only supplies its minimum, and without
+		// repeats a class supplies at most its size
+		switch {
+		case class.chars == "":
+		case !config.ClassWeights.IsZero() && weights[i] == 0:
+			capacity += class.min
+		case config.NoRepeat && class.max > 0:
+			capacity += min(class.max, size)
+		case config.NoRepeat:
+			capacity += size
+		case class.max > 0:
+			capacity += class.max
+		default:
+			capacity += config.Length
+		}
+	}
+
+	if required > config.Length {
+		return fmt.Errorf("minimum character counts add up to %d, more than the password length %d", required, config.Length)
+	}
+
+	if capacity < config.Length {
+		if !config.ClassWeights.IsZero() {
+			return fmt.Errorf("class weights and maximum character counts allow at most %d characters, fewer than the password length %d", capacity, config.Length)
+		}
+		return fmt.Errorf("maximum character counts allow at most %d characters, fewer than the password length %d", capacity, config.Length)
+	}
+
+	if config.MaxRepeat < 0 {
+		return fmt.Errorf("maximum repeat must not be negative")
+	}
+
+	if config.MaxRepeat > 0 && config.Length > config.MaxRepeat && len(uniqueRunes(buildCharset(config))) == 1 {
+		return fmt.Errorf("a single-character charset cannot avoid runs longer than %d", config.MaxRepeat)
+	}
+
+	if config.MaxClassRun < 0 {
+		return fmt.Errorf("maximum class run must not be negative")
+	}
+
+	if config.MaxClassRun > 0 && config.Length > config.MaxClassRun && singleClass(buildCharset(config)) {
+		return fmt.Errorf("a charset of a single class cannot avoid class runs longer than %d", config.MaxClassRun)
+	}
+
+	for _, pattern := range config.ForbiddenPatterns {
+		if pattern == "" {
+			return fmt.Errorf("forbidden patterns must not be empty")
+		}
+	}
+
+	if config.ForbidDictionaryWords < 0 {
+		return fmt.Errorf("minimum dictionary word length must not be negative")
+	}
+
+	if config.MinEntropy < 0 {
+		return fmt.Errorf("minimum entropy must not be negative")
+	}
+
+	if config.MinDistinct < 0 {
+		return fmt.Errorf("minimum distinct characters must not be negative")
+	}
+
+	if config.MinDistinct > config.Length {
+		return fmt.Errorf("a password of %d characters cannot use %d different characters", config.Length, config.MinDistinct)
+	}
+
+	if available := distinctChars(buildCharset(config)); config.MinDistinct > available {
+		return fmt.Errorf("minimum of %d distinct characters exceeds the %d characters available", config.MinDistinct, available)
+	}
+
+	// The estimate is highest for a password with every class of the
+	// charset and no penalized pattern
+	if best := float64(config.Length) * math.Log2(float64(classCharSpace(buildCharset(config)))); config.MinEntropy > 0 && best < config.MinEntropy {
+		return fmt.Errorf("minimum entropy of %.1f bits is unreachable: %d characters from this charset give at most %.1f bits", config.MinEntropy, config.Length, max(best, 0))
+	}
+
+	return nil
+}
+
+func ValidateLength(length int) error {
+	if length < 1 {
+		return fmt.Errorf("password length must be at least 1")
+	}
+
+	return nil
+}
+
+func ValidatePassphraseConfig(config PassphraseConfig) error {
+	if config.Words < 1 {
+		return fmt.Errorf("passphrase must contain at least 1 word")
+	}
+
+	if config.Wordlist != nil {
+		if err := validateWordlist(config.Wordlist); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Generate validates the configuration and returns a random password built
+// from the enabled character sets.
+func Generate(config Config) (string, error) {
+	return defaultGenerator.Generate(config)
+}
+
+// Generate is like the package-level Generate, drawing from g's source.
+func (g *Generator) Generate(config Config) (string, error) {
+	if err := ValidateConfig(config); err != nil {
+		return "", err
+	}
+
+	return generatePassword(config, g.random)
+}
+
+// GenerateBytes is like Generate but returns the password in a []byte that
+// the caller can wipe with Zero once it has been used. Unlike a string, the
+// buffer is not copied behind the caller's back, although any string made
+// from it is.
+func GenerateBytes(config Config) ([]byte, error) {
+	return defaultGenerator.GenerateBytes(config)
+}
+
+// GenerateBytes is like the package-level GenerateBytes, drawing from g's
+// source.
+func (g *Generator) GenerateBytes(config Config) ([]byte, error) {
+	if err := ValidateConfig(config); err != nil {
+		return nil, err
+	}
+
+	return generatePasswordBytes(config, g.random)
+}
+
+// Zero overwrites b with zero bytes, e.g. a password from GenerateBytes
+// that is no longer needed.
+func Zero(b []byte) {
+	clear(b)
+}
+
+// GenerateWithMinEntropy regenerates passwords until one's estimated entropy
+// reaches minEntropy bits. It fails up front when the length and charset
+// cannot reach the threshold, and with ErrMaxAttempts after
+// Config.MaxAttempts unlucky draws.
+func GenerateWithMinEntropy(config Config, minEntropy float64) (string, error) {
+	return defaultGenerator.GenerateWithMinEntropy(config, minEntropy)
+}
+
+// GenerateWithMinEntropy is like the package-level GenerateWithMinEntropy,
+// drawing from g's source.
+func (g *Generator) GenerateWithMinEntropy(config Config, minEntropy float64) (string, error) {
+	if err := ValidateConfig(config); err != nil {
+		return "", err
+	}
+
+	if best := maxEntropy(config); best < minEntropy {
+		return "", fmt.Errorf("minimum entropy of %.1f bits is unreachable: %d characters from this charset give at most %.1f bits", minEntropy, config.Length, best)
+	}
+
+	opts := AnalysisOptions{AlternateClasses: config.AlternateClasses && CanAlternateClasses(config), ClassWeights: config.ClassWeights, NoRepeat: config.NoRepeat}
+	if HasExactCharset(config) {
+		opts.Charset = buildCharset(config)
+	}
+
+	for attempt := 0; attempt < maxAttempts(config); attempt++ {
+		password, err := generatePassword(config, g.random)
+		if err != nil {
+			return "", err
+		}
+
+		if calculateEntropyWithOptions(password, opts) >= minEntropy {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w after %d attempts: min-entropy %.1f not reached with length %d", ErrMaxAttempts, maxAttempts(config), minEntropy, config.Length)
+}
+
+// maxEntropy is the highest entropy the strength estimate can assign to a
+// password generated from config, i.e. one without any penalized patterns.
+func maxEntropy(config Config) float64 {
+	multiplier := 1.0
+	if config.AlternateClasses && CanAlternateClasses(config) {
+		multiplier = alternationMultiplier(classSizes(buildCharset(config)), config.Length)
+	}
+	multiplier *= weightMultiplier(classSizes(buildCharset(config)), config.ClassWeights)
+	if config.NoRepeat {
+		multiplier *= noRepeatMultiplier(utf8.RuneCountInString(buildCharset(config)), config.Length)
+	}
+
+	if HasExactCharset(config) {
+		return multiplier * float64(config.Length) * math.Log2(float64(utf8.RuneCountInString(buildCharset(config))))
+	}
+
+	// The estimate credits a whole character class as soon as one of its
+	// characters appears, so mirror its class sizes
+	charSpace := 0
+	if config.IncludeLower {
+		charSpace += 26
+	}
+	if config.IncludeUpper {
+		charSpace += 26
+	}
+	if config.IncludeDigits {
+		charSpace += 10
+	}
+	if config.IncludeSymbols {
+		charSpace += 32
+	}
+
+	return multiplier * float64(config.Length) * math.Log2(float64(charSpace))
+}
+
+func generatePassword(config Config, random io.Reader) (string, error) {
+	password, err := generatePasswordBytes(config, random)
+	if err != nil {
+		return "", err
+	}
+	defer Zero(password)
+
+	return string(password), nil
+}
+
+// generatePasswordBytes builds the password in a []rune working buffer,
+// so multi-byte characters are picked whole, and returns it UTF-8 encoded.
+// Every discarded candidate and the working buffer are zeroed, so callers
+// can Zero the result once they are done with it.
+func generatePasswordBytes(config Config, random io.Reader) ([]byte, error) {
+	password, err := generateWithEntropy(config, random)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(password)
+
+	size := 0
+	for _, char := range password {
+		size += utf8.RuneLen(char)
+	}
+
+	encoded := make([]byte, 0, size)
+	for _, char := range password {
+		encoded = utf8.AppendRune(encoded, char)
+	}
+	return encoded, nil
+}
+
+// generateWithEntropy generates candidates until one reaches
+// config.MinEntropy bits, as policy validation measures them.
+func generateWithEntropy(config Config, random io.Reader) ([]rune, error) {
+	if config.MinEntropy <= 0 {
+		return generateAllowed(config, random)
+	}
+
+	for attempt := 0; attempt < maxAttempts(config); attempt++ {
+		password, err := generateAllowed(config, random)
+		if err != nil {
+			return nil, err
+		}
+
+		if calculateEntropy(string(password)) >= config.MinEntropy {
+			return password, nil
+		}
+		clear(password)
+	}
+
+	return nil, fmt.Errorf("%w after %d attempts: entropy of %.1f bits not reached with length %d", ErrMaxAttempts, maxAttempts(config), config.MinEntropy, config.Length)
+}
+
+// generateAllowed generates candidates until one avoids the common words
+// and forbidden patterns config asks to avoid and uses config.MinDistinct
+// different characters.
+func generateAllowed(config Config, random io.Reader) ([]rune, error) {
+	if !config.AvoidCommon && len(config.ForbiddenPatterns) == 0 && config.ForbidDictionaryWords == 0 && !config.ForbidDates && !config.ForbidRepeatedSubstrings && config.MinDistinct == 0 {
+		return generateLimited(config, random)
+	}
+
+	for attempt := 0; attempt < maxAttempts(config); attempt++ {
+		password, err := generateLimited(config, random)
+		if err != nil {
+			return nil, err
+		}
+
+		if !containsAvoidedPattern(config, string(password)) && distinctChars(string(password)) >= config.MinDistinct {
+			return password, nil
+		}
+		clear(password)
+	}
+
+	return nil, fmt.Errorf("%w after %d attempts: every password contained a common word, forbidden pattern, dictionary word, date or repeated substring, or too few distinct characters", ErrMaxAttempts, maxAttempts(config))
+}
+
+// containsAvoidedPattern reports whether password contains a common
+// password when config.AvoidCommon is set, one of the
+// config.ForbiddenPatterns, an English word config.ForbidDictionaryWords
+// letters long or more, a date when config.ForbidDates is set, or a
+// repeated substring when config.ForbidRepeatedSubstrings is set.
+func containsAvoidedPattern(config Config, password string) bool {
+	if config.AvoidCommon && hasCommonPatterns(password) {
+		return true
+	}
+
+	if config.ForbidDates && hasDatePattern(password) {
+		return true
+	}
+
+	if config.ForbidRepeatedSubstrings && hasRepeatedSubstrings(password) {
+		return true
+	}
+
+	if config.ForbidDictionaryWords > 0 && len(findEnglishWords(password, config.ForbidDictionaryWords)) > 0 {
+		return true
+	}
+
+	lower := strings.ToLower(password)
+	for _, pattern := range config.ForbiddenPatterns {
+		if containsForbiddenPattern(lower, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateLimited generates a candidate that meets the repeat limit and the
+// character class limits of config.
+func generateLimited(config Config, random io.Reader) ([]rune, error) {
+	// Neighbours of different classes never repeat, so alternating
+	// passwords meet any repeat limit as they are
+	if config.AlternateClasses && CanAlternateClasses(config) {
+		password, err := generateAlternating(config, random)
+		if err != nil || password != nil {
+			return password, err
+		}
+	}
+
+	if config.MaxRepeat <= 0 && config.MaxClassRun <= 0 {
+		return generateCandidate(config, random)
+	}
+
+	// Re-picking characters to break up runs can take away a required one,
+	// exceed a maximum or start a new run, so start over whenever the
+	// limits no longer hold
+	for attempt := 0; attempt < maxAttempts(config); attempt++ {
+		password, err := generateCandidate(config, random)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.MaxRepeat > 0 {
+			if err := limitRepeats(random, password, buildCharset(config), config.MaxRepeat); err != nil {
+				clear(password)
+				return nil, err
+			}
+		}
+		if config.MaxClassRun > 0 {
+			if err := limitClassRuns(random, password, config, config.MaxClassRun); err != nil {
+				clear(password)
+				return nil, err
+			}
+		}
+
+		if meetsLimits(config, password) && meetsRunLimits(config, password) {
+			return password, nil
+		}
+		clear(password)
+	}
+
+	if config.MaxClassRun > 0 {
+		return nil, fmt.Errorf("%w after %d attempts: max-class-run %d not met within the class limits", ErrMaxAttempts, maxAttempts(config), config.MaxClassRun)
+	}
+	return nil, fmt.Errorf("%w after %d attempts: max-repeat %d not met within the class limits", ErrMaxAttempts, maxAttempts(config), config.MaxRepeat)
+}
+
+// meetsRunLimits reports whether password respects MaxRepeat and
+// MaxClassRun, which breaking up one kind of run can undo for the other.
+// Like meetsLimits it works on the rune buffer directly.
+func meetsRunLimits(config Config, password []rune) bool {
+	repeat, classRun := 1, 1
+	for i := 1; i < len(password); i++ {
+		if password[i] == password[i-1] {
+			repeat++
+		} else {
+			repeat = 1
+		}
+		if runeClass(password[i]) == runeClass(password[i-1]) {
+			classRun++
+		} else {
+			classRun = 1
+		}
+
+		if (config.MaxRepeat > 0 && repeat > config.MaxRepeat) || (config.MaxClassRun > 0 && classRun > config.MaxClassRun) {
+			return false
+		}
+	}
+	return true
+}
+
+func generateCandidate(config Config, random io.Reader) ([]rune, error) {
+	charset := []rune(buildCharset(config))
+
+	if len(charset) == 0 {
+		return nil, fmt.Errorf("no valid characters available for password generation")
+	}
+
+	// Place the required characters of each class first. The buffer is
+	// allocated at full length up front so appends never copy it.
+	password := make([]rune, 0, config.Length)
+	for _, class := range classLimits(config) {
+		if class.min <= 0 {
+			continue
+		}
+
+		chars := []rune(class.chars)
+		var indices []int
+		var err error
+		if config.NoRepeat {
+			indices, err = distinctIndices(random, class.min, len(chars))
+		} else {
+			indices, err = randomIndices(random, class.min, len(chars))
+		}
+		if err != nil {
+			clear(password)
+			return nil, err
+		}
+		for _, index := range indices {
+			password = append(password, chars[index])
+		}
+	}
+
+	if len(password) > config.Length {
+		clear(password)
+		return nil, fmt.Errorf("minimum character counts add up to %d, more than the password length %d", len(password), config.Length)
+	}
+
+	required := len(password)
+
+	var err error
+	switch {
+	case config.NoRepeat:
+		password, err = fillUnique(random, password, config)
+	case !config.ClassWeights.IsZero():
+		password, err = fillWeighted(random, password, config)
+	case hasMaximums(config):
+		password, err = fillCapped(random, password, config)
+	default:
+		password, err = fillFrom(random, password, config.Length, charset)
+	}
+	if err != nil {
+		clear(password)
+		return nil, err
+	}
+
+	// Shuffle so the required characters do not always lead
+	if required > 0 {
+		if err := shuffle(random, password); err != nil {
+			clear(password)
+			return nil, err
+		}
+	}
+
+	return password, nil
+}
+
+// fillFrom appends characters drawn uniformly from charset to password
+// until it is length characters long.
+func fillFrom(random io.Reader, password []rune, length int, charset []rune) ([]rune, error) {
+	indices, err := randomIndices(random, length-len(password), len(charset))
+	if err != nil {
+		return password, err
+	}
+	for _, index := range indices {
+		password = append(password, charset[index])
+	}
+	return password, nil
+}
+
+// fillCapped is like fillFrom but draws one character at a time from the
+// classes still below their maximum. password must hold exactly the
+// minimum count of each class.
+func fillCapped(random io.Reader, password []rune, config Config) ([]rune, error) {
+	classes := classLimits(config)
+	counts := make([]int, len(classes))
+	for i, class := range classes {
+		counts[i] = class.min
+	}
+
+	for len(password) < config.Length {
+		var allowed []rune
+		var owners []int
+		for i, class := range classes {
+			if class.max > 0 && counts[i] >= class.max {
+				continue
+			}
+			for _, char := range class.chars {
+				allowed = append(allowed, char)
+				owners = append(owners, i)
+			}
+		}
+
+		if len(allowed) == 0 {
+			return password, fmt.Errorf("maximum character counts leave no characters for a password of length %d", config.Length)
+		}
+
+		indices, err := randomIndices(random, 1, len(allowed))
+		if err != nil {
+			return password, err
+		}
+		password = append(password, allowed[indices[0]])
+		counts[owners[indices[0]]]++
+	}
+
+	return password, nil
+}
+
+func hasMaximums(config Config) bool {
+	return config.MaxUpper > 0 || config.MaxLower > 0 || config.MaxDigits > 0 || config.MaxSymbols > 0
+}
+
+// limitRepeats re-picks, in place, every character that would extend a run
+// past maxRepeat, choosing uniformly among the other characters of the
+// charset.
+func limitRepeats(random io.Reader, password []rune, charset string, maxRepeat int) error {
+	run := 1
+	for i := 1; i < len(password); i++ {
+		if password[i] != password[i-1] {
+			run = 1
+			continue
+		}
+
+		run++
+		if run <= maxRepeat {
+			continue
+		}
+
+		others := []rune(strings.ReplaceAll(charset, string(password[i-1]), ""))
+		if len(others) == 0 {
+			return fmt.Errorf("a single-character charset cannot avoid runs longer than %d", maxRepeat)
+		}
+
+		indices, err := randomIndices(random, 1, len(others))
+		if err != nil {
+			return err
+		}
+		password[i] = others[indices[0]]
+		run = 1
+	}
+
+	return nil
+}
+
+// longestRun returns the length of the longest run of one repeated character.
+func longestRun(password string) int {
+	longest, run := 0, 0
+	var previous rune
+	for i, char := range password {
+		if i > 0 && char == previous {
+			run++
+		} else {
+			run = 1
+		}
+		previous = char
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// meetsLimits reports whether password has the minimum and at most the
+// maximum count of each class. It works on the rune buffer directly so
+// checking a candidate does not leave a string copy of it behind.
+func meetsLimits(config Config, password []rune) bool {
+	for _, class := range classLimits(config) {
+		count := 0
+		for _, char := range password {
+			if strings.ContainsRune(class.chars, char) {
+				count++
+			}
+		}
+		if count < class.min || (class.max > 0 && count > class.max) {
+			return false
+		}
+	}
+	return true
+}
+
+type classLimit struct {
+	name  string
+	chars string
+	min   int
+	max   int // 0 means no maximum
+}
+
+// classLimits pairs each character class's minimum and maximum counts with
+// the characters of that class available in the charset.
+func classLimits(config Config) []classLimit {
+	charset := buildCharset(config)
+
+	return []classLimit{
+		{name: "uppercase letters", chars: filterChars(charset, isUpper), min: config.MinUpper, max: config.MaxUpper},
+		{name: "lowercase letters", chars: filterChars(charset, isLower), min: config.MinLower, max: config.MaxLower},
+		{name: "digits", chars: filterChars(charset, isDigit), min: config.MinDigits, max: config.MaxDigits},
+		{name: "symbols", chars: filterChars(charset, isSymbol), min: config.MinSymbols, max: config.MaxSymbols},
+	}
+}
+
+func isUpper(char rune) bool  { return char >= 'A' && char <= 'Z' }
+func isLower(char rune) bool  { return char >= 'a' && char <= 'z' }
+func isDigit(char rune) bool  { return char >= '0' && char <= '9' }
+func isSymbol(char rune) bool { return !isUpper(char) && !isLower(char) && !isDigit(char) }
+
+func filterChars(charset string, keep func(rune) bool) string {
+	var result strings.Builder
+	for _, char := range charset {
+		if keep(char) {
+			result.WriteRune(char)
+		}
+	}
+	return result.String()
+}
+
+// GeneratePassphrase joins randomly chosen words from config.Wordlist, or
+// the EFF large wordlist, with config.Separator or, when
+// config.Separators is set, a separator drawn from it for every gap.
+func GeneratePassphrase(config PassphraseConfig) (string, error) {
+	return defaultGenerator.GeneratePassphrase(config)
+}
+
+// GeneratePassphrase is like the package-level GeneratePassphrase, drawing
+// from g's source.
+func (g *Generator) GeneratePassphrase(config PassphraseConfig) (string, error) {
+	if err := ValidatePassphraseConfig(config); err != nil {
+		return "", err
+	}
+
+	list := passphraseWords(config)
+	words := make([]string, config.Words)
+
+	indices, err := randomIndices(g.random, config.Words, len(list))
+	if err != nil {
+		return "", err
+	}
+
+	for i, index := range indices {
+		word := list[index]
+		if config.Capitalize {
+			word = capitalize(word)
+		}
+		words[i] = word
+	}
+
+	if config.Separators == "" {
+		return strings.Join(words, config.Separator), nil
+	}
+
+	separators := []rune(uniqueRunes(config.Separators))
+	indices, err = randomIndices(g.random, config.Words-1, len(separators))
+	if err != nil {
+		return "", err
+	}
+
+	var passphrase strings.Builder
+	for i, word := range words {
+		if i > 0 {
+			passphrase.WriteRune(separators[indices[i-1]])
+		}
+		passphrase.WriteString(word)
+	}
+	return passphrase.String(), nil
+}
+
+// GeneratePIN returns a numeric PIN of the given length. When forbidTrivial
+// is set, PINs with repeated digits (111) or runs (123, 987) are rejected
+// and regenerated.
+func GeneratePIN(length int, forbidTrivial bool) (string, error) {
+	return defaultGenerator.GeneratePIN(length, forbidTrivial)
+}
+
+// GeneratePIN is like the package-level GeneratePIN, drawing from g's source.
+func (g *Generator) GeneratePIN(length int, forbidTrivial bool) (string, error) {
+	if err := ValidateLength(length); err != nil {
+		return "", err
+	}
+
+	config := Config{Length: length, IncludeDigits: true}
+
+	for attempt := 0; attempt < maxAttempts(config); attempt++ {
+		pin, err := generatePassword(config, g.random)
+		if err != nil {
+			return "", err
+		}
+
+		if !forbidTrivial || !isTrivialPIN(pin) {
+			return pin, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w after %d attempts: every PIN of length %d was trivial", ErrMaxAttempts, maxAttempts(config), length)
+}
+
+func isTrivialPIN(pin string) bool {
+	return hasRepeatedChars(pin) || hasSequentialChars(pin)
+}
+
+// GeneratePronounceable builds a password of the given length by alternating
+// consonants and vowels, starting with a consonant (e.g. "fotibaku").
+func GeneratePronounceable(length int) (string, error) {
+	return defaultGenerator.GeneratePronounceable(length)
+}
+
+// GeneratePronounceable is like the package-level GeneratePronounceable,
+// drawing from g's source.
+func (g *Generator) GeneratePronounceable(length int) (string, error) {
+	if length < 1 {
+		return "", fmt.Errorf("password length must be at least 1")
+	}
+
+	password := make([]byte, length)
+
+	for i := 0; i < length; i++ {
+		set := Consonants
+		if i%2 == 1 {
+			set = Vowels
+		}
+
+		index, err := randomInt(g.random, len(set))
+		if err != nil {
+			return "", err
+		}
+		password[i] = set[index]
+	}
+
+	return string(password), nil
+}
+
+// Charset returns the resolved set of characters passwords are drawn from
+// for the given configuration.
+func Charset(config Config) string {
+	return buildCharset(config)
+}
+
+func buildCharset(config Config) string {
+	if config.Charset != "" {
+		return applyExclusions(config, uniqueRunes(config.Charset))
+	}
+
+	var charset strings.Builder
+
+	if config.IncludeLower {
+		charset.WriteString(LowerCase)
+	}
+
+	if config.IncludeUpper {
+		charset.WriteString(UpperCase)
+	}
+
+	if config.IncludeDigits {
+		charset.WriteString(Digits)
+	}
+
+	if config.IncludeSymbols {
+		charset.WriteString(symbolSet(config.SymbolSet))
+	}
+
+	if config.IncludeSpace {
+		charset.WriteString(" ")
+	}
+
+	return applyExclusions(config, charset.String())
+}
+
+func applyExclusions(config Config, charset string) string {
+	if config.ExcludeAmbiguous {
+		for _, char := range ambiguousSet(config.AmbiguousChars) {
+			charset = strings.ReplaceAll(charset, string(char), "")
+		}
+	}
+
+	for _, char := range config.ExcludeChars {
+		charset = strings.ReplaceAll(charset, string(char), "")
+	}
+
+	if config.ASCIIOnly {
+		charset = strings.Map(func(char rune) rune {
+			if char < 0x21 || char > 0x7E {
+				return -1
+			}
+			return char
+		}, charset)
+	}
+
+	if config.AllowedSymbols != "" {
+		charset = strings.Map(func(char rune) rune {
+			if isSymbol(char) && !strings.ContainsRune(config.AllowedSymbols, char) {
+				return -1
+			}
+			return char
+		}, charset)
+	}
+
+	return charset
+}
+
+// intersectRunes returns the characters of a that are also in b, in the
+// order of a and without duplicates.
+func intersectRunes(a, b string) string {
+	return uniqueRunes(strings.Map(func(char rune) rune {
+		if !strings.ContainsRune(b, char) {
+			return -1
+		}
+		return char
+	}, a))
+}
+
+// ambiguousSet returns chars, or the default Ambiguous set when it is empty.
+func ambiguousSet(chars string) string {
+	if chars == "" {
+		return Ambiguous
+	}
+	return chars
+}
+
+// uniqueRunes removes duplicate characters while keeping first occurrences
+// in their original order.
+func uniqueRunes(s string) string {
+	seen := make(map[rune]bool)
+	var result strings.Builder
+
+	for _, char := range s {
+		if !seen[char] {
+			seen[char] = true
+			result.WriteRune(char)
+		}
+	}
+
+	return result.String()
+}
@@ -0,0 +1,161 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HybridConfig configures GenerateHybrid: capitalized words from the
+// passphrase wordlist with digits and symbols inserted at random
+// positions, such as "Falcon7$Grove2Mint".
+type HybridConfig struct {
+	Words   int // Capitalized words, at least 1
+	Digits  int // Digits inserted between or inside the words
+	Symbols int // Symbols inserted between or inside the words
+}
+
+// DefaultHybridConfig meets the corporate policy with every password: three
+// words of at least three letters and three insertions make the 12
+// characters it requires, with two uppercase letters and two digits.
+var DefaultHybridConfig = HybridConfig{Words: 3, Digits: 2, Symbols: 1}
+
+// hybridDigits and hybridSymbols are the characters GenerateHybrid inserts,
+// leaving out the Ambiguous ones.
+const (
+	hybridDigits  = "23456789"
+	hybridSymbols = Symbols
+)
+
+// hybridWordlist is the EFF large wordlist without the words that contain
+// an Ambiguous character once capitalized, such as "lemon" or "igloo", or
+// a common pattern, such as "dragon", so hybrid passwords pass policies
+// that exclude either. Hyphenated words are left out too, as their hyphen
+// would pass for an inserted symbol.
+var hybridWordlist = func() []string {
+	var words []string
+	for _, word := range wordlist {
+		lettersOnly := strings.IndexFunc(word, func(r rune) bool { return !isLower(r) }) < 0
+		if lettersOnly && !strings.ContainsAny(capitalize(word), Ambiguous) && !hasCommonPatterns(word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}()
+
+// ValidateHybridConfig reports an error for a config GenerateHybrid cannot
+// use.
+func ValidateHybridConfig(config HybridConfig) error {
+	if config.Words < 1 {
+		return fmt.Errorf("hybrid password must contain at least 1 word")
+	}
+	if config.Digits < 0 || config.Symbols < 0 {
+		return fmt.Errorf("hybrid password digit and symbol counts must not be negative")
+	}
+	return nil
+}
+
+// GenerateHybrid returns a password of capitalized words with digits and
+// symbols inserted at random positions, see HybridConfig.
+func GenerateHybrid(config HybridConfig) (string, error) {
+	return defaultGenerator.GenerateHybrid(config)
+}
+
+// GenerateHybrid is like the package-level GenerateHybrid, drawing from
+// g's source. A candidate in which the words and insertions happen to form
+// a common pattern, such as "Nomad" followed by "Mint", is regenerated.
+func (g *Generator) GenerateHybrid(config HybridConfig) (string, error) {
+	if err := ValidateHybridConfig(config); err != nil {
+		return "", err
+	}
+
+	for attempt := 0; attempt < DefaultMaxAttempts; attempt++ {
+		password, err := g.hybridCandidate(config)
+		if err != nil {
+			return "", err
+		}
+		if !hasCommonPatterns(password) {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w after %d attempts: every hybrid password contained a common pattern", ErrMaxAttempts, DefaultMaxAttempts)
+}
+
+// hybridCandidate joins config.Words capitalized words, then inserts each
+// digit and symbol at a uniformly random position of the password so far,
+// which places them uniformly among all arrangements.
+func (g *Generator) hybridCandidate(config HybridConfig) (string, error) {
+	indices, err := randomIndices(g.random, config.Words, len(hybridWordlist))
+	if err != nil {
+		return "", err
+	}
+
+	var password []rune
+	for _, index := range indices {
+		password = append(password, []rune(capitalize(hybridWordlist[index]))...)
+	}
+
+	inserts := []struct {
+		chars string
+		count int
+	}{
+		{hybridDigits, config.Digits},
+		{hybridSymbols, config.Symbols},
+	}
+	for _, insert := range inserts {
+		for range insert.count {
+			char, err := randomInt(g.random, len(insert.chars))
+			if err != nil {
+				return "", err
+			}
+			position, err := randomInt(g.random, len(password)+1)
+			if err != nil {
+				return "", err
+			}
+			password = append(password[:position], append([]rune{rune(insert.chars[char])}, password[position:]...)...)
+		}
+	}
+
+	return string(password), nil
+}
+
+// capitalize returns word with its first letter in upper case.
+func capitalize(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(first)) + word[size:]
+}
+
+// HybridEntropy returns the entropy in bits of a password generated by
+// GenerateHybrid with config: log2 of the wordlist size for every word,
+// log2 of the number of ways to arrange the digits and symbols among the
+// letters of password, and log2 of the digit and symbol sets for each of
+// them. The arrangements depend on the length of the words drawn, so the
+// result is for password in particular.
+func HybridEntropy(password string, config HybridConfig) float64 {
+	words := float64(config.Words) * math.Log2(float64(len(hybridWordlist)))
+	return words + hybridInsertionEntropy(utf8.RuneCountInString(password), config)
+}
+
+// hybridInsertionEntropy is the part of HybridEntropy due to the digits and
+// symbols in a password of length characters: log2 of the multinomial
+// length!/(letters!·digits!·symbols!) plus the choice of each character.
+func hybridInsertionEntropy(length int, config HybridConfig) float64 {
+	letters := length - config.Digits - config.Symbols
+	if letters < 0 {
+		return 0
+	}
+
+	arrangements := logFactorial(length) - logFactorial(letters) - logFactorial(config.Digits) - logFactorial(config.Symbols)
+	return arrangements/math.Ln2 +
+		float64(config.Digits)*math.Log2(float64(len(hybridDigits))) +
+		float64(config.Symbols)*math.Log2(float64(len(hybridSymbols)))
+}
+
+// logFactorial returns the natural logarithm of n!.
+func logFactorial(n int) float64 {
+	value, _ := math.Lgamma(float64(n + 1))
+	return value
+}
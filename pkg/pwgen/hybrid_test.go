@@ -0,0 +1,118 @@
+package pwgen
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestGenerateHybridMeetsCorporatePolicy(t *testing.T) {
+	policy, err := GetPolicy("corporate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator := NewSeededGenerator("hybrid")
+	for i := 0; i < 2000; i++ {
+		password, err := generator.GenerateHybrid(DefaultHybridConfig)
+		if err != nil {
+			t.Fatalf("GenerateHybrid() error = %v", err)
+		}
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+			t.Fatalf("GenerateHybrid() = %q, violates the corporate policy: %v", password, violations)
+		}
+	}
+}
+
+func TestGenerateHybridShape(t *testing.T) {
+	config := HybridConfig{Words: 2, Digits: 3, Symbols: 2}
+	password, err := NewSeededGenerator("shape").GenerateHybrid(config)
+	if err != nil {
+		t.Fatalf("GenerateHybrid() error = %v", err)
+	}
+
+	var upper, digits, symbols int
+	var letters strings.Builder
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			upper++
+			letters.WriteRune(char)
+		case unicode.IsLower(char):
+			letters.WriteRune(char)
+		case strings.ContainsRune(hybridDigits, char):
+			digits++
+		case strings.ContainsRune(hybridSymbols, char):
+			symbols++
+		default:
+			t.Fatalf("GenerateHybrid() = %q, has unexpected character %q", password, char)
+		}
+	}
+	if upper != config.Words || digits != config.Digits || symbols != config.Symbols {
+		t.Errorf("GenerateHybrid() = %q, want %d words, %d digits and %d symbols", password, config.Words, config.Digits, config.Symbols)
+	}
+
+	// Without the insertions, the password is its capitalized words
+	var words []string
+	for _, char := range letters.String() {
+		if unicode.IsUpper(char) {
+			words = append(words, "")
+		}
+		words[len(words)-1] += string(unicode.ToLower(char))
+	}
+	for _, word := range words {
+		if !slices.Contains(hybridWordlist, word) {
+			t.Errorf("GenerateHybrid() = %q, %q is not in the hybrid wordlist", password, word)
+		}
+	}
+}
+
+func TestHybridWordlist(t *testing.T) {
+	if len(hybridWordlist) < 4000 {
+		t.Errorf("hybridWordlist has %d words, want most of the EFF wordlist", len(hybridWordlist))
+	}
+	for _, word := range hybridWordlist {
+		if strings.ContainsAny(capitalize(word), Ambiguous) || hasCommonPatterns(word) || strings.Contains(word, "-") {
+			t.Errorf("hybridWordlist contains %q", word)
+		}
+	}
+}
+
+func TestValidateHybridConfig(t *testing.T) {
+	tests := []struct {
+		config  HybridConfig
+		wantErr bool
+	}{
+		{DefaultHybridConfig, false},
+		{HybridConfig{Words: 1}, false},
+		{HybridConfig{Words: 0, Digits: 2}, true},
+		{HybridConfig{Words: 2, Symbols: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateHybridConfig(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateHybridConfig(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHybridEntropy(t *testing.T) {
+	config := HybridConfig{Words: 2, Digits: 2, Symbols: 1}
+
+	// 11 letters, 2 digits and a symbol can be arranged 14!/(11!·2!·1!) =
+	// 1092 ways
+	password := "Abc4defGhij5k!"
+	want := 2*math.Log2(float64(len(hybridWordlist))) + math.Log2(1092) + 2*math.Log2(8) + math.Log2(float64(len(Symbols)))
+	if got := HybridEntropy(password, config); math.Abs(got-want) > 1e-9 {
+		t.Errorf("HybridEntropy() = %v, want %v", got, want)
+	}
+
+	breakdown := ExplainEntropy(password, AnalysisOptions{Hybrid: &config})
+	if breakdown.Method != "hybrid" || breakdown.Words != 2 || breakdown.CharSpace != len(hybridWordlist) || math.Abs(breakdown.Entropy-want) > 1e-9 {
+		t.Errorf("ExplainEntropy() = %+v, want the hybrid method at %.1f bits", breakdown, want)
+	}
+	if words := 2 * math.Log2(float64(len(hybridWordlist))); math.Abs(breakdown.InsertionEntropy-(want-words)) > 1e-9 {
+		t.Errorf("ExplainEntropy() InsertionEntropy = %v, want %v", breakdown.InsertionEntropy, want-words)
+	}
+}
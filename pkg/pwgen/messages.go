@@ -0,0 +1,113 @@
+package pwgen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FeedbackMessage is a piece of strength feedback identified by a stable ID,
+// so callers can show it in any language with Localize.
+type FeedbackMessage struct {
+	ID   string
+	Args []string // Values substituted into the message, e.g. matched words
+}
+
+// Feedback message IDs used in PasswordStrength.Messages.
+const (
+	MsgUseMinLength      = "use-min-length"
+	MsgConsiderLonger    = "consider-longer"
+	MsgAddLower          = "add-lower"
+	MsgAddUpper          = "add-upper"
+	MsgAddDigits         = "add-digits"
+	MsgAddSymbols        = "add-symbols"
+	MsgAvoidRepeated     = "avoid-repeated"
+	MsgAvoidSequential   = "avoid-sequential"
+	MsgAvoidDictionary   = "avoid-dictionary"
+	MsgAvoidDates        = "avoid-dates"
+	MsgAvoidRepetition   = "avoid-repetition"
+	MsgUseMoreDistinct   = "use-more-distinct"
+	MsgTooPredictable    = "too-predictable"
+	MsgExcellentPassword = "excellent-password"
+)
+
+// DefaultLanguage is used for languages or messages missing from the catalog.
+const DefaultLanguage = "en"
+
+// messageCatalog maps a language code to the fmt format of each message.
+var messageCatalog = map[string]map[string]string{
+	"en": {
+		MsgUseMinLength:      "Use at least 8 characters",
+		MsgConsiderLonger:    "Consider using 12+ characters for better security",
+		MsgAddLower:          "Add lowercase letters",
+		MsgAddUpper:          "Add uppercase letters",
+		MsgAddDigits:         "Add numbers",
+		MsgAddSymbols:        "Add symbols (!@#$%%^&*)",
+		MsgAvoidRepeated:     "Avoid repeated characters",
+		MsgAvoidSequential:   "Avoid sequential characters (abc, 123)",
+		MsgAvoidDictionary:   "Avoid dictionary words (%s)",
+		MsgAvoidDates:        "Avoid years and dates",
+		MsgAvoidRepetition:   "Avoid repeating a group of characters (abcabc)",
+		MsgUseMoreDistinct:   "Use more different characters",
+		MsgTooPredictable:    "Password is too predictable",
+		MsgExcellentPassword: "Excellent password strength!",
+	},
+	"fr": {
+		MsgUseMinLength:      "Utilisez au moins 8 caractères",
+		MsgConsiderLonger:    "Utilisez plutôt 12 caractères ou plus pour une meilleure sécurité",
+		MsgAddLower:          "Ajoutez des lettres minuscules",
+		MsgAddUpper:          "Ajoutez des lettres majuscules",
+		MsgAddDigits:         "Ajoutez des chiffres",
+		MsgAddSymbols:        "Ajoutez des symboles (!@#$%%^&*)",
+		MsgAvoidRepeated:     "Évitez les caractères répétés",
+		MsgAvoidSequential:   "Évitez les suites de caractères (abc, 123)",
+		MsgAvoidDictionary:   "Évitez les mots du dictionnaire (%s)",
+		MsgAvoidDates:        "Évitez les années et les dates",
+		MsgAvoidRepetition:   "Évitez de répéter un groupe de caractères (abcabc)",
+		MsgUseMoreDistinct:   "Utilisez davantage de caractères différents",
+		MsgTooPredictable:    "Le mot de passe est trop prévisible",
+		MsgExcellentPassword: "Excellent mot de passe !",
+	},
+}
+
+// Languages returns the language codes with a feedback catalog, sorted.
+func Languages() []string {
+	var languages []string
+	for lang := range messageCatalog {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// IsSupportedLanguage reports whether lang has a feedback catalog.
+func IsSupportedLanguage(lang string) bool {
+	_, exists := messageCatalog[lang]
+	return exists
+}
+
+// Localize renders the message in lang, falling back to English when the
+// language or the message is not in the catalog.
+func (m FeedbackMessage) Localize(lang string) string {
+	format, exists := messageCatalog[lang][m.ID]
+	if !exists {
+		format, exists = messageCatalog[DefaultLanguage][m.ID]
+	}
+	if !exists {
+		return m.ID
+	}
+
+	args := make([]any, len(m.Args))
+	for i, arg := range m.Args {
+		args[i] = arg
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// LocalizeFeedback renders each message in lang, see FeedbackMessage.Localize.
+func LocalizeFeedback(messages []FeedbackMessage, lang string) []string {
+	var feedback []string
+	for _, message := range messages {
+		feedback = append(feedback, message.Localize(lang))
+	}
+	return feedback
+}
@@ -0,0 +1,72 @@
+package pwgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCatalogsCoverEveryMessage(t *testing.T) {
+	english := messageCatalog[DefaultLanguage]
+
+	for _, lang := range Languages() {
+		for id := range english {
+			if _, exists := messageCatalog[lang][id]; !exists {
+				t.Errorf("catalog %q is missing message %q", lang, id)
+			}
+		}
+	}
+}
+
+func TestFeedbackMessageLocalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		message FeedbackMessage
+		lang    string
+		want    string
+	}{
+		{"english", FeedbackMessage{ID: MsgAddUpper}, "en", "Add uppercase letters"},
+		{"french", FeedbackMessage{ID: MsgAddUpper}, "fr", "Ajoutez des lettres majuscules"},
+		{"literal percent sign", FeedbackMessage{ID: MsgAddSymbols}, "en", "Add symbols (!@#$%^&*)"},
+		{"arguments", FeedbackMessage{ID: MsgAvoidDictionary, Args: []string{"password"}}, "fr", "Évitez les mots du dictionnaire (password)"},
+		{"unknown language falls back to english", FeedbackMessage{ID: MsgAddDigits}, "xx", "Add numbers"},
+		{"unknown message falls back to its ID", FeedbackMessage{ID: "no-such-message"}, "en", "no-such-message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.message.Localize(tt.lang); got != tt.want {
+				t.Errorf("Localize(%q) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzePasswordStrengthMessages(t *testing.T) {
+	strength := AnalyzePasswordStrength("abc")
+
+	if len(strength.Messages) == 0 {
+		t.Fatal("AnalyzePasswordStrength() returned no feedback messages")
+	}
+
+	// Feedback stays the English rendering of Messages
+	if !reflect.DeepEqual(strength.Feedback, LocalizeFeedback(strength.Messages, "en")) {
+		t.Errorf("Feedback = %v, want the English rendering of %v", strength.Feedback, strength.Messages)
+	}
+
+	french := LocalizeFeedback(strength.Messages, "fr")
+	if len(french) != len(strength.Messages) {
+		t.Fatalf("LocalizeFeedback() returned %d messages, want %d", len(french), len(strength.Messages))
+	}
+	if french[0] != "Utilisez au moins 8 caractères" {
+		t.Errorf("LocalizeFeedback(fr)[0] = %q, want the French minimum length message", french[0])
+	}
+}
+
+func TestIsSupportedLanguage(t *testing.T) {
+	if !IsSupportedLanguage("en") || !IsSupportedLanguage("fr") {
+		t.Error("IsSupportedLanguage() should accept en and fr")
+	}
+	if IsSupportedLanguage("xx") {
+		t.Error("IsSupportedLanguage(xx) = true, want false")
+	}
+}
@@ -0,0 +1,274 @@
+package pwgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// patternPlaceholders lists the placeholders for error messages.
+const patternPlaceholders = "A, a, 9, s"
+
+// patternEscapes lists the other characters a backslash can escape.
+const patternEscapes = `\[]{}`
+
+// maxPatternRepeat caps a quantifier such as "9{4}".
+const maxPatternRepeat = 256
+
+// patternClasses maps each pattern placeholder to the characters it stands for.
+var patternClasses = map[byte]string{
+	'A': UpperCase,
+	'a': LowerCase,
+	'9': Digits,
+	's': Symbols,
+}
+
+// patternToken is one position of a parsed pattern: either a character class
+// to draw from or a literal copied as is.
+type patternToken struct {
+	class   string
+	literal byte
+}
+
+// ValidatePattern checks that a pattern such as "Aaaa-9999" or
+// "A{4}-[A-F0-9]{8}" is well formed. A backslash makes the next character
+// literal, so "\A" yields "A".
+func ValidatePattern(pattern string) error {
+	_, err := parsePattern(pattern)
+	return err
+}
+
+// GenerateFromPattern returns a password shaped like pattern, where A, a, 9
+// and s are replaced by a random uppercase letter, lowercase letter, digit
+// and symbol, a range such as [A-F] by a random character from it, and every
+// other character is kept. A quantifier such as {4} repeats the placeholder,
+// range or literal before it.
+func GenerateFromPattern(pattern string) (string, error) {
+	return defaultGenerator.GenerateFromPattern(pattern)
+}
+
+// GenerateFromPattern is like the package-level GenerateFromPattern, drawing
+// from g's source.
+func (g *Generator) GenerateFromPattern(pattern string) (string, error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return "", err
+	}
+
+	return generateFromPattern(pattern, g.random)
+}
+
+func generateFromPattern(pattern string, random io.Reader) (string, error) {
+	tokens, err := parsePattern(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	password := make([]byte, len(tokens))
+
+	for i, token := range tokens {
+		if token.class == "" {
+			password[i] = token.literal
+			continue
+		}
+
+		index, err := randomInt(random, len(token.class))
+		if err != nil {
+			return "", err
+		}
+		password[i] = token.class[index]
+	}
+
+	return string(password), nil
+}
+
+// PatternEntropy returns the entropy in bits of passwords generated from
+// pattern: the sum of log2 of each placeholder's or range's size. Literal
+// characters are known to an attacker and add nothing.
+func PatternEntropy(pattern string) (float64, error) {
+	spaces, err := patternSpaces(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	entropy := 0.0
+	for _, space := range spaces {
+		entropy += math.Log2(float64(space))
+	}
+
+	return entropy, nil
+}
+
+// patternSpaces returns the number of possible characters at each random
+// position of pattern, leaving out literals.
+func patternSpaces(pattern string) ([]int, error) {
+	tokens, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var spaces []int
+	for _, token := range tokens {
+		if token.class != "" {
+			spaces = append(spaces, len(token.class))
+		}
+	}
+
+	return spaces, nil
+}
+
+func parsePattern(pattern string) ([]patternToken, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern must not be empty")
+	}
+
+	var tokens []patternToken
+	placeholders := 0
+	// quantifiable is whether the last token came from a placeholder, range
+	// or literal, rather than a quantifier
+	quantifiable := false
+
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+
+		if char >= 0x80 {
+			return nil, fmt.Errorf("pattern contains non-ASCII character at position %d", i+1)
+		}
+
+		switch char {
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, fmt.Errorf("pattern ends with an unfinished escape")
+			}
+			i++
+			next := pattern[i]
+			if _, ok := patternClasses[next]; !ok && !strings.ContainsRune(patternEscapes, rune(next)) {
+				return nil, fmt.Errorf("unknown escape \\%c in pattern, only placeholders (%s) and %s can be escaped", next, patternPlaceholders, patternEscapes)
+			}
+			tokens = append(tokens, patternToken{literal: next})
+
+		case '[':
+			class, end, err := parsePatternRange(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, patternToken{class: class})
+			placeholders++
+
+		case '{':
+			if !quantifiable {
+				return nil, fmt.Errorf("quantifier at position %d does not follow a placeholder, range or literal", i+1)
+			}
+			count, end, err := parsePatternQuantifier(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			last := tokens[len(tokens)-1]
+			for range count - 1 {
+				tokens = append(tokens, last)
+			}
+			if last.class != "" {
+				placeholders += count - 1
+			}
+			quantifiable = false
+			continue
+
+		default:
+			if class, ok := patternClasses[char]; ok {
+				tokens = append(tokens, patternToken{class: class})
+				placeholders++
+			} else {
+				tokens = append(tokens, patternToken{literal: char})
+			}
+		}
+
+		quantifiable = true
+	}
+
+	if placeholders == 0 {
+		return nil, fmt.Errorf("pattern has no placeholders (%s) or ranges, so it would always produce the same password", patternPlaceholders)
+	}
+
+	return tokens, nil
+}
+
+// parsePatternRange parses the range such as "[A-F0-9]" starting at
+// pattern[start], returning its distinct characters in order and the index
+// of the closing bracket. A backslash makes the next character literal, and
+// a dash first or last in the range stands for itself.
+func parsePatternRange(pattern string, start int) (string, int, error) {
+	var chars []byte
+	for i := start + 1; i < len(pattern); i++ {
+		char := pattern[i]
+
+		switch {
+		case char == ']':
+			class := uniqueRunes(string(chars))
+			if len(class) < 2 {
+				return "", 0, fmt.Errorf("range at position %d must contain at least 2 different characters", start+1)
+			}
+			return class, i, nil
+
+		case char == '\\':
+			if i+1 == len(pattern) {
+				return "", 0, fmt.Errorf("pattern ends with an unfinished escape")
+			}
+			i++
+			char = pattern[i]
+
+		case char == '-' && len(chars) > 0 && i+1 < len(pattern) && pattern[i+1] != ']':
+			low, high := chars[len(chars)-1], pattern[i+1]
+			if high == '\\' && i+2 < len(pattern) {
+				high = pattern[i+2]
+				i++
+			}
+			if err := checkRangeChar(high, i+2); err != nil {
+				return "", 0, err
+			}
+			if high < low {
+				return "", 0, fmt.Errorf("range %c-%c at position %d is reversed", low, high, i)
+			}
+			for c := low + 1; c <= high && c > low; c++ {
+				chars = append(chars, c)
+			}
+			i++
+			continue
+		}
+
+		if err := checkRangeChar(char, i+1); err != nil {
+			return "", 0, err
+		}
+		chars = append(chars, char)
+	}
+
+	return "", 0, fmt.Errorf("range at position %d is missing its closing ]", start+1)
+}
+
+// checkRangeChar rejects characters a range cannot hold: anything but
+// printable ASCII.
+func checkRangeChar(char byte, position int) error {
+	if char < 0x20 || char > 0x7E {
+		return fmt.Errorf("range contains a non-printable or non-ASCII character at position %d", position)
+	}
+	return nil
+}
+
+// parsePatternQuantifier parses the quantifier such as "{4}" starting at
+// pattern[start], returning the count and the index of the closing brace.
+func parsePatternQuantifier(pattern string, start int) (int, int, error) {
+	end := strings.IndexByte(pattern[start:], '}')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("quantifier at position %d is missing its closing }", start+1)
+	}
+	end += start
+
+	count, err := strconv.Atoi(pattern[start+1 : end])
+	if err != nil || count < 1 || count > maxPatternRepeat || strings.ContainsAny(pattern[start+1:end], "+-") {
+		return 0, 0, fmt.Errorf("quantifier %s at position %d must be a whole number from 1 to %d", pattern[start:end+1], start+1, maxPatternRepeat)
+	}
+
+	return count, end, nil
+}
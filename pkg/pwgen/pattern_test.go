@@ -0,0 +1,133 @@
+package pwgen
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateFromPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		literal string // expected password with placeholders masked as '?'
+	}{
+		{pattern: "Aaaa-9999", literal: "????-????"},
+		{pattern: "ss.AA", literal: "??.??"},
+		{pattern: `\A-a`, literal: "A-?"},
+		{pattern: `9\\9`, literal: `?\?`},
+		{pattern: "A{4}-9{4}-A{4}", literal: "????-????-????"},
+		{pattern: "[A-F0-9]{8}", literal: "????????"},
+		{pattern: "-{3}a", literal: "---?"},
+		{pattern: `[\]x]\{`, literal: "?{"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			tokens, err := parsePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("parsePattern(%q) error = %v", tt.pattern, err)
+			}
+
+			for i := 0; i < 20; i++ {
+				password, err := GenerateFromPattern(tt.pattern)
+				if err != nil {
+					t.Fatalf("GenerateFromPattern(%q) error = %v", tt.pattern, err)
+				}
+
+				if len(password) != len(tokens) {
+					t.Fatalf("GenerateFromPattern(%q) = %q, want length %d", tt.pattern, password, len(tokens))
+				}
+
+				masked := []byte(password)
+				for j, token := range tokens {
+					if token.class == "" {
+						continue
+					}
+					if !strings.ContainsRune(token.class, rune(password[j])) {
+						t.Fatalf("GenerateFromPattern(%q) = %q, position %d not in its class", tt.pattern, password, j)
+					}
+					masked[j] = '?'
+				}
+
+				if string(masked) != tt.literal {
+					t.Fatalf("GenerateFromPattern(%q) = %q, literals do not match %q", tt.pattern, password, tt.literal)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"Aaaa-9999", false},
+		{`\s9`, false},
+		{"", true},
+		{"----", true},
+		{`\Ab`, true},
+		{`Aa\x`, true},
+		{`Aa\`, true},
+		{"Aé", true},
+		{"a{3}9{4}", false},
+		{"[A-F]", false},
+		{"[-a]", false},
+		{"{3}A", true},
+		{"A{2}{3}", true},
+		{"A{0}", true},
+		{"A{257}", true},
+		{"A{+3}", true},
+		{"A{3", true},
+		{"A{x}", true},
+		{"[A-F", true},
+		{"[]", true},
+		{"[aa]", true},
+		{"[F-A]", true},
+		{"[a-\x7f]", true},
+		{"-{8}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			err := ValidatePattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatternEntropy(t *testing.T) {
+	got, err := PatternEntropy("Aa-9s")
+	if err != nil {
+		t.Fatalf("PatternEntropy() error = %v", err)
+	}
+
+	want := 2*math.Log2(26) + math.Log2(10) + math.Log2(float64(len(Symbols)))
+	if math.Abs(got-want) > 0.001 {
+		t.Errorf("PatternEntropy() = %.3f, want %.3f", got, want)
+	}
+
+	// A range counts its own size, and a quantifier each repetition
+	got, err = PatternEntropy("[A-F]{4}-9")
+	if err != nil {
+		t.Fatalf("PatternEntropy() error = %v", err)
+	}
+	if want := 4*math.Log2(6) + math.Log2(10); math.Abs(got-want) > 0.001 {
+		t.Errorf("PatternEntropy([A-F]{4}-9) = %.3f, want %.3f", got, want)
+	}
+
+	// The analysis credits each position's class instead of the whole charset
+	strength := AnalyzePasswordStrengthWithOptions("Kx-7!", AnalysisOptions{Pattern: "Aa-9s"})
+	if math.Abs(strength.Entropy-want) > 0.001 {
+		t.Errorf("AnalyzePasswordStrengthWithOptions() Entropy = %.3f, want %.3f", strength.Entropy, want)
+	}
+
+	// --verbose lists the size of every position
+	breakdown := ExplainEntropy("Kx-7!", AnalysisOptions{Pattern: "Aa-9s"})
+	if want := []int{26, 26, 10, len(Symbols)}; !slices.Equal(breakdown.PositionSpaces, want) {
+		t.Errorf("ExplainEntropy() PositionSpaces = %v, want %v", breakdown.PositionSpaces, want)
+	}
+}
@@ -0,0 +1,74 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+)
+
+// PatternPenalties are the multipliers the entropy estimate is scaled by
+// for each weak pattern found in a password, see EntropyBreakdown.Penalties.
+// Each must be in (0, 1], where 1 leaves the estimate alone. A zero field
+// means its DefaultPatternPenalties value, so the zero value applies the
+// defaults throughout.
+type PatternPenalties struct {
+	Repeated   float64 // The same character three or more times in a row
+	Sequential float64 // A run of the alphabet, digits or a keyboard row
+	Substrings float64 // A group of characters repeated back-to-back
+	Common     float64 // A common password such as "password" or "letmein"
+	Date       float64 // A year, date or month name
+	Distinct   float64 // Few different characters, as in "abababab"
+}
+
+// DefaultPatternPenalties are the multipliers applied when
+// AnalysisOptions.PatternPenalties leaves them unset.
+var DefaultPatternPenalties = PatternPenalties{
+	Repeated:   0.8,
+	Sequential: 0.7,
+	Substrings: 0.8,
+	Common:     0.6,
+	Date:       0.8,
+	Distinct:   0.8,
+}
+
+// withDefaults returns p with each zero field replaced by its
+// DefaultPatternPenalties value.
+func (p PatternPenalties) withDefaults() PatternPenalties {
+	fields := []*float64{&p.Repeated, &p.Sequential, &p.Substrings, &p.Common, &p.Date, &p.Distinct}
+	defaults := DefaultPatternPenalties.list()
+	for i, field := range fields {
+		if *field == 0 {
+			*field = defaults[i]
+		}
+	}
+	return p
+}
+
+// list returns the multipliers in the order of patternPenaltyNames.
+func (p PatternPenalties) list() []float64 {
+	return []float64{p.Repeated, p.Sequential, p.Substrings, p.Common, p.Date, p.Distinct}
+}
+
+// patternPenaltyNames names the patterns of PatternPenalties in field
+// order, as listed in EntropyBreakdown.Penalties.
+var patternPenaltyNames = []string{
+	"repeated characters",
+	"sequential characters",
+	"repeated substrings",
+	"common pattern",
+	"year or date",
+	"few distinct characters",
+}
+
+// ValidatePatternPenalties reports an error for a multiplier outside
+// (0, 1], other than zero, which means the default.
+func ValidatePatternPenalties(p PatternPenalties) error {
+	for i, multiplier := range p.list() {
+		if multiplier == 0 {
+			continue
+		}
+		if math.IsNaN(multiplier) || multiplier < 0 || multiplier > 1 {
+			return fmt.Errorf("%s penalty must be more than 0 and at most 1, got %g", patternPenaltyNames[i], multiplier)
+		}
+	}
+	return nil
+}
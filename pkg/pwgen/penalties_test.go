@@ -0,0 +1,56 @@
+package pwgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidatePatternPenalties(t *testing.T) {
+	valid := []PatternPenalties{
+		{},
+		DefaultPatternPenalties,
+		{Repeated: 1, Sequential: 0.01},
+	}
+	for _, penalties := range valid {
+		if err := ValidatePatternPenalties(penalties); err != nil {
+			t.Errorf("ValidatePatternPenalties(%+v) error = %v", penalties, err)
+		}
+	}
+
+	invalid := []PatternPenalties{
+		{Repeated: -0.5},
+		{Common: 1.1},
+		{Date: math.NaN()},
+	}
+	for _, penalties := range invalid {
+		if err := ValidatePatternPenalties(penalties); err == nil {
+			t.Errorf("ValidatePatternPenalties(%+v) should fail", penalties)
+		}
+	}
+}
+
+func TestPatternPenaltiesOption(t *testing.T) {
+	// "password" is a common pattern and nothing else
+	defaults := ExplainEntropy("password", AnalysisOptions{})
+	if len(defaults.Penalties) != 1 || defaults.Penalties[0].Multiplier != DefaultPatternPenalties.Common {
+		t.Fatalf("ExplainEntropy() penalties = %+v, want the default common pattern penalty", defaults.Penalties)
+	}
+
+	custom := ExplainEntropy("password", AnalysisOptions{PatternPenalties: PatternPenalties{Common: 0.25}})
+	if custom.Penalties[0].Multiplier != 0.25 || math.Abs(custom.Entropy-custom.RawEntropy*0.25) > 1e-9 {
+		t.Errorf("ExplainEntropy() = %+v, want the common pattern multiplied by 0.25", custom)
+	}
+
+	// A multiplier of 1 keeps the pattern listed but leaves the estimate alone
+	ignored := ExplainEntropy("password", AnalysisOptions{PatternPenalties: PatternPenalties{Common: 1}})
+	if ignored.Entropy != ignored.RawEntropy {
+		t.Errorf("ExplainEntropy() entropy = %.1f, want the raw %.1f", ignored.Entropy, ignored.RawEntropy)
+	}
+
+	// Zero fields fall back to their default, under every entropy model
+	shannon := ExplainEntropy("aaa2024", AnalysisOptions{EntropyModel: EntropyModelShannon, PatternPenalties: PatternPenalties{Repeated: 0.5}})
+	want := []EntropyPenalty{{"repeated characters", 0.5}, {"year or date", DefaultPatternPenalties.Date}}
+	if len(shannon.Penalties) != len(want) || shannon.Penalties[0] != want[0] || shannon.Penalties[1] != want[1] {
+		t.Errorf("ExplainEntropy() penalties = %+v, want %+v", shannon.Penalties, want)
+	}
+}
@@ -0,0 +1,992 @@
+package pwgen
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type PasswordPolicy struct {
+	Name                     string   `yaml:"name"`
+	Description              string   `yaml:"description"`
+	MinLength                int      `yaml:"min_length"`
+	MaxLength                int      `yaml:"max_length"`
+	RequireUpper             bool     `yaml:"require_upper"`
+	RequireLower             bool     `yaml:"require_lower"`
+	RequireDigits            bool     `yaml:"require_digits"`
+	RequireSymbols           bool     `yaml:"require_symbols"`
+	MinUpper                 int      `yaml:"min_upper"`
+	MinLower                 int      `yaml:"min_lower"`
+	MinDigits                int      `yaml:"min_digits"`
+	MinSymbols               int      `yaml:"min_symbols"`
+	MaxUpper                 int      `yaml:"max_upper"` // 0 means no maximum, as for the other Max* counts
+	MaxLower                 int      `yaml:"max_lower"`
+	MaxDigits                int      `yaml:"max_digits"`
+	MaxSymbols               int      `yaml:"max_symbols"`
+	MinCharacterClasses      int      `yaml:"min_character_classes"` // Of uppercase, lowercase, digits and symbols, e.g. "3 of 4"
+	ExcludeAmbiguous         bool     `yaml:"exclude_ambiguous"`
+	AmbiguousChars           string   `yaml:"ambiguous_chars"` // Defaults to Ambiguous when empty
+	ForbiddenChars           string   `yaml:"forbidden_chars"`
+	AllowedSymbols           string   `yaml:"allowed_symbols"` // When set, the only symbols a password may contain
+	ForbiddenPatterns        []string `yaml:"forbidden_patterns"`
+	MaxSimilarityToForbidden float64  `yaml:"max_similarity_to_forbidden"` // From 0 to 1; passwords closer than this to a forbidden pattern fail, 0 disables
+	ForbidRepeatedSubstrings bool     `yaml:"forbid_repeated_substrings"`  // Reject a group of characters repeated back-to-back, such as "abcabc" or "xyxyxy"
+	MinEntropy               float64  `yaml:"min_entropy"`
+	MaxConsecutiveRepeat     int      `yaml:"max_consecutive_repeat"`  // 0 allows runs of any length
+	ForbidDictionaryWords    int      `yaml:"forbid_dictionary_words"` // Reject embedded English words of at least this many letters, 0 disables
+	ForbidDates              bool     `yaml:"forbid_dates"`            // Reject years from 1900 to 2099, MMDDYYYY and DDMMYYYY dates and month names
+	NoRepeatedChars          bool     `yaml:"no_repeated_chars"`       // Reject passwords using any character more than once
+	MaxSameClassRun          int      `yaml:"max_same_class_run"`      // Reject more than this many characters of one class in a row, e.g. "1234" for 3; 0 disables
+	ForbidZeroWidth          bool     `yaml:"forbid_zero_width"`       // Reject invisible characters such as zero-width spaces and joiners, see InvisibleChars
+	MinDistinctChars         int      `yaml:"min_distinct_chars"`      // Reject passwords using fewer different characters, e.g. "abababab" uses 2
+}
+
+type PolicyViolation struct {
+	Rule        string
+	Description string
+}
+
+var BuiltinPolicies = map[string]PasswordPolicy{
+	"basic": {
+		Name:              "Basic Security",
+		Description:       "Minimal security requirements suitable for low-risk applications",
+		MinLength:         8,
+		MaxLength:         0, // No limit
+		RequireUpper:      true,
+		RequireLower:      true,
+		RequireDigits:     true,
+		RequireSymbols:    false,
+		MinUpper:          1,
+		MinLower:          1,
+		MinDigits:         1,
+		MinSymbols:        0,
+		ExcludeAmbiguous:  false,
+		ForbiddenChars:    "",
+		ForbiddenPatterns: []string{"password", "123456", "qwerty"},
+		MinEntropy:        25,
+	},
+	"corporate": {
+		Name:              "Corporate Standard",
+		Description:       "Standard corporate password policy with moderate security",
+		MinLength:         12,
+		MaxLength:         0,
+		RequireUpper:      true,
+		RequireLower:      true,
+		RequireDigits:     true,
+		RequireSymbols:    true,
+		MinUpper:          2,
+		MinLower:          2,
+		MinDigits:         2,
+		MinSymbols:        1,
+		ExcludeAmbiguous:  true,
+		ForbiddenChars:    "",
+		ForbiddenPatterns: []string{"password", "123456", "qwerty", "admin", "login", "welcome"},
+		MinEntropy:        40,
+	},
+	"high-security": {
+		Name:             "High Security",
+		Description:      "Stringent requirements for high-security environments",
+		MinLength:        16,
+		MaxLength:        0,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigits:    true,
+		RequireSymbols:   true,
+		MinUpper:         3,
+		MinLower:         3,
+		MinDigits:        3,
+		MinSymbols:       2,
+		ExcludeAmbiguous: true,
+		ForbiddenChars:   "",
+		ForbiddenPatterns: []string{
+			"password", "123456", "qwerty", "admin", "login", "welcome",
+			"letmein", "monkey", "dragon", "master", "shadow", "football",
+		},
+		MinEntropy: 60,
+	},
+	"aws": {
+		Name:              "AWS IAM Policy",
+		Description:       "Meets AWS IAM password policy requirements",
+		MinLength:         8,
+		MaxLength:         128,
+		RequireUpper:      true,
+		RequireLower:      true,
+		RequireDigits:     true,
+		RequireSymbols:    true,
+		MinUpper:          1,
+		MinLower:          1,
+		MinDigits:         1,
+		MinSymbols:        1,
+		ExcludeAmbiguous:  false,
+		ForbiddenChars:    "",
+		AllowedSymbols:    "!@#$%^&*()_+-=[]{}|'",
+		ForbiddenPatterns: []string{},
+		MinEntropy:        30,
+	},
+	"azure": {
+		Name:              "Azure AD Policy",
+		Description:       "Meets Azure AD password complexity requirements",
+		MinLength:         8,
+		MaxLength:         256,
+		RequireUpper:      true,
+		RequireLower:      true,
+		RequireDigits:     true,
+		RequireSymbols:    true,
+		MinUpper:          1,
+		MinLower:          1,
+		MinDigits:         1,
+		MinSymbols:        1,
+		ExcludeAmbiguous:  false,
+		ForbiddenChars:    "",
+		AllowedSymbols:    "@#$%^&*-_!+=[]{}|\\:',.?/`~\"();<> ",
+		ForbiddenPatterns: []string{},
+		MinEntropy:        35,
+	},
+	"pci-dss": {
+		Name:              "PCI DSS Compliant",
+		Description:       "Meets PCI DSS password requirements for payment systems",
+		MinLength:         7,
+		MaxLength:         0,
+		RequireUpper:      true,
+		RequireLower:      true,
+		RequireDigits:     true,
+		RequireSymbols:    false,
+		MinUpper:          1,
+		MinLower:          1,
+		MinDigits:         1,
+		MinSymbols:        0,
+		ExcludeAmbiguous:  false,
+		ForbiddenChars:    "",
+		ForbiddenPatterns: []string{},
+		MinEntropy:        28,
+	},
+}
+
+func GetPolicy(name string) (PasswordPolicy, error) {
+	if policy, exists := BuiltinPolicies[name]; exists {
+		return policy, nil
+	}
+	return PasswordPolicy{}, fmt.Errorf("policy '%s' not found", name)
+}
+
+// RegisterPolicy adds policy to BuiltinPolicies under name, so GetPolicy,
+// MergePolicies and ListPolicies offer it like the builtin ones. It reports
+// whether a policy of that name was replaced.
+func RegisterPolicy(name string, policy PasswordPolicy) bool {
+	_, exists := BuiltinPolicies[name]
+	BuiltinPolicies[name] = policy
+	return exists
+}
+
+// ListPolicies returns the names of BuiltinPolicies, sorted.
+func ListPolicies() []string {
+	var policies []string
+	for name := range BuiltinPolicies {
+		policies = append(policies, name)
+	}
+	sort.Strings(policies)
+	return policies
+}
+
+// ValidateAgainstPolicies validates the password against each named policy
+// and returns the violations per policy name; a policy the password meets
+// maps to no violations. An unknown name is reported as a single
+// "UnknownPolicy" violation.
+func ValidateAgainstPolicies(password string, names []string) map[string][]PolicyViolation {
+	results := make(map[string][]PolicyViolation, len(names))
+
+	for _, name := range names {
+		policy, err := GetPolicy(name)
+		if err != nil {
+			results[name] = []PolicyViolation{{Rule: "UnknownPolicy", Description: err.Error()}}
+			continue
+		}
+
+		results[name] = ValidatePasswordAgainstPolicy(password, policy)
+	}
+
+	return results
+}
+
+// MergePolicies combines the named policies into the most restrictive policy
+// that satisfies all of them: the longest minimum and shortest maximum
+// length, every requirement, the highest minimum and lowest maximum count
+// of each character class, the union of forbidden and ambiguous characters
+// and forbidden patterns, and the symbols every policy allows. It fails for
+// an unknown name, an empty list, or when the combined policy cannot be
+// satisfied.
+func MergePolicies(names []string) (PasswordPolicy, error) {
+	if len(names) == 0 {
+		return PasswordPolicy{}, fmt.Errorf("no policies to merge")
+	}
+
+	policies := make([]PasswordPolicy, 0, len(names))
+	for _, name := range names {
+		policy, err := GetPolicy(name)
+		if err != nil {
+			return PasswordPolicy{}, err
+		}
+		policies = append(policies, policy)
+	}
+
+	if len(policies) == 1 {
+		return policies[0], nil
+	}
+
+	var titles []string
+	for _, policy := range policies {
+		titles = append(titles, policy.Name)
+	}
+
+	merged := PasswordPolicy{
+		Name:        strings.Join(titles, " + "),
+		Description: fmt.Sprintf("Combination of the %s policies", strings.Join(names, ", ")),
+	}
+
+	customAmbiguous := false
+	var ambiguous []string
+	seenPatterns := make(map[string]bool)
+
+	for _, policy := range policies {
+		merged.MinLength = max(merged.MinLength, policy.MinLength)
+		merged.MaxLength = stricterMax(merged.MaxLength, policy.MaxLength)
+
+		merged.RequireUpper = merged.RequireUpper || policy.RequireUpper
+		merged.RequireLower = merged.RequireLower || policy.RequireLower
+		merged.RequireDigits = merged.RequireDigits || policy.RequireDigits
+		merged.RequireSymbols = merged.RequireSymbols || policy.RequireSymbols
+
+		merged.MinUpper = max(merged.MinUpper, policy.MinUpper)
+		merged.MinLower = max(merged.MinLower, policy.MinLower)
+		merged.MinDigits = max(merged.MinDigits, policy.MinDigits)
+		merged.MinSymbols = max(merged.MinSymbols, policy.MinSymbols)
+
+		merged.MaxUpper = stricterMax(merged.MaxUpper, policy.MaxUpper)
+		merged.MaxLower = stricterMax(merged.MaxLower, policy.MaxLower)
+		merged.MaxDigits = stricterMax(merged.MaxDigits, policy.MaxDigits)
+		merged.MaxSymbols = stricterMax(merged.MaxSymbols, policy.MaxSymbols)
+		merged.MinCharacterClasses = max(merged.MinCharacterClasses, policy.MinCharacterClasses)
+		merged.MaxConsecutiveRepeat = stricterMax(merged.MaxConsecutiveRepeat, policy.MaxConsecutiveRepeat)
+		merged.MaxSameClassRun = stricterMax(merged.MaxSameClassRun, policy.MaxSameClassRun)
+
+		if policy.ExcludeAmbiguous {
+			merged.ExcludeAmbiguous = true
+			ambiguous = append(ambiguous, ambiguousSet(policy.AmbiguousChars))
+			customAmbiguous = customAmbiguous || policy.AmbiguousChars != ""
+		}
+
+		merged.ForbiddenChars = uniqueRunes(merged.ForbiddenChars + policy.ForbiddenChars)
+		if policy.AllowedSymbols != "" {
+			if merged.AllowedSymbols == "" {
+				merged.AllowedSymbols = uniqueRunes(policy.AllowedSymbols)
+			} else if merged.AllowedSymbols = intersectRunes(merged.AllowedSymbols, policy.AllowedSymbols); merged.AllowedSymbols == "" {
+				return PasswordPolicy{}, fmt.Errorf("policies %s cannot be combined: no symbol is allowed by all of them", strings.Join(names, ", "))
+			}
+		}
+		for _, pattern := range policy.ForbiddenPatterns {
+			if !seenPatterns[pattern] {
+				seenPatterns[pattern] = true
+				merged.ForbiddenPatterns = append(merged.ForbiddenPatterns, pattern)
+			}
+		}
+
+		merged.MinEntropy = max(merged.MinEntropy, policy.MinEntropy)
+		merged.MinDistinctChars = max(merged.MinDistinctChars, policy.MinDistinctChars)
+		merged.ForbidDictionaryWords = stricterMax(merged.ForbidDictionaryWords, policy.ForbidDictionaryWords)
+		merged.ForbidDates = merged.ForbidDates || policy.ForbidDates
+		merged.ForbidZeroWidth = merged.ForbidZeroWidth || policy.ForbidZeroWidth
+		merged.ForbidRepeatedSubstrings = merged.ForbidRepeatedSubstrings || policy.ForbidRepeatedSubstrings
+		merged.NoRepeatedChars = merged.NoRepeatedChars || policy.NoRepeatedChars
+		if policy.MaxSimilarityToForbidden > 0 && (merged.MaxSimilarityToForbidden == 0 || policy.MaxSimilarityToForbidden < merged.MaxSimilarityToForbidden) {
+			merged.MaxSimilarityToForbidden = policy.MaxSimilarityToForbidden
+		}
+	}
+
+	// Leave the default set implied unless some policy customized it
+	if customAmbiguous {
+		merged.AmbiguousChars = uniqueRunes(strings.Join(ambiguous, ""))
+	}
+
+	if err := checkSatisfiable(merged); err != nil {
+		return PasswordPolicy{}, fmt.Errorf("policies %s cannot be combined: %w", strings.Join(names, ", "), err)
+	}
+
+	return merged, nil
+}
+
+// checkSatisfiable reports the first of the length and character count
+// limits that no password can meet at the same time.
+func checkSatisfiable(policy PasswordPolicy) error {
+	if problems := satisfiabilityProblems(policy); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// satisfiabilityProblems lists every combination of limits in policy that
+// no password can meet.
+func satisfiabilityProblems(policy PasswordPolicy) []error {
+	var problems []error
+
+	if policy.MaxLength > 0 && policy.MinLength > policy.MaxLength {
+		problems = append(problems, fmt.Errorf("minimum length %d is more than the maximum length %d", policy.MinLength, policy.MaxLength))
+	}
+
+	classes := []struct {
+		name     string
+		min, max int
+		size     int // Distinct characters in the class, 0 for symbols, which may be any Unicode
+	}{
+		{"uppercase letters", policy.MinUpper, policy.MaxUpper, 26},
+		{"lowercase letters", policy.MinLower, policy.MaxLower, 26},
+		{"digits", policy.MinDigits, policy.MaxDigits, 10},
+		{"symbols", policy.MinSymbols, policy.MaxSymbols, 0},
+	}
+
+	required := 0
+	for _, class := range classes {
+		if class.max > 0 && class.min > class.max {
+			problems = append(problems, fmt.Errorf("minimum of %d %s is more than the maximum of %d", class.min, class.name, class.max))
+		}
+		if policy.NoRepeatedChars && class.size > 0 && class.min > class.size {
+			problems = append(problems, fmt.Errorf("minimum of %d %s cannot be met without repeated characters, as there are only %d", class.min, class.name, class.size))
+		}
+		required += class.min
+	}
+
+	if policy.MaxLength > 0 && required > policy.MaxLength {
+		problems = append(problems, fmt.Errorf("minimum character counts add up to %d, more than the maximum length %d", required, policy.MaxLength))
+	}
+
+	if policy.AllowedSymbols != "" && (policy.RequireSymbols || policy.MinSymbols > 0) {
+		usable := false
+		for _, char := range policy.AllowedSymbols {
+			if isSymbol(char) && !strings.ContainsRune(policy.ForbiddenChars, char) {
+				usable = true
+				break
+			}
+		}
+		if !usable {
+			problems = append(problems, fmt.Errorf("symbols are required but none of the allowed symbols %q is usable", policy.AllowedSymbols))
+		}
+	}
+
+	if policy.MaxSimilarityToForbidden < 0 || policy.MaxSimilarityToForbidden > 1 {
+		problems = append(problems, fmt.Errorf("maximum similarity to forbidden patterns must be between 0 and 1"))
+	}
+
+	if policy.ForbidDictionaryWords < 0 {
+		problems = append(problems, fmt.Errorf("minimum dictionary word length must not be negative"))
+	}
+
+	if policy.MinCharacterClasses > len(classes) {
+		problems = append(problems, fmt.Errorf("at least %d character classes are required but there are only %d", policy.MinCharacterClasses, len(classes)))
+	}
+
+	if policy.MaxLength > 0 && policy.MinCharacterClasses > policy.MaxLength {
+		problems = append(problems, fmt.Errorf("at least %d character classes cannot fit in the maximum length %d", policy.MinCharacterClasses, policy.MaxLength))
+	}
+
+	// Runs of at most r characters need a character of another class
+	// between each, so a minimum of m needs ceil(m/r)-1 of them
+	if policy.MaxSameClassRun > 0 && policy.MaxLength > 0 {
+		for _, class := range classes {
+			if class.min == 0 {
+				continue
+			}
+			separators := (class.min+policy.MaxSameClassRun-1)/policy.MaxSameClassRun - 1
+			if class.min+separators > policy.MaxLength {
+				problems = append(problems, fmt.Errorf("minimum of %d %s in runs of at most %d needs %d characters, more than the maximum length %d", class.min, class.name, policy.MaxSameClassRun, class.min+separators, policy.MaxLength))
+			}
+		}
+	}
+
+	return problems
+}
+
+// maxClassCharSpace is the character space the entropy estimate credits a
+// password using every character class.
+const maxClassCharSpace = 26 + 26 + 10 + 32
+
+// CheckPolicy lists every problem that makes policy inconsistent or
+// unusable, for linting a custom policy before it is deployed: the limits
+// no password can meet together, as MergePolicies rejects, plus a missing
+// name, negative values, empty forbidden patterns and a MinEntropy that no
+// password within MaxLength can reach. It returns nil for a sound policy.
+func CheckPolicy(policy PasswordPolicy) []error {
+	var problems []error
+
+	if strings.TrimSpace(policy.Name) == "" {
+		problems = append(problems, fmt.Errorf("policy has no name"))
+	}
+
+	counts := []struct {
+		name  string
+		value int
+	}{
+		{"min_length", policy.MinLength},
+		{"max_length", policy.MaxLength},
+		{"min_upper", policy.MinUpper},
+		{"min_lower", policy.MinLower},
+		{"min_digits", policy.MinDigits},
+		{"min_symbols", policy.MinSymbols},
+		{"max_upper", policy.MaxUpper},
+		{"max_lower", policy.MaxLower},
+		{"max_digits", policy.MaxDigits},
+		{"max_symbols", policy.MaxSymbols},
+		{"min_character_classes", policy.MinCharacterClasses},
+		{"max_consecutive_repeat", policy.MaxConsecutiveRepeat},
+		{"max_same_class_run", policy.MaxSameClassRun},
+		{"min_distinct_chars", policy.MinDistinctChars},
+	}
+	for _, count := range counts {
+		if count.value < 0 {
+			problems = append(problems, fmt.Errorf("%s must not be negative, got %d", count.name, count.value))
+		}
+	}
+
+	if policy.MinEntropy < 0 {
+		problems = append(problems, fmt.Errorf("min_entropy must not be negative, got %.1f", policy.MinEntropy))
+	}
+
+	problems = append(problems, satisfiabilityProblems(policy)...)
+
+	for _, char := range uniqueRunes(policy.AllowedSymbols) {
+		if !isSymbol(char) {
+			problems = append(problems, fmt.Errorf("allowed_symbols contains '%c', which is not a symbol", char))
+		}
+	}
+
+	for i, pattern := range policy.ForbiddenPatterns {
+		if pattern == "" {
+			problems = append(problems, fmt.Errorf("forbidden pattern %d is empty and would forbid every password", i+1))
+		}
+	}
+
+	if policy.MaxLength > 0 && policy.MinDistinctChars > policy.MaxLength {
+		problems = append(problems, fmt.Errorf("min_distinct_chars %d exceeds max_length %d", policy.MinDistinctChars, policy.MaxLength))
+	}
+
+	if policy.MaxLength > 0 {
+		if best := float64(policy.MaxLength) * math.Log2(maxClassCharSpace); best < policy.MinEntropy {
+			problems = append(problems, fmt.Errorf("minimum entropy of %.1f bits is unreachable: %d characters give at most %.1f bits", policy.MinEntropy, policy.MaxLength, best))
+		}
+	}
+
+	return problems
+}
+
+func ValidatePasswordAgainstPolicy(password string, policy PasswordPolicy) []PolicyViolation {
+	var violations []PolicyViolation
+
+	// Length checks count the characters a reader sees, so combining
+	// marks and invisible characters cannot pad a short password
+	length := visibleLength(password)
+	var uncounted string
+	if length != utf8.RuneCountInString(password) {
+		uncounted = fmt.Sprintf(" (found %d; combining marks and invisible characters are not counted)", length)
+	}
+	if length < policy.MinLength {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MinLength",
+			Description: fmt.Sprintf("Password must be at least %d characters long%s", policy.MinLength, uncounted),
+		})
+	}
+
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxLength",
+			Description: fmt.Sprintf("Password must not exceed %d characters%s", policy.MaxLength, uncounted),
+		})
+	}
+
+	if invisible := InvisibleChars(password); policy.ForbidZeroWidth && len(invisible) > 0 {
+		violations = append(violations, PolicyViolation{
+			Rule:        "ForbidZeroWidth",
+			Description: fmt.Sprintf("Password must not contain invisible characters, found %s", formatCodePoints(invisible)),
+		})
+	}
+
+	// Character type requirements
+	upperCount := countMatches(password, upperPattern)
+	lowerCount := countMatches(password, lowerPattern)
+	digitCount := countMatches(password, digitPattern)
+	symbolCount := countMatches(password, symbolPattern)
+
+	if policy.RequireUpper && upperCount == 0 {
+		violations = append(violations, PolicyViolation{
+			Rule:        "RequireUpper",
+			Description: "Password must contain at least one uppercase letter",
+		})
+	}
+
+	if policy.RequireLower && lowerCount == 0 {
+		violations = append(violations, PolicyViolation{
+			Rule:        "RequireLower",
+			Description: "Password must contain at least one lowercase letter",
+		})
+	}
+
+	if policy.RequireDigits && digitCount == 0 {
+		violations = append(violations, PolicyViolation{
+			Rule:        "RequireDigits",
+			Description: "Password must contain at least one digit",
+		})
+	}
+
+	if policy.RequireSymbols && symbolCount == 0 {
+		violations = append(violations, PolicyViolation{
+			Rule:        "RequireSymbols",
+			Description: "Password must contain at least one symbol",
+		})
+	}
+
+	// Minimum character counts
+	if upperCount < policy.MinUpper {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MinUpper",
+			Description: fmt.Sprintf("Password must contain at least %d uppercase letters", policy.MinUpper),
+		})
+	}
+
+	if lowerCount < policy.MinLower {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MinLower",
+			Description: fmt.Sprintf("Password must contain at least %d lowercase letters", policy.MinLower),
+		})
+	}
+
+	if digitCount < policy.MinDigits {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MinDigits",
+			Description: fmt.Sprintf("Password must contain at least %d digits", policy.MinDigits),
+		})
+	}
+
+	if symbolCount < policy.MinSymbols {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MinSymbols",
+			Description: fmt.Sprintf("Password must contain at least %d symbols", policy.MinSymbols),
+		})
+	}
+
+	// Maximum character counts
+	if policy.MaxUpper > 0 && upperCount > policy.MaxUpper {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxUpper",
+			Description: fmt.Sprintf("Password must contain at most %d uppercase letters", policy.MaxUpper),
+		})
+	}
+
+	if policy.MaxLower > 0 && lowerCount > policy.MaxLower {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxLower",
+			Description: fmt.Sprintf("Password must contain at most %d lowercase letters", policy.MaxLower),
+		})
+	}
+
+	if policy.MaxDigits > 0 && digitCount > policy.MaxDigits {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxDigits",
+			Description: fmt.Sprintf("Password must contain at most %d digits", policy.MaxDigits),
+		})
+	}
+
+	if policy.MaxSymbols > 0 && symbolCount > policy.MaxSymbols {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxSymbols",
+			Description: fmt.Sprintf("Password must contain at most %d symbols", policy.MaxSymbols),
+		})
+	}
+
+	// Character class variety, e.g. "3 of 4 character types"
+	if policy.MinCharacterClasses > 0 {
+		present := 0
+		for _, count := range []int{upperCount, lowerCount, digitCount, symbolCount} {
+			if count > 0 {
+				present++
+			}
+		}
+
+		if present < policy.MinCharacterClasses {
+			violations = append(violations, PolicyViolation{
+				Rule:        "MinCharacterClasses",
+				Description: fmt.Sprintf("Password must contain at least %d of the 4 character types (uppercase, lowercase, digits, symbols), found %d", policy.MinCharacterClasses, present),
+			})
+		}
+	}
+
+	// Ambiguous character check
+	if policy.ExcludeAmbiguous {
+		ambiguous := ambiguousSet(policy.AmbiguousChars)
+		for _, char := range ambiguous {
+			if strings.ContainsRune(password, char) {
+				violations = append(violations, PolicyViolation{
+					Rule:        "ExcludeAmbiguous",
+					Description: fmt.Sprintf("Password must not contain ambiguous characters (%s)", ambiguous),
+				})
+				break
+			}
+		}
+	}
+
+	// Consecutive repeats
+	if policy.MaxConsecutiveRepeat > 0 && longestRun(password) > policy.MaxConsecutiveRepeat {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxConsecutiveRepeat",
+			Description: fmt.Sprintf("Password must not repeat a character more than %d times in a row", policy.MaxConsecutiveRepeat),
+		})
+	}
+
+	// Runs of one character class
+	if policy.MaxSameClassRun > 0 && longestClassRun(password) > policy.MaxSameClassRun {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MaxSameClassRun",
+			Description: fmt.Sprintf("Password must not have more than %d characters of the same class in a row", policy.MaxSameClassRun),
+		})
+	}
+
+	// Forbidden characters
+	if policy.ForbiddenChars != "" {
+		for _, char := range policy.ForbiddenChars {
+			if strings.ContainsRune(password, char) {
+				violations = append(violations, PolicyViolation{
+					Rule:        "ForbiddenChars",
+					Description: fmt.Sprintf("Password must not contain forbidden character '%c'", char),
+				})
+			}
+		}
+	}
+
+	// Symbols outside the whitelist
+	if policy.AllowedSymbols != "" {
+		for _, char := range uniqueRunes(password) {
+			if isSymbol(char) && !strings.ContainsRune(policy.AllowedSymbols, char) {
+				violations = append(violations, PolicyViolation{
+					Rule:        "AllowedSymbols",
+					Description: fmt.Sprintf("Password must not contain the symbol '%c', only %s are allowed", char, policy.AllowedSymbols),
+				})
+			}
+		}
+	}
+
+	// Forbidden patterns, and near misses of them
+	lower := strings.ToLower(password)
+	for _, pattern := range policy.ForbiddenPatterns {
+		if containsForbiddenPattern(lower, strings.ToLower(pattern)) {
+			violations = append(violations, PolicyViolation{
+				Rule:        "ForbiddenPatterns",
+				Description: fmt.Sprintf("Password must not contain forbidden pattern '%s'", pattern),
+			})
+			continue
+		}
+
+		if policy.MaxSimilarityToForbidden > 0 && similarity(password, pattern) > policy.MaxSimilarityToForbidden {
+			violations = append(violations, PolicyViolation{
+				Rule:        "MaxSimilarityToForbidden",
+				Description: fmt.Sprintf("Password is too similar to forbidden pattern '%s'", pattern),
+			})
+		}
+	}
+
+	// Embedded dictionary words
+	if policy.ForbidDictionaryWords > 0 {
+		for _, word := range findEnglishWords(password, policy.ForbidDictionaryWords) {
+			violations = append(violations, PolicyViolation{
+				Rule:        "ForbidDictionaryWords",
+				Description: fmt.Sprintf("Password must not contain the dictionary word '%s'", word),
+			})
+		}
+	}
+
+	// Years, dates and month names
+	if policy.ForbidDates && hasDatePattern(password) {
+		violations = append(violations, PolicyViolation{
+			Rule:        "ForbidDates",
+			Description: "Password must not contain a year, date or month name",
+		})
+	}
+
+	// A group of characters repeated back-to-back
+	if policy.ForbidRepeatedSubstrings && hasRepeatedSubstrings(password) {
+		violations = append(violations, PolicyViolation{
+			Rule:        "ForbidRepeatedSubstrings",
+			Description: "Password must not repeat a group of characters back-to-back, as in \"abcabc\"",
+		})
+	}
+
+	// Any character used twice
+	if policy.NoRepeatedChars && len(uniqueRunes(password)) != len(password) {
+		violations = append(violations, PolicyViolation{
+			Rule:        "NoRepeatedChars",
+			Description: "Password must not use any character more than once",
+		})
+	}
+
+	// Too few different characters
+	if distinct := distinctChars(password); distinct < policy.MinDistinctChars {
+		violations = append(violations, PolicyViolation{
+			Rule:        "MinDistinctChars",
+			Description: fmt.Sprintf("Password must contain at least %d different characters, found %d", policy.MinDistinctChars, distinct),
+		})
+	}
+
+	// Entropy check
+	if policy.MinEntropy > 0 {
+		entropy := calculateEntropy(password)
+		if entropy < policy.MinEntropy {
+			violations = append(violations, PolicyViolation{
+				Rule:        "MinEntropy",
+				Description: fmt.Sprintf("Password entropy (%.1f bits) must be at least %.1f bits", entropy, policy.MinEntropy),
+			})
+		}
+	}
+
+	return violations
+}
+
+// InvisibleChars returns the characters of password that take up no space
+// when displayed, once each in order of appearance: format characters such
+// as zero-width spaces, joiners, bidi marks and soft hyphens, and variation
+// selectors.
+func InvisibleChars(password string) []rune {
+	var invisible []rune
+	for _, char := range password {
+		if isInvisible(char) && !slices.Contains(invisible, char) {
+			invisible = append(invisible, char)
+		}
+	}
+	return invisible
+}
+
+func isInvisible(char rune) bool {
+	return unicode.In(char, unicode.Cf, unicode.Variation_Selector)
+}
+
+// visibleLength approximates the number of characters a reader sees in
+// password: its runes, leaving out invisible characters and the combining
+// marks that attach to the character before them. "é" written as "e" and
+// U+0301 counts once and a zero-width space not at all, though a sequence
+// of emoji joined into one still counts each of them.
+func visibleLength(password string) int {
+	length := 0
+	for i, char := range password {
+		if isInvisible(char) || (i > 0 && unicode.Is(unicode.M, char)) {
+			continue
+		}
+		length++
+	}
+	return length
+}
+
+// formatCodePoints lists chars as "U+200B, U+FEFF".
+func formatCodePoints(chars []rune) string {
+	codes := make([]string, len(chars))
+	for i, char := range chars {
+		codes[i] = fmt.Sprintf("%U", char)
+	}
+	return strings.Join(codes, ", ")
+}
+
+// containsForbiddenPattern reports whether password contains pattern. When
+// the pattern is as long as the password the check amounts to comparing
+// the whole password, so it is done in constant time to avoid leaking how
+// many leading characters matched.
+func containsForbiddenPattern(password, pattern string) bool {
+	if len(pattern) == len(password) {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(pattern)) == 1
+	}
+	return strings.Contains(password, pattern)
+}
+
+// similarity compares a and b case-insensitively after l33t normalization:
+// 1 minus their Levenshtein distance divided by the longer length, so 1
+// means identical and 0 nothing in common.
+func similarity(a, b string) float64 {
+	a = leetNormalize(strings.ToLower(a))
+	b = leetNormalize(strings.ToLower(b))
+
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein returns the number of single-byte insertions, deletions and
+// substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func countMatches(text string, re *regexp.Regexp) int {
+	matches := re.FindAllString(text, -1)
+	return len(matches)
+}
+
+func ApplyPolicyToConfig(policy PasswordPolicy, config *Config) {
+	// Adjust length to meet minimum requirements
+	if config.Length < policy.MinLength {
+		config.Length = policy.MinLength
+	}
+
+	if policy.MaxLength > 0 && config.Length > policy.MaxLength {
+		config.Length = policy.MaxLength
+	}
+
+	// Enable required character types
+	if policy.RequireUpper {
+		config.IncludeUpper = true
+	}
+
+	if policy.RequireLower {
+		config.IncludeLower = true
+	}
+
+	if policy.RequireDigits {
+		config.IncludeDigits = true
+	}
+
+	if policy.RequireSymbols {
+		config.IncludeSymbols = true
+	}
+
+	// Apply ambiguous character exclusion
+	if policy.ExcludeAmbiguous {
+		config.ExcludeAmbiguous = true
+
+		// Exclude the policy's set on top of any custom set already chosen
+		if config.AmbiguousChars != "" || policy.AmbiguousChars != "" {
+			config.AmbiguousChars = uniqueRunes(ambiguousSet(config.AmbiguousChars) + ambiguousSet(policy.AmbiguousChars))
+		}
+	}
+
+	// Only draw symbols the policy allows
+	if policy.AllowedSymbols != "" {
+		if allowed := intersectRunes(config.AllowedSymbols, policy.AllowedSymbols); config.AllowedSymbols != "" && allowed != "" {
+			config.AllowedSymbols = allowed
+		} else {
+			config.AllowedSymbols = uniqueRunes(policy.AllowedSymbols)
+		}
+	}
+
+	// Keep the stricter repeat limit
+	if policy.MaxConsecutiveRepeat > 0 && (config.MaxRepeat == 0 || policy.MaxConsecutiveRepeat < config.MaxRepeat) {
+		config.MaxRepeat = policy.MaxConsecutiveRepeat
+	}
+
+	// Regenerate instead of emitting a password with a forbidden pattern
+	for _, pattern := range policy.ForbiddenPatterns {
+		if !slices.Contains(config.ForbiddenPatterns, pattern) {
+			config.ForbiddenPatterns = append(config.ForbiddenPatterns, pattern)
+		}
+	}
+
+	config.ForbidDictionaryWords = stricterMax(config.ForbidDictionaryWords, policy.ForbidDictionaryWords)
+	config.ForbidDates = config.ForbidDates || policy.ForbidDates
+	// Invisible characters only get into a charset with AllowControl
+	config.AllowControl = config.AllowControl && !policy.ForbidZeroWidth
+	config.ForbidRepeatedSubstrings = config.ForbidRepeatedSubstrings || policy.ForbidRepeatedSubstrings
+	config.NoRepeat = config.NoRepeat || policy.NoRepeatedChars
+	config.MaxClassRun = stricterMax(config.MaxClassRun, policy.MaxSameClassRun)
+	config.MinEntropy = max(config.MinEntropy, policy.MinEntropy)
+	config.MinDistinct = max(config.MinDistinct, policy.MinDistinctChars)
+
+	// Keep the stricter maximum character counts
+	config.MaxUpper = stricterMax(config.MaxUpper, policy.MaxUpper)
+	config.MaxLower = stricterMax(config.MaxLower, policy.MaxLower)
+	config.MaxDigits = stricterMax(config.MaxDigits, policy.MaxDigits)
+	config.MaxSymbols = stricterMax(config.MaxSymbols, policy.MaxSymbols)
+
+	// Guarantee the minimum character counts at generation time
+	config.MinUpper = max(config.MinUpper, policy.MinUpper)
+	config.MinLower = max(config.MinLower, policy.MinLower)
+	config.MinDigits = max(config.MinDigits, policy.MinDigits)
+	config.MinSymbols = max(config.MinSymbols, policy.MinSymbols)
+
+	if policy.MinCharacterClasses > 0 {
+		requireCharacterClasses(config, policy.MinCharacterClasses)
+	}
+
+	if config.MinUpper > 0 {
+		config.IncludeUpper = true
+	}
+
+	if config.MinLower > 0 {
+		config.IncludeLower = true
+	}
+
+	if config.MinDigits > 0 {
+		config.IncludeDigits = true
+	}
+
+	if config.MinSymbols > 0 {
+		config.IncludeSymbols = true
+	}
+}
+
+// requireCharacterClasses guarantees at least one character from enough
+// classes to reach n, preferring classes the config already includes and
+// otherwise enabling new ones in the order upper, lower, digits, symbols.
+func requireCharacterClasses(config *Config, n int) {
+	classes := []struct {
+		included bool
+		min      *int
+	}{
+		{config.IncludeUpper, &config.MinUpper},
+		{config.IncludeLower, &config.MinLower},
+		{config.IncludeDigits, &config.MinDigits},
+		{config.IncludeSymbols, &config.MinSymbols},
+	}
+
+	guaranteed := 0
+	for _, class := range classes {
+		if *class.min > 0 {
+			guaranteed++
+		}
+	}
+
+	for _, preferIncluded := range []bool{true, false} {
+		for _, class := range classes {
+			if guaranteed >= n {
+				return
+			}
+			if *class.min == 0 && (class.included || !preferIncluded) {
+				*class.min = 1
+				guaranteed++
+			}
+		}
+	}
+}
+
+// stricterMax returns the smaller of two limits where 0 means no limit.
+func stricterMax(a, b int) int {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
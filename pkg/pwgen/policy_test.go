@@ -0,0 +1,1221 @@
+package pwgen
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policyName string
+		wantErr    bool
+		wantMinLen int
+	}{
+		{
+			name:       "basic policy",
+			policyName: "basic",
+			wantErr:    false,
+			wantMinLen: 8,
+		},
+		{
+			name:       "corporate policy",
+			policyName: "corporate",
+			wantErr:    false,
+			wantMinLen: 12,
+		},
+		{
+			name:       "high-security policy",
+			policyName: "high-security",
+			wantErr:    false,
+			wantMinLen: 16,
+		},
+		{
+			name:       "nonexistent policy",
+			policyName: "nonexistent",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := GetPolicy(tt.policyName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && policy.MinLength != tt.wantMinLen {
+				t.Errorf("GetPolicy() MinLength = %d, want %d", policy.MinLength, tt.wantMinLen)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordAgainstPolicy(t *testing.T) {
+	basicPolicy, _ := GetPolicy("basic")
+	corporatePolicy, _ := GetPolicy("corporate")
+
+	tests := []struct {
+		name           string
+		password       string
+		policy         PasswordPolicy
+		wantViolations int
+	}{
+		{
+			name:           "valid basic password",
+			password:       "MySecure1", // Changed to avoid 'password' pattern
+			policy:         basicPolicy,
+			wantViolations: 0,
+		},
+		{
+			name:           "too short",
+			password:       "Pass1",
+			policy:         basicPolicy,
+			wantViolations: 1,
+		},
+		{
+			name:           "missing digits",
+			password:       "MySecure", // Changed to avoid 'password' pattern
+			policy:         basicPolicy,
+			wantViolations: 2, // RequireDigits and MinDigits
+		},
+		{
+			name:           "corporate policy violation",
+			password:       "weak123", // Simple password with multiple violations
+			policy:         corporatePolicy,
+			wantViolations: 7, // All the violations listed in test output
+		},
+		{
+			name:           "valid corporate password",
+			password:       "MyC2rp2r@te!Secure", // Removed ambiguous chars and 'password' pattern
+			policy:         corporatePolicy,
+			wantViolations: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidatePasswordAgainstPolicy(tt.password, tt.policy)
+			if len(violations) != tt.wantViolations {
+				t.Errorf("ValidatePasswordAgainstPolicy() violations = %d, want %d",
+					len(violations), tt.wantViolations)
+				for _, v := range violations {
+					t.Logf("  - %s: %s", v.Rule, v.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePasswordAgainstPolicyExtended(t *testing.T) {
+	policy := PasswordPolicy{
+		Name:              "Test Policy",
+		MinLength:         8,
+		MaxLength:         20,
+		RequireUpper:      true,
+		RequireLower:      true,
+		RequireDigits:     true,
+		RequireSymbols:    true,
+		MinUpper:          2,
+		MinLower:          2,
+		MinDigits:         1,
+		MinSymbols:        1,
+		ExcludeAmbiguous:  true,
+		ForbiddenChars:    "xyz",
+		ForbiddenPatterns: []string{"test"},
+		MinEntropy:        50,
+	}
+
+	tests := []struct {
+		name           string
+		password       string
+		wantViolations []string
+	}{
+		{
+			name:           "too long password",
+			password:       "ThisPasswordIsTooLongForThePolicy123!",
+			wantViolations: []string{"MaxLength"},
+		},
+		{
+			name:           "forbidden chars",
+			password:       "Password123x!",
+			wantViolations: []string{"ForbiddenChars"},
+		},
+		{
+			name:           "forbidden pattern",
+			password:       "TestPassword123!",
+			wantViolations: []string{"ForbiddenPatterns"},
+		},
+		{
+			name:           "multiple violations",
+			password:       "short",
+			wantViolations: []string{"MinLength", "RequireUpper", "RequireDigits", "RequireSymbols", "MinUpper", "MinDigits", "MinSymbols", "MinEntropy"},
+		},
+		{
+			name:           "min lower violation",
+			password:       "PASSWORD123!",
+			wantViolations: []string{"MinLower"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidatePasswordAgainstPolicy(tt.password, policy)
+
+			violationRules := make(map[string]bool)
+			for _, v := range violations {
+				violationRules[v.Rule] = true
+			}
+
+			for _, expectedRule := range tt.wantViolations {
+				if !violationRules[expectedRule] {
+					t.Errorf("Expected violation rule '%s' not found", expectedRule)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPolicyToConfig(t *testing.T) {
+	basicPolicy, _ := GetPolicy("basic")
+
+	config := Config{
+		Length:           6, // too short
+		IncludeUpper:     false,
+		IncludeLower:     true,
+		IncludeDigits:    false,
+		IncludeSymbols:   false,
+		ExcludeAmbiguous: false,
+	}
+
+	ApplyPolicyToConfig(basicPolicy, &config)
+
+	if config.Length < basicPolicy.MinLength {
+		t.Errorf("ApplyPolicyToConfig() length = %d, want >= %d", config.Length, basicPolicy.MinLength)
+	}
+
+	if !config.IncludeUpper {
+		t.Error("ApplyPolicyToConfig() should enable uppercase letters")
+	}
+
+	if !config.IncludeDigits {
+		t.Error("ApplyPolicyToConfig() should enable digits")
+	}
+}
+
+func TestApplyPolicyToConfigExtended(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         PasswordPolicy
+		initialConfig  Config
+		expectedConfig Config
+	}{
+		{
+			name: "max length constraint",
+			policy: PasswordPolicy{
+				MinLength: 8,
+				MaxLength: 12,
+			},
+			initialConfig: Config{
+				Length: 20, // too long
+			},
+			expectedConfig: Config{
+				Length: 12,
+			},
+		},
+		{
+			name: "require symbols",
+			policy: PasswordPolicy{
+				RequireSymbols: true,
+			},
+			initialConfig: Config{
+				IncludeSymbols: false,
+			},
+			expectedConfig: Config{
+				IncludeSymbols: true,
+			},
+		},
+		{
+			name: "exclude ambiguous",
+			policy: PasswordPolicy{
+				ExcludeAmbiguous: true,
+			},
+			initialConfig: Config{
+				ExcludeAmbiguous: false,
+			},
+			expectedConfig: Config{
+				ExcludeAmbiguous: true,
+			},
+		},
+		{
+			name: "no changes needed",
+			policy: PasswordPolicy{
+				MinLength:        8,
+				RequireUpper:     false,
+				RequireLower:     false,
+				RequireDigits:    false,
+				RequireSymbols:   false,
+				ExcludeAmbiguous: false,
+			},
+			initialConfig: Config{
+				Length:           10,
+				IncludeUpper:     true,
+				IncludeLower:     true,
+				IncludeDigits:    true,
+				IncludeSymbols:   true,
+				ExcludeAmbiguous: true,
+			},
+			expectedConfig: Config{
+				Length:           10,
+				IncludeUpper:     true,
+				IncludeLower:     true,
+				IncludeDigits:    true,
+				IncludeSymbols:   true,
+				ExcludeAmbiguous: true,
+			},
+		},
+		{
+			name: "all requirements",
+			policy: PasswordPolicy{
+				MinLength:        16,
+				RequireUpper:     true,
+				RequireLower:     true,
+				RequireDigits:    true,
+				RequireSymbols:   true,
+				ExcludeAmbiguous: true,
+			},
+			initialConfig: Config{
+				Length:           10,
+				IncludeUpper:     false,
+				IncludeLower:     false,
+				IncludeDigits:    false,
+				IncludeSymbols:   false,
+				ExcludeAmbiguous: false,
+			},
+			expectedConfig: Config{
+				Length:           16,
+				IncludeUpper:     true,
+				IncludeLower:     true,
+				IncludeDigits:    true,
+				IncludeSymbols:   true,
+				ExcludeAmbiguous: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.initialConfig
+			ApplyPolicyToConfig(tt.policy, &config)
+
+			if config.Length != tt.expectedConfig.Length {
+				t.Errorf("ApplyPolicyToConfig() Length = %d, want %d", config.Length, tt.expectedConfig.Length)
+			}
+			if config.IncludeUpper != tt.expectedConfig.IncludeUpper {
+				t.Errorf("ApplyPolicyToConfig() IncludeUpper = %v, want %v", config.IncludeUpper, tt.expectedConfig.IncludeUpper)
+			}
+			if config.IncludeLower != tt.expectedConfig.IncludeLower {
+				t.Errorf("ApplyPolicyToConfig() IncludeLower = %v, want %v", config.IncludeLower, tt.expectedConfig.IncludeLower)
+			}
+			if config.IncludeDigits != tt.expectedConfig.IncludeDigits {
+				t.Errorf("ApplyPolicyToConfig() IncludeDigits = %v, want %v", config.IncludeDigits, tt.expectedConfig.IncludeDigits)
+			}
+			if config.IncludeSymbols != tt.expectedConfig.IncludeSymbols {
+				t.Errorf("ApplyPolicyToConfig() IncludeSymbols = %v, want %v", config.IncludeSymbols, tt.expectedConfig.IncludeSymbols)
+			}
+			if config.ExcludeAmbiguous != tt.expectedConfig.ExcludeAmbiguous {
+				t.Errorf("ApplyPolicyToConfig() ExcludeAmbiguous = %v, want %v", config.ExcludeAmbiguous, tt.expectedConfig.ExcludeAmbiguous)
+			}
+		})
+	}
+}
+
+func TestApplyPolicyToConfigGeneratesCompliantPasswords(t *testing.T) {
+	for _, name := range []string{"corporate", "high-security"} {
+		t.Run(name, func(t *testing.T) {
+			policy, _ := GetPolicy(name)
+
+			config := Config{Length: 8, IncludeLower: true}
+			ApplyPolicyToConfig(policy, &config)
+
+			if config.MinDigits != policy.MinDigits || config.MinSymbols != policy.MinSymbols {
+				t.Fatalf("ApplyPolicyToConfig() MinDigits = %d, MinSymbols = %d, want %d, %d",
+					config.MinDigits, config.MinSymbols, policy.MinDigits, policy.MinSymbols)
+			}
+
+			for i := 0; i < 200; i++ {
+				password, err := Generate(config)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+
+				for _, violation := range ValidatePasswordAgainstPolicy(password, policy) {
+					// Entropy and forbidden patterns depend on the draw itself
+					if violation.Rule == "MinEntropy" || violation.Rule == "ForbiddenPatterns" {
+						continue
+					}
+					t.Fatalf("Generate() = %q violates %s: %s", password, violation.Rule, violation.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAgainstPolicies(t *testing.T) {
+	// Long enough for aws, but missing the corporate minimum counts
+	results := ValidateAgainstPolicies("Xk7!pQz9", []string{"aws", "corporate", "missing"})
+
+	if len(results) != 3 {
+		t.Fatalf("ValidateAgainstPolicies() returned %d results, want 3", len(results))
+	}
+
+	if violations := results["aws"]; len(violations) != 0 {
+		t.Errorf("aws violations = %v, want none", violations)
+	}
+
+	if len(results["corporate"]) == 0 {
+		t.Error("corporate should report violations")
+	}
+
+	if missing := results["missing"]; len(missing) != 1 || missing[0].Rule != "UnknownPolicy" {
+		t.Errorf("missing policy violations = %v, want a single UnknownPolicy", missing)
+	}
+}
+
+func TestPolicyAmbiguousChars(t *testing.T) {
+	policy := PasswordPolicy{ExcludeAmbiguous: true, AmbiguousChars: "5S"}
+
+	if violations := ValidatePasswordAgainstPolicy("Pass5word", policy); len(violations) != 1 || violations[0].Rule != "ExcludeAmbiguous" {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want one ExcludeAmbiguous violation", violations)
+	}
+
+	if violations := ValidatePasswordAgainstPolicy("Pass0word", policy); len(violations) != 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want 0 allowed with a custom set", violations)
+	}
+
+	config := Config{AmbiguousChars: "2Z"}
+	ApplyPolicyToConfig(policy, &config)
+	if config.AmbiguousChars != "2Z5S" {
+		t.Errorf("ApplyPolicyToConfig() AmbiguousChars = %q, want %q", config.AmbiguousChars, "2Z5S")
+	}
+}
+
+func TestPolicyMaxConsecutiveRepeat(t *testing.T) {
+	policy := PasswordPolicy{MaxConsecutiveRepeat: 1}
+
+	if violations := ValidatePasswordAgainstPolicy("abab", policy); len(violations) != 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want none", violations)
+	}
+
+	violations := ValidatePasswordAgainstPolicy("abba", policy)
+	if len(violations) != 1 || violations[0].Rule != "MaxConsecutiveRepeat" {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want one MaxConsecutiveRepeat violation", violations)
+	}
+
+	config := Config{MaxRepeat: 3}
+	ApplyPolicyToConfig(policy, &config)
+	if config.MaxRepeat != 1 {
+		t.Errorf("ApplyPolicyToConfig() MaxRepeat = %d, want the stricter 1", config.MaxRepeat)
+	}
+}
+
+func TestListPolicies(t *testing.T) {
+	policies := ListPolicies()
+
+	expectedPolicies := []string{"basic", "corporate", "high-security", "aws", "azure", "pci-dss"}
+
+	if len(policies) < len(expectedPolicies) {
+		t.Errorf("ListPolicies() returned %d policies, want at least %d", len(policies), len(expectedPolicies))
+	}
+
+	// Check if all expected policies are present
+	policyMap := make(map[string]bool)
+	for _, policy := range policies {
+		policyMap[policy] = true
+	}
+
+	for _, expected := range expectedPolicies {
+		if !policyMap[expected] {
+			t.Errorf("ListPolicies() missing expected policy: %s", expected)
+		}
+	}
+}
+
+func TestContainsForbiddenPattern(t *testing.T) {
+	tests := []struct {
+		password string
+		pattern  string
+		want     bool
+	}{
+		{"password", "password", true},  // Same length, constant-time path
+		{"passwore", "password", false}, // Same length, differs at the end
+		{"mypassword1", "password", true},
+		{"secret", "password", false},
+		{"pass", "password", false},
+	}
+
+	for _, tt := range tests {
+		if got := containsForbiddenPattern(tt.password, tt.pattern); got != tt.want {
+			t.Errorf("containsForbiddenPattern(%q, %q) = %v, want %v", tt.password, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyMaximums(t *testing.T) {
+	policy := PasswordPolicy{Name: "capped", MinSymbols: 1, MaxSymbols: 2, MaxUpper: 3}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"abc!def", nil},
+		{"abc!d@f", nil},
+		{"ab!c@d#f", []string{"MaxSymbols"}},
+		{"abcdef", []string{"MinSymbols"}},
+		{"ABCD!ef", []string{"MaxUpper"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, violation := range ValidatePasswordAgainstPolicy(tt.password, policy) {
+			got = append(got, violation.Rule)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) rules = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPolicyToConfigMaximums(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 12, MinSymbols: 1, MaxSymbols: 2, MaxDigits: 4}
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, MaxDigits: 3, MaxUpper: 5}
+
+	ApplyPolicyToConfig(policy, &config)
+
+	if config.MaxSymbols != 2 {
+		t.Errorf("ApplyPolicyToConfig() MaxSymbols = %d, want 2 from the policy", config.MaxSymbols)
+	}
+	if config.MaxDigits != 3 {
+		t.Errorf("ApplyPolicyToConfig() MaxDigits = %d, want the stricter config value 3", config.MaxDigits)
+	}
+	if config.MaxUpper != 5 {
+		t.Errorf("ApplyPolicyToConfig() MaxUpper = %d, want 5 kept from the config", config.MaxUpper)
+	}
+
+	for i := 0; i < 50; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+			t.Fatalf("Generate() = %q violates the policy: %v", password, violations)
+		}
+	}
+}
+
+func TestMergePolicies(t *testing.T) {
+	merged, err := MergePolicies([]string{"aws", "azure", "corporate"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+
+	if merged.MinLength != 12 {
+		t.Errorf("MergePolicies() MinLength = %d, want 12 from corporate", merged.MinLength)
+	}
+	if merged.MaxLength != 128 {
+		t.Errorf("MergePolicies() MaxLength = %d, want 128 from aws", merged.MaxLength)
+	}
+	if !merged.RequireUpper || !merged.RequireLower || !merged.RequireDigits || !merged.RequireSymbols {
+		t.Error("MergePolicies() should require every character type")
+	}
+	if merged.MinUpper != 2 || merged.MinDigits != 2 || merged.MinSymbols != 1 {
+		t.Errorf("MergePolicies() minimums = %d/%d/%d, want 2/2/1 from corporate", merged.MinUpper, merged.MinDigits, merged.MinSymbols)
+	}
+	if !merged.ExcludeAmbiguous || merged.AmbiguousChars != "" {
+		t.Errorf("MergePolicies() ExcludeAmbiguous = %v, AmbiguousChars = %q, want true and the default set", merged.ExcludeAmbiguous, merged.AmbiguousChars)
+	}
+	if merged.MinEntropy != 40 {
+		t.Errorf("MergePolicies() MinEntropy = %.1f, want 40 from corporate", merged.MinEntropy)
+	}
+	if !reflect.DeepEqual(merged.ForbiddenPatterns, BuiltinPolicies["corporate"].ForbiddenPatterns) {
+		t.Errorf("MergePolicies() ForbiddenPatterns = %v, want those of corporate", merged.ForbiddenPatterns)
+	}
+
+	config := Config{Length: 8, IncludeLower: true}
+	ApplyPolicyToConfig(merged, &config)
+	for i := 0; i < 50; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		for name, violations := range ValidateAgainstPolicies(password, []string{"aws", "azure", "corporate"}) {
+			if len(violations) > 0 {
+				t.Fatalf("Generate() = %q violates %s: %v", password, name, violations)
+			}
+		}
+	}
+}
+
+func TestMergePoliciesSingle(t *testing.T) {
+	merged, err := MergePolicies([]string{"basic"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+	if !reflect.DeepEqual(merged, BuiltinPolicies["basic"]) {
+		t.Errorf("MergePolicies() of one policy = %+v, want it unchanged", merged)
+	}
+}
+
+func TestMergePoliciesUnions(t *testing.T) {
+	BuiltinPolicies["test-a"] = PasswordPolicy{Name: "A", ExcludeAmbiguous: true, AmbiguousChars: "5S", ForbiddenChars: "'\"", ForbiddenPatterns: []string{"acme"}, MaxConsecutiveRepeat: 3, MaxSymbols: 4}
+	BuiltinPolicies["test-b"] = PasswordPolicy{Name: "B", ExcludeAmbiguous: true, ForbiddenChars: "\"\\", ForbiddenPatterns: []string{"acme", "admin"}, MaxConsecutiveRepeat: 2}
+	t.Cleanup(func() {
+		delete(BuiltinPolicies, "test-a")
+		delete(BuiltinPolicies, "test-b")
+	})
+
+	merged, err := MergePolicies([]string{"test-a", "test-b"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+
+	if merged.Name != "A + B" {
+		t.Errorf("MergePolicies() Name = %q, want %q", merged.Name, "A + B")
+	}
+	if merged.AmbiguousChars != "5S"+Ambiguous {
+		t.Errorf("MergePolicies() AmbiguousChars = %q, want %q", merged.AmbiguousChars, "5S"+Ambiguous)
+	}
+	if merged.ForbiddenChars != "'\"\\" {
+		t.Errorf("MergePolicies() ForbiddenChars = %q, want %q", merged.ForbiddenChars, "'\"\\")
+	}
+	if !reflect.DeepEqual(merged.ForbiddenPatterns, []string{"acme", "admin"}) {
+		t.Errorf("MergePolicies() ForbiddenPatterns = %v, want [acme admin]", merged.ForbiddenPatterns)
+	}
+	if merged.MaxConsecutiveRepeat != 2 || merged.MaxSymbols != 4 {
+		t.Errorf("MergePolicies() MaxConsecutiveRepeat = %d, MaxSymbols = %d, want 2 and 4", merged.MaxConsecutiveRepeat, merged.MaxSymbols)
+	}
+}
+
+func TestMergePoliciesErrors(t *testing.T) {
+	BuiltinPolicies["test-long"] = PasswordPolicy{Name: "Long", MinLength: 20}
+	BuiltinPolicies["test-short"] = PasswordPolicy{Name: "Short", MaxLength: 16}
+	BuiltinPolicies["test-symbols"] = PasswordPolicy{Name: "Few symbols", MaxSymbols: 1}
+	BuiltinPolicies["test-counts"] = PasswordPolicy{Name: "Counts", MinUpper: 5, MinLower: 5, MinDigits: 5, MinSymbols: 5}
+	t.Cleanup(func() {
+		delete(BuiltinPolicies, "test-long")
+		delete(BuiltinPolicies, "test-short")
+		delete(BuiltinPolicies, "test-symbols")
+		delete(BuiltinPolicies, "test-counts")
+	})
+
+	tests := []struct {
+		name  string
+		names []string
+	}{
+		{"empty", nil},
+		{"unknown policy", []string{"aws", "nonexistent"}},
+		{"min length above max length", []string{"test-long", "test-short"}},
+		{"min symbols above max symbols", []string{"high-security", "test-symbols"}},
+		{"minimums above max length", []string{"test-counts", "test-short"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MergePolicies(tt.names); err == nil {
+				t.Errorf("MergePolicies(%v) should fail", tt.names)
+			}
+		})
+	}
+}
+
+func TestPolicyMinCharacterClasses(t *testing.T) {
+	policy := PasswordPolicy{Name: "3 of 4", MinCharacterClasses: 3}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"abcDEF123", nil},
+		{"abcDEF!!!", nil},
+		{"abc123!!!", nil},
+		{"ABC123!!!", nil},
+		{"aB3!", nil},
+		{"abcDEFghi", []string{"MinCharacterClasses"}},
+		{"abc123456", []string{"MinCharacterClasses"}},
+		{"!!!!!!!!!", []string{"MinCharacterClasses"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, violation := range ValidatePasswordAgainstPolicy(tt.password, policy) {
+			got = append(got, violation.Rule)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) rules = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPolicyToConfigMinCharacterClasses(t *testing.T) {
+	policy := PasswordPolicy{Name: "3 of 4", MinLength: 8, MinCharacterClasses: 3}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   [4]int // MinUpper, MinLower, MinDigits, MinSymbols
+	}{
+		{
+			name:   "prefers included classes",
+			config: Config{Length: 12, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, IncludeSymbols: true},
+			want:   [4]int{1, 1, 1, 0},
+		},
+		{
+			name:   "enables missing classes",
+			config: Config{Length: 12, IncludeLower: true},
+			want:   [4]int{1, 1, 1, 0},
+		},
+		{
+			name:   "keeps existing minimums",
+			config: Config{Length: 12, IncludeLower: true, IncludeSymbols: true, MinSymbols: 2},
+			want:   [4]int{1, 1, 0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			ApplyPolicyToConfig(policy, &config)
+
+			got := [4]int{config.MinUpper, config.MinLower, config.MinDigits, config.MinSymbols}
+			if got != tt.want {
+				t.Fatalf("ApplyPolicyToConfig() minimums = %v, want %v", got, tt.want)
+			}
+
+			for i := 0; i < 50; i++ {
+				password, err := Generate(config)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+				if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+					t.Fatalf("Generate() = %q violates the policy: %v", password, violations)
+				}
+			}
+		})
+	}
+}
+
+func TestMergePoliciesMinCharacterClasses(t *testing.T) {
+	BuiltinPolicies["test-three-of-four"] = PasswordPolicy{Name: "Three of four", MinCharacterClasses: 3}
+	BuiltinPolicies["test-five-classes"] = PasswordPolicy{Name: "Five classes", MinCharacterClasses: 5}
+	t.Cleanup(func() {
+		delete(BuiltinPolicies, "test-three-of-four")
+		delete(BuiltinPolicies, "test-five-classes")
+	})
+
+	merged, err := MergePolicies([]string{"basic", "test-three-of-four"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+	if merged.MinCharacterClasses != 3 {
+		t.Errorf("MergePolicies() MinCharacterClasses = %d, want 3", merged.MinCharacterClasses)
+	}
+
+	if _, err := MergePolicies([]string{"basic", "test-five-classes"}); err == nil {
+		t.Error("MergePolicies() should fail when more than 4 character classes are required")
+	}
+}
+
+func TestApplyPolicyToConfigForbiddenPatterns(t *testing.T) {
+	policy := PasswordPolicy{ForbiddenPatterns: []string{"admin", "qwerty"}}
+	config := Config{Length: 12, IncludeLower: true, ForbiddenPatterns: []string{"admin"}}
+
+	ApplyPolicyToConfig(policy, &config)
+
+	if !reflect.DeepEqual(config.ForbiddenPatterns, []string{"admin", "qwerty"}) {
+		t.Errorf("ApplyPolicyToConfig() ForbiddenPatterns = %v, want [admin qwerty]", config.ForbiddenPatterns)
+	}
+}
+
+func TestPolicyMaxSimilarityToForbidden(t *testing.T) {
+	policy := PasswordPolicy{ForbiddenPatterns: []string{"password"}, MaxSimilarityToForbidden: 0.75}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"passw0rd", []string{"MaxSimilarityToForbidden"}},
+		{"Pa$$w0rd1", []string{"MaxSimilarityToForbidden"}},
+		{"pasword", []string{"MaxSimilarityToForbidden"}},
+		{"mypassword", []string{"ForbiddenPatterns"}}, // Exact matches are reported once
+		{"xK9#mP2$vL7@", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, violation := range ValidatePasswordAgainstPolicy(tt.password, policy) {
+			got = append(got, violation.Rule)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) rules = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	// Without a threshold only exact substrings are forbidden
+	policy.MaxSimilarityToForbidden = 0
+	if violations := ValidatePasswordAgainstPolicy("passw0rd", policy); len(violations) != 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy(passw0rd) = %v without MaxSimilarityToForbidden, want none", violations)
+	}
+}
+
+func TestPolicyForbidDictionaryWords(t *testing.T) {
+	policy := PasswordPolicy{ForbidDictionaryWords: 5}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"xK9#mP2$vL7@", nil},
+		{"9rain!Kx", nil}, // "rain" is shorter than 5 letters
+		{"9House!Kx", []string{"Password must not contain the dictionary word 'house'"}},
+		{"r1v3r#Q", []string{"Password must not contain the dictionary word 'river'"}},
+		{"house-river", []string{
+			"Password must not contain the dictionary word 'house'",
+			"Password must not contain the dictionary word 'river'",
+		}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, violation := range ValidatePasswordAgainstPolicy(tt.password, policy) {
+			if violation.Rule != "ForbidDictionaryWords" {
+				t.Errorf("ValidatePasswordAgainstPolicy(%q) reported rule %s", tt.password, violation.Rule)
+			}
+			got = append(got, violation.Description)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	policy.ForbidDictionaryWords = 4
+	if violations := ValidatePasswordAgainstPolicy("9rain!Kx", policy); len(violations) != 1 {
+		t.Errorf("ValidatePasswordAgainstPolicy(9rain!Kx) = %v with 4 letter words forbidden, want one violation", violations)
+	}
+}
+
+func TestApplyPolicyToConfigForbidDictionaryWords(t *testing.T) {
+	policy := PasswordPolicy{ForbidDictionaryWords: 4}
+
+	// About 2% of such short passwords from common letters contain a word,
+	// so 500 of them would fail without regeneration
+	config := Config{Length: 6, Charset: "aehinorst"}
+	ApplyPolicyToConfig(policy, &config)
+	if config.ForbidDictionaryWords != 4 {
+		t.Fatalf("ApplyPolicyToConfig() ForbidDictionaryWords = %d, want 4", config.ForbidDictionaryWords)
+	}
+
+	for i := 0; i < 500; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+			t.Fatalf("Generate() = %q violates the policy: %v", password, violations)
+		}
+	}
+
+	// A stricter minimum from the config is kept
+	config.ForbidDictionaryWords = 3
+	ApplyPolicyToConfig(PasswordPolicy{ForbidDictionaryWords: 6}, &config)
+	if config.ForbidDictionaryWords != 3 {
+		t.Errorf("ApplyPolicyToConfig() ForbidDictionaryWords = %d, want the stricter 3", config.ForbidDictionaryWords)
+	}
+}
+
+func TestApplyPolicyToConfigMinEntropy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, RequireLower: true, RequireDigits: true, MinEntropy: 50}
+
+	// 10 letters and digits give at most 10 × log2(36) = 51.7 bits, and only
+	// when both classes appear without a penalized pattern
+	config := Config{}
+	ApplyPolicyToConfig(policy, &config)
+	if config.MinEntropy != 50 {
+		t.Fatalf("ApplyPolicyToConfig() MinEntropy = %.1f, want 50", config.MinEntropy)
+	}
+
+	generator := NewSeededGenerator("policy entropy")
+	for i := 0; i < 200; i++ {
+		password, err := generator.Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+			t.Fatalf("Generate() = %q violates the policy: %v", password, violations)
+		}
+	}
+
+	// Without the loop some of the same passwords would fail
+	config.MinEntropy = 0
+	failures := 0
+	for i := 0; i < 200; i++ {
+		password, err := generator.Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if calculateEntropy(password) < policy.MinEntropy {
+			failures++
+		}
+	}
+	if failures == 0 {
+		t.Error("Generate() without MinEntropy never fell short, so the test does not exercise regeneration")
+	}
+
+	// A stricter minimum from the config is kept
+	config.MinEntropy = 55
+	ApplyPolicyToConfig(PasswordPolicy{MinEntropy: 40}, &config)
+	if config.MinEntropy != 55 {
+		t.Errorf("ApplyPolicyToConfig() MinEntropy = %.1f, want the stricter 55", config.MinEntropy)
+	}
+}
+
+func TestValidateConfigMinEntropy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"negative", Config{Length: 8, IncludeLower: true, MinEntropy: -1}, "must not be negative"},
+		{"unreachable", Config{Length: 8, IncludeDigits: true, MinEntropy: 40}, "unreachable: 8 characters from this charset give at most 26.6 bits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	// Out of reach of the charset size, but not of the class sizes the
+	// estimate credits
+	if err := ValidateConfig(Config{Length: 8, Charset: "abc123", MinEntropy: 40}); err != nil {
+		t.Errorf("ValidateConfig() error = %v, want none", err)
+	}
+}
+
+func TestPolicyForbidDates(t *testing.T) {
+	policy := PasswordPolicy{ForbidDates: true}
+
+	for _, password := range []string{"Summer2024!", "01011990", "Xq!october9"} {
+		violations := ValidatePasswordAgainstPolicy(password, policy)
+		if len(violations) != 1 || violations[0].Rule != "ForbidDates" {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want a ForbidDates violation", password, violations)
+		}
+	}
+	if violations := ValidatePasswordAgainstPolicy("2x4y", policy); len(violations) > 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy(2x4y) = %v, want no violation", violations)
+	}
+
+	// Four digits are a year 2 times in 100, so 500 passwords would
+	// contain some without regeneration
+	config := Config{Length: 4, IncludeDigits: true}
+	ApplyPolicyToConfig(policy, &config)
+	for i := 0; i < 500; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if hasDatePattern(password) {
+			t.Fatalf("Generate() = %q contains a date", password)
+		}
+	}
+}
+
+func TestPolicyForbidRepeatedSubstrings(t *testing.T) {
+	policy := PasswordPolicy{ForbidRepeatedSubstrings: true}
+
+	for _, password := range []string{"abcabc", "xyxyxy", "K9!K9!K9!"} {
+		violations := ValidatePasswordAgainstPolicy(password, policy)
+		if len(violations) != 1 || violations[0].Rule != "ForbidRepeatedSubstrings" {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want a ForbidRepeatedSubstrings violation", password, violations)
+		}
+	}
+	if violations := ValidatePasswordAgainstPolicy("abcdef", policy); len(violations) > 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy(abcdef) = %v, want no violation", violations)
+	}
+
+	// Six characters from two are "ababab" or "bababa" 2 times in 64, so
+	// 500 passwords would contain some without regeneration
+	config := Config{Length: 6, Charset: "ab"}
+	ApplyPolicyToConfig(policy, &config)
+	for i := 0; i < 500; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if hasRepeatedSubstrings(password) {
+			t.Fatalf("Generate() = %q contains a repeated substring", password)
+		}
+	}
+}
+
+func TestMergePoliciesForbidDictionaryWords(t *testing.T) {
+	BuiltinPolicies["test-no-long-words"] = PasswordPolicy{Name: "No long words", ForbidDictionaryWords: 6}
+	BuiltinPolicies["test-no-words"] = PasswordPolicy{Name: "No words", ForbidDictionaryWords: 4}
+	BuiltinPolicies["test-negative-words"] = PasswordPolicy{Name: "Negative", ForbidDictionaryWords: -1}
+	t.Cleanup(func() {
+		delete(BuiltinPolicies, "test-no-long-words")
+		delete(BuiltinPolicies, "test-no-words")
+		delete(BuiltinPolicies, "test-negative-words")
+	})
+
+	merged, err := MergePolicies([]string{"test-no-long-words", "basic", "test-no-words"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+	if merged.ForbidDictionaryWords != 4 {
+		t.Errorf("MergePolicies() ForbidDictionaryWords = %d, want the stricter 4", merged.ForbidDictionaryWords)
+	}
+
+	if _, err := MergePolicies([]string{"basic", "test-negative-words"}); err == nil {
+		t.Error("MergePolicies() should reject a negative ForbidDictionaryWords")
+	}
+}
+
+func TestPolicyAllowedSymbols(t *testing.T) {
+	policy := PasswordPolicy{AllowedSymbols: "!@#$"}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"Abc123", nil},
+		{"Abc!@#123", nil},
+		{"Abc<1>!<", []string{
+			"Password must not contain the symbol '<', only !@#$ are allowed",
+			"Password must not contain the symbol '>', only !@#$ are allowed",
+		}},
+		{"é!", []string{"Password must not contain the symbol 'é', only !@#$ are allowed"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, violation := range ValidatePasswordAgainstPolicy(tt.password, policy) {
+			if violation.Rule != "AllowedSymbols" {
+				t.Errorf("ValidatePasswordAgainstPolicy(%q) reported rule %s", tt.password, violation.Rule)
+			}
+			got = append(got, violation.Description)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPolicyToConfigAllowedSymbols(t *testing.T) {
+	policy, _ := GetPolicy("aws")
+
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, SymbolSet: "all"}
+	ApplyPolicyToConfig(policy, &config)
+
+	for _, char := range Charset(config) {
+		if isSymbol(char) && !strings.ContainsRune(policy.AllowedSymbols, char) {
+			t.Errorf("Charset() contains %q, which the aws policy does not allow", char)
+		}
+	}
+	if !HasExactCharset(config) {
+		t.Error("HasExactCharset() = false, want the restricted symbols to be counted exactly")
+	}
+
+	for i := 0; i < 200; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if violations := ValidatePasswordAgainstPolicy(password, policy); len(violations) > 0 {
+			t.Fatalf("Generate() = %q violates the policy: %v", password, violations)
+		}
+	}
+
+	// A whitelist already in the config is narrowed to the shared symbols
+	config.AllowedSymbols = "!?#"
+	ApplyPolicyToConfig(PasswordPolicy{AllowedSymbols: "#!$"}, &config)
+	if config.AllowedSymbols != "!#" {
+		t.Errorf("ApplyPolicyToConfig() AllowedSymbols = %q, want the shared !#", config.AllowedSymbols)
+	}
+}
+
+func TestMergePoliciesAllowedSymbols(t *testing.T) {
+	BuiltinPolicies["test-brackets"] = PasswordPolicy{Name: "Brackets", AllowedSymbols: "[]{}<>"}
+	BuiltinPolicies["test-quotes"] = PasswordPolicy{Name: "Quotes", AllowedSymbols: "'\"`"}
+	t.Cleanup(func() {
+		delete(BuiltinPolicies, "test-brackets")
+		delete(BuiltinPolicies, "test-quotes")
+	})
+
+	merged, err := MergePolicies([]string{"aws", "basic", "azure"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+	if merged.AllowedSymbols != "!@#$%^&*()_+-=[]{}|'" {
+		t.Errorf("MergePolicies() AllowedSymbols = %q, want the symbols allowed by both aws and azure", merged.AllowedSymbols)
+	}
+
+	merged, err = MergePolicies([]string{"aws", "test-brackets"})
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+	if merged.AllowedSymbols != "[]{}" {
+		t.Errorf("MergePolicies() AllowedSymbols = %q, want []{}", merged.AllowedSymbols)
+	}
+
+	if _, err := MergePolicies([]string{"test-brackets", "test-quotes"}); err == nil {
+		t.Error("MergePolicies() should reject policies that share no allowed symbol")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"passw0rd", "password", 1},
+		{"PASSWORD", "password", 1},
+		{"passwor", "password", 0.875},
+		{"abc", "xyz", 0},
+		{"", "", 1},
+	}
+
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"flaw", "lawn", 2},
+		{"same", "same", 0},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyLengthCountsRunes(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 10}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"éééééé", []string{"MinLength"}}, // 6 characters in 12 bytes
+		{"éééééééééé", nil},               // 10 characters in 20 bytes
+		{"€€€€€€€€€€€", []string{"MaxLength"}},
+		// Combining acute accents attach to the letter before them
+		{strings.Repeat("e\u0301", 6), []string{"MinLength"}},
+		{strings.Repeat("e\u0301", 10), nil},
+		{"\u0301" + strings.Repeat("x", 7), nil}, // A leading mark has nothing to attach to
+		// Zero-width spaces and joiners do not count at all
+		{"abcd\u200b\u200b\u200b\u200b", []string{"MinLength"}},
+		{"abcdefgh\u200d\u200d\u200d", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, violation := range ValidatePasswordAgainstPolicy(tt.password, policy) {
+			got = append(got, violation.Rule)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) rules = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyLengthExplainsUncountedChars(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8}
+
+	violations := ValidatePasswordAgainstPolicy("abc\u200bdef\u200b", policy)
+	if len(violations) != 1 || !strings.Contains(violations[0].Description, "(found 6; combining marks and invisible characters are not counted)") {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want the MinLength violation to explain the count", violations)
+	}
+
+	violations = ValidatePasswordAgainstPolicy("abcdef", policy)
+	if len(violations) != 1 || strings.Contains(violations[0].Description, "not counted") {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want no explanation when every character counts", violations)
+	}
+}
+
+func TestPolicyForbidZeroWidth(t *testing.T) {
+	policy := PasswordPolicy{ForbidZeroWidth: true}
+
+	tests := []struct {
+		password string
+		found    string // Empty when the password passes
+	}{
+		{"Tr0ub4dor&3", ""},
+		{"Cafe\u0301-latte", ""}, // Combining marks are visible
+		{"pass\u200bword", "U+200B"},
+		{"\ufeffsecret\u200dkey\u200d", "U+FEFF, U+200D"},
+		{"admin\u00ad1", "U+00AD"}, // Soft hyphen
+		{"\u202eevil", "U+202E"},   // Right-to-left override
+		{"heart\u2764\ufe0f", "U+FE0F"},
+	}
+
+	for _, tt := range tests {
+		violations := ValidatePasswordAgainstPolicy(tt.password, policy)
+		if tt.found == "" {
+			if len(violations) > 0 {
+				t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want no violation", tt.password, violations)
+			}
+			continue
+		}
+		if len(violations) != 1 || violations[0].Rule != "ForbidZeroWidth" || !strings.HasSuffix(violations[0].Description, "found "+tt.found) {
+			t.Errorf("ValidatePasswordAgainstPolicy(%q) = %v, want a ForbidZeroWidth violation naming %s", tt.password, violations, tt.found)
+		}
+	}
+
+	if violations := ValidatePasswordAgainstPolicy("pass\u200bword", PasswordPolicy{}); len(violations) > 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want invisible characters allowed by default", violations)
+	}
+
+	BuiltinPolicies["test-zero-width"] = PasswordPolicy{Name: "Zero Width", ForbidZeroWidth: true}
+	t.Cleanup(func() { delete(BuiltinPolicies, "test-zero-width") })
+	merged, err := MergePolicies([]string{"corporate", "test-zero-width"})
+	if err != nil || !merged.ForbidZeroWidth {
+		t.Errorf("MergePolicies() = %+v, %v, want ForbidZeroWidth from either policy", merged, err)
+	}
+
+	// A charset of invisible characters is only accepted with AllowControl,
+	// which the policy turns off
+	config := Config{Length: 8, Charset: "ab\u200b", AllowControl: true}
+	ApplyPolicyToConfig(policy, &config)
+	if config.AllowControl {
+		t.Error("ApplyPolicyToConfig() should turn off AllowControl")
+	}
+	if err := ValidateConfig(config); err == nil {
+		t.Error("ValidateConfig() should reject a charset with a zero-width space once the policy applies")
+	}
+}
+
+func BenchmarkValidatePasswordAgainstPolicy(b *testing.B) {
+	policy, _ := GetPolicy("corporate")
+
+	for b.Loop() {
+		ValidatePasswordAgainstPolicy("C0mpl3x!P@ssw0rd#7391", policy)
+	}
+}
@@ -0,0 +1,159 @@
+package pwgen
+
+import (
+	"math"
+	"strings"
+)
+
+// PolicyAwareEntropy estimates the entropy in bits of a password of the
+// given length drawn from charset, for an attacker who knows it complies
+// with policy and so never tries a candidate that does not: log2 of the
+// number of compliant strings. An empty charset means every ASCII letter,
+// digit and punctuation character.
+//
+// The policy's forbidden and ambiguous characters and disallowed symbols
+// are removed from charset, and its class rules (required classes, minimum
+// and maximum counts, minimum number of classes and no repeated characters)
+// are counted exactly. Rules on content such as forbidden patterns,
+// dictionary words, dates, repeats and class runs rule out only a small
+// share of random strings and are left out, so the count is approximated
+// from above. It returns 0 when no string of that length complies.
+func PolicyAwareEntropy(length int, charset string, policy PasswordPolicy) float64 {
+	if length < 1 || length < policy.MinLength || (policy.MaxLength > 0 && length > policy.MaxLength) {
+		return 0
+	}
+	if charset == "" {
+		charset = UpperCase + LowerCase + Digits + SymbolSets["all"]
+	}
+
+	config := Config{
+		Length:           length,
+		Charset:          charset,
+		ExcludeAmbiguous: policy.ExcludeAmbiguous,
+		AmbiguousChars:   policy.AmbiguousChars,
+		ExcludeChars:     policy.ForbiddenChars,
+		AllowedSymbols:   policy.AllowedSymbols,
+		MinUpper:         max(policy.MinUpper, boolCount(policy.RequireUpper)),
+		MinLower:         max(policy.MinLower, boolCount(policy.RequireLower)),
+		MinDigits:        max(policy.MinDigits, boolCount(policy.RequireDigits)),
+		MinSymbols:       max(policy.MinSymbols, boolCount(policy.RequireSymbols)),
+		MaxUpper:         policy.MaxUpper,
+		MaxLower:         policy.MaxLower,
+		MaxDigits:        policy.MaxDigits,
+		MaxSymbols:       policy.MaxSymbols,
+	}
+
+	// The compliant strings with counts c1..c4 of the four classes number
+	// length!/(c1!...c4!) × size1^c1 × ... × size4^c4, or with falling
+	// factorials of the sizes without repeats. Sum them over the allowed
+	// counts by multiplying one series per class, tracking how many
+	// classes are used for min_character_classes. Everything is in log2
+	// so long passwords do not overflow.
+	ways := make([][]float64, 5) // ways[classes][n] for n characters from that many classes
+	for classes := range ways {
+		ways[classes] = make([]float64, length+1)
+		for n := range ways[classes] {
+			ways[classes][n] = math.Inf(-1)
+		}
+	}
+	ways[0][0] = 0
+
+	for _, class := range classLimits(config) {
+		size := len([]rune(class.chars))
+		most := length
+		if class.max > 0 {
+			most = min(most, class.max)
+		}
+		if policy.NoRepeatedChars {
+			most = min(most, size)
+		}
+		if size == 0 {
+			most = 0
+		}
+
+		next := make([][]float64, 5)
+		for classes := range next {
+			next[classes] = make([]float64, length+1)
+			for n := range next[classes] {
+				next[classes][n] = math.Inf(-1)
+			}
+		}
+
+		for count := class.min; count <= most; count++ {
+			term := 0.0
+			if count > 0 && policy.NoRepeatedChars {
+				term = log2Factorial(size) - log2Factorial(size-count) - log2Factorial(count)
+			} else if count > 0 {
+				term = float64(count)*math.Log2(float64(size)) - log2Factorial(count)
+			}
+
+			used := boolCount(count > 0)
+			for classes := 0; classes+used < len(ways); classes++ {
+				for n := 0; n+count <= length; n++ {
+					if !math.IsInf(ways[classes][n], -1) {
+						next[classes+used][n+count] = log2Add(next[classes+used][n+count], ways[classes][n]+term)
+					}
+				}
+			}
+		}
+		ways = next
+	}
+
+	total := math.Inf(-1)
+	for classes := max(policy.MinCharacterClasses, 0); classes < len(ways); classes++ {
+		total = log2Add(total, ways[classes][length])
+	}
+	if math.IsInf(total, -1) {
+		return 0
+	}
+	return total + log2Factorial(length)
+}
+
+// policyAwareCharset is the charset for PolicyAwareEntropy when analyzing
+// password: charset when it is known, otherwise every class present in
+// password, with the symbols classCharSpace counts.
+func policyAwareCharset(password, charset string) string {
+	if charset != "" {
+		return charset
+	}
+
+	var classes strings.Builder
+	if lowerPattern.MatchString(password) {
+		classes.WriteString(LowerCase)
+	}
+	if upperPattern.MatchString(password) {
+		classes.WriteString(UpperCase)
+	}
+	if digitPattern.MatchString(password) {
+		classes.WriteString(Digits)
+	}
+	if symbolPattern.MatchString(password) {
+		classes.WriteString(SymbolSets["all"])
+	}
+	return classes.String()
+}
+
+// log2Factorial returns log2(n!).
+func log2Factorial(n int) float64 {
+	value, _ := math.Lgamma(float64(n) + 1)
+	return value / math.Ln2
+}
+
+// log2Add returns log2(2^a + 2^b) without leaving log space.
+func log2Add(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	return a + math.Log2(1+math.Exp2(b-a))
+}
+
+// boolCount is 1 when b is set, for counting conditions.
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package pwgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPolicyAwareEntropyCounts(t *testing.T) {
+	const charset = "abAB12!?"
+	policies := map[string]PasswordPolicy{
+		"none":          {},
+		"class rules":   {RequireUpper: true, MinDigits: 1, MaxSymbols: 1},
+		"three classes": {MinCharacterClasses: 3},
+		"no repeats":    {RequireLower: true, NoRepeatedChars: true},
+		"forbidden":     {ForbiddenChars: "a", AllowedSymbols: "!", RequireSymbols: true},
+		"unsatisfiable": {MinDigits: 3},
+	}
+
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			// Count the compliant strings of length 4 one by one
+			compliant := 0
+			var candidate [4]rune
+			var enumerate func(position int)
+			enumerate = func(position int) {
+				if position == len(candidate) {
+					if len(ValidatePasswordAgainstPolicy(string(candidate[:]), policy)) == 0 {
+						compliant++
+					}
+					return
+				}
+				for _, char := range charset {
+					candidate[position] = char
+					enumerate(position + 1)
+				}
+			}
+			enumerate(0)
+
+			want := 0.0
+			if compliant > 0 {
+				want = math.Log2(float64(compliant))
+			}
+			if got := PolicyAwareEntropy(4, charset, policy); math.Abs(got-want) > 1e-9 {
+				t.Errorf("PolicyAwareEntropy() = %.6f, want log2(%d) = %.6f", got, compliant, want)
+			}
+		})
+	}
+}
+
+func TestPolicyAwareEntropy(t *testing.T) {
+	if got, want := PolicyAwareEntropy(8, Digits, PasswordPolicy{}), 8*math.Log2(10); math.Abs(got-want) > 1e-9 {
+		t.Errorf("PolicyAwareEntropy() without rules = %.3f, want %.3f", got, want)
+	}
+
+	aws := BuiltinPolicies["aws"]
+	for _, length := range []int{aws.MinLength - 1, aws.MaxLength + 1} {
+		if got := PolicyAwareEntropy(length, "", aws); got != 0 {
+			t.Errorf("PolicyAwareEntropy(%d) = %.1f, want 0 outside the policy's lengths", length, got)
+		}
+	}
+
+	// Long passwords stay finite
+	if got := PolicyAwareEntropy(1000, "", PasswordPolicy{MinCharacterClasses: 4}); math.IsInf(got, 0) || math.IsNaN(got) || got <= 6000 {
+		t.Errorf("PolicyAwareEntropy(1000) = %v, want about 1000 × log2(94) bits", got)
+	}
+}
+
+func TestAnalyzePolicyAwareEntropy(t *testing.T) {
+	const password = "Xk9#mP2@qR5!vL7z"
+	policy := BuiltinPolicies["corporate"]
+
+	plain := AnalyzePasswordStrengthWithOptions(password, AnalysisOptions{})
+	if plain.PolicyAwareEntropy != 0 || plain.PolicyAwareTimeToCrack != "" {
+		t.Errorf("PolicyAwareEntropy = %.1f without a policy, want it unset", plain.PolicyAwareEntropy)
+	}
+
+	strength := AnalyzePasswordStrengthWithOptions(password, AnalysisOptions{Policy: &policy})
+	if strength.PolicyAwareEntropy <= 0 || strength.PolicyAwareEntropy >= strength.Entropy || strength.PolicyAwareTimeToCrack == "" {
+		t.Errorf("PolicyAwareEntropy = %.1f, Entropy = %.1f, want a smaller positive estimate", strength.PolicyAwareEntropy, strength.Entropy)
+	}
+}
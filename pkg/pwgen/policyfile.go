@@ -0,0 +1,98 @@
+package pwgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParsePolicy decodes a policy from YAML using the keys of the
+// PasswordPolicy yaml tags. Unknown keys are an error, so a misspelled rule
+// is not silently ignored. The policy itself is not checked, see
+// CheckPolicy.
+func ParsePolicy(data []byte) (PasswordPolicy, error) {
+	var policy PasswordPolicy
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		if errors.Is(err, io.EOF) {
+			return PasswordPolicy{}, fmt.Errorf("policy file is empty")
+		}
+		return PasswordPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// LoadPolicyFile reads a policy from a YAML file and rejects it with every
+// problem CheckPolicy finds.
+func LoadPolicyFile(path string) (PasswordPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PasswordPolicy{}, err
+	}
+
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return PasswordPolicy{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if problems := CheckPolicy(policy); len(problems) > 0 {
+		return PasswordPolicy{}, fmt.Errorf("%s: %w", path, errors.Join(problems...))
+	}
+
+	return policy, nil
+}
+
+// PolicyFile is a policy loaded by LoadPolicyDir, with the key it is
+// selected by: its file name without the .yaml or .yml extension.
+type PolicyFile struct {
+	Key    string
+	Path   string
+	Policy PasswordPolicy
+}
+
+// LoadPolicyDir loads every .yaml and .yml file in dir with LoadPolicyFile,
+// in file name order. A file that fails to load is left out and its error
+// returned in skipped, so one broken policy does not hide the others. err
+// is only set when dir itself cannot be read.
+func LoadPolicyDir(dir string) (policies []PolicyFile, skipped []error, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		policy, err := LoadPolicyFile(path)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+
+		policies = append(policies, PolicyFile{
+			Key:    strings.TrimSuffix(name, filepath.Ext(name)),
+			Path:   path,
+			Policy: policy,
+		})
+	}
+
+	return policies, skipped, nil
+}
@@ -0,0 +1,177 @@
+package pwgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	data := []byte(`name: Team policy
+description: For the build servers
+min_length: 14
+require_upper: true
+min_digits: 2
+forbidden_patterns: [acme, build]
+min_entropy: 60
+`)
+
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	want := PasswordPolicy{
+		Name:              "Team policy",
+		Description:       "For the build servers",
+		MinLength:         14,
+		RequireUpper:      true,
+		MinDigits:         2,
+		ForbiddenPatterns: []string{"acme", "build"},
+		MinEntropy:        60,
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("ParsePolicy() = %+v, want %+v", policy, want)
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"unknown key", "name: Typo\nmin_lenght: 12\n", "min_lenght"},
+		{"wrong type", "name: Types\nmin_length: twelve\n", "twelve"},
+		{"empty", "", "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParsePolicy() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	for name, policy := range BuiltinPolicies {
+		if problems := CheckPolicy(policy); len(problems) > 0 {
+			t.Errorf("CheckPolicy(%s) = %v, want no problems", name, problems)
+		}
+	}
+
+	policy := PasswordPolicy{
+		MinLength:         20,
+		MaxLength:         10,
+		MinUpper:          4,
+		MinDigits:         4,
+		MinSymbols:        4,
+		MaxSymbols:        2,
+		MaxLower:          -1,
+		ForbiddenPatterns: []string{"acme", ""},
+		MinEntropy:        80,
+		ForbiddenChars:    "!",
+		AllowedSymbols:    "!a",
+	}
+
+	want := []string{
+		"policy has no name",
+		"max_lower must not be negative, got -1",
+		"minimum length 20 is more than the maximum length 10",
+		"minimum of 4 symbols is more than the maximum of 2",
+		"minimum character counts add up to 12, more than the maximum length 10",
+		`symbols are required but none of the allowed symbols "!a" is usable`,
+		"allowed_symbols contains 'a', which is not a symbol",
+		"forbidden pattern 2 is empty and would forbid every password",
+		"minimum entropy of 80.0 bits is unreachable: 10 characters give at most 65.5 bits",
+	}
+
+	var got []string
+	for _, problem := range CheckPolicy(policy) {
+		got = append(got, problem.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckPolicy() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(valid, []byte("name: Valid\nmin_length: 12\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicyFile(valid)
+	if err != nil {
+		t.Fatalf("LoadPolicyFile() error = %v", err)
+	}
+	if policy.Name != "Valid" || policy.MinLength != 12 {
+		t.Errorf("LoadPolicyFile() = %+v, want the Valid policy", policy)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("min_length: 12\nmax_length: 8\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadPolicyFile(invalid)
+	if err == nil {
+		t.Fatal("LoadPolicyFile() should reject an inconsistent policy")
+	}
+	for _, want := range []string{"invalid.yaml", "policy has no name", "minimum length 12 is more than the maximum length 8"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadPolicyFile() error = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadPolicyDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"team.yaml":   "name: Team\nmin_length: 20\n",
+		"build.yml":   "name: Build servers\nmin_length: 24\n",
+		"typo.yaml":   "name: Typo\nmin_lenght: 12\n",
+		"broken.yaml": "min_length: 12\nmax_length: 8\n",
+		"notes.txt":   "not a policy\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "archive.yaml"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	policies, skipped, err := LoadPolicyDir(dir)
+	if err != nil {
+		t.Fatalf("LoadPolicyDir() error = %v", err)
+	}
+
+	var keys []string
+	for _, file := range policies {
+		keys = append(keys, file.Key)
+	}
+	if want := []string{"build", "team"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("LoadPolicyDir() keys = %v, want %v", keys, want)
+	}
+	if policies[1].Policy.Name != "Team" || policies[1].Path != filepath.Join(dir, "team.yaml") {
+		t.Errorf("LoadPolicyDir() team = %+v", policies[1])
+	}
+
+	if len(skipped) != 2 {
+		t.Fatalf("LoadPolicyDir() skipped = %v, want broken.yaml and typo.yaml", skipped)
+	}
+	for i, want := range []string{"broken.yaml", "typo.yaml"} {
+		if !strings.Contains(skipped[i].Error(), want) {
+			t.Errorf("LoadPolicyDir() skipped[%d] = %v, want it to name %s", i, skipped[i], want)
+		}
+	}
+
+	if _, _, err := LoadPolicyDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadPolicyDir() should fail for a missing directory")
+	}
+}
@@ -0,0 +1,265 @@
+package pwgen
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	mathrand "math/rand/v2"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Generator generates passwords from a given source of randomness. The
+// package-level Generate functions use a Generator backed by crypto/rand.
+type Generator struct {
+	random io.Reader
+	source RandomSource
+}
+
+var defaultGenerator = NewGenerator(rand.Reader)
+
+// RandomSource identifies the random source a Generator draws from, so
+// callers can audit which one produced a password.
+type RandomSource int
+
+const (
+	// SourceCryptoRand is crypto/rand, used by the package-level functions.
+	SourceCryptoRand RandomSource = iota
+	// SourceCombined is crypto/rand mixed with extra entropy by
+	// NewMixedReader, never weaker than crypto/rand alone.
+	SourceCombined
+	// SourceSeeded is the deterministic stream of NewSeededGenerator.
+	SourceSeeded
+	// SourceCustom is any other reader passed to NewGenerator.
+	SourceCustom
+)
+
+func (s RandomSource) String() string {
+	switch s {
+	case SourceCryptoRand:
+		return "crypto/rand"
+	case SourceCombined:
+		return "combined"
+	case SourceSeeded:
+		return "seeded"
+	default:
+		return "custom"
+	}
+}
+
+// Secure reports whether s is backed by crypto/rand. A custom source may be
+// secure too, but pwgen cannot tell.
+func (s RandomSource) Secure() bool {
+	return s == SourceCryptoRand || s == SourceCombined
+}
+
+// Source returns the random source of the package-level functions, which
+// is always crypto/rand.
+func Source() RandomSource {
+	return defaultGenerator.Source()
+}
+
+// Source returns the random source g draws from.
+func (g *Generator) Source() RandomSource {
+	return g.source
+}
+
+// NewGenerator returns a Generator drawing random bytes from random, which
+// must be a cryptographically secure source for real passwords.
+func NewGenerator(random io.Reader) *Generator {
+	return &Generator{random: random, source: sourceOf(random)}
+}
+
+// sourceOf recognizes crypto/rand, alone or mixed with extra entropy.
+func sourceOf(random io.Reader) RandomSource {
+	if random == rand.Reader {
+		return SourceCryptoRand
+	}
+	if mixed, ok := random.(*mixedReader); ok && mixed.random == rand.Reader {
+		return SourceCombined
+	}
+	return SourceCustom
+}
+
+// NewSeededGenerator returns a Generator whose output is fully determined by
+// seed, using a ChaCha8 stream keyed by the seed's SHA-256 hash.
+//
+// It is insecure: anyone who knows or guesses the seed can reproduce every
+// password. Use it only for reproducible tests.
+func NewSeededGenerator(seed string) *Generator {
+	return &Generator{random: mathrand.NewChaCha8(sha256.Sum256([]byte(seed))), source: SourceSeeded}
+}
+
+// mixedBlockSize is the most HKDF-Expand can produce from one info value
+// with SHA-256. mixedReader starts a new block before reaching it.
+const mixedBlockSize = 255 * sha256.Size
+
+// mixedInfo is the HKDF info prefix, followed by the block number.
+const mixedInfo = "pwgen extra entropy"
+
+// mixedReader XORs a random source with a keystream expanded from extra
+// entropy by HKDF-SHA256.
+type mixedReader struct {
+	random io.Reader
+	key    []byte // HKDF pseudorandom key extracted from the extra entropy
+	block  uint64
+	used   int // Bytes of the current block already read
+	stream io.Reader
+}
+
+// NewMixedReader returns a reader whose bytes are those of random XORed with
+// a keystream derived from entropy, so the output depends on both.
+//
+// This never makes the output weaker than random: XORing uniform random
+// bytes with any independent stream leaves them uniform, whatever entropy
+// contains. If random were ever flawed, the keystream still hides the
+// flaw from anyone who does not know entropy.
+func NewMixedReader(random io.Reader, entropy []byte) io.Reader {
+	return &mixedReader{
+		random: random,
+		key:    hkdf.Extract(sha256.New, entropy, nil),
+	}
+}
+
+func (r *mixedReader) Read(p []byte) (int, error) {
+	n, err := r.random.Read(p)
+
+	mask := make([]byte, n)
+	defer clear(mask)
+	if maskErr := r.keystream(mask); maskErr != nil {
+		return 0, fmt.Errorf("failed to expand extra entropy: %w", maskErr)
+	}
+
+	for i := range n {
+		p[i] ^= mask[i]
+	}
+	return n, err
+}
+
+// keystream fills mask with the next bytes of the HKDF keystream, moving
+// on to the next block whenever one is used up.
+func (r *mixedReader) keystream(mask []byte) error {
+	for len(mask) > 0 {
+		if r.stream == nil || r.used == mixedBlockSize {
+			info := binary.BigEndian.AppendUint64([]byte(mixedInfo), r.block)
+			r.stream = hkdf.Expand(sha256.New, r.key, info)
+			r.block++
+			r.used = 0
+		}
+
+		chunk := mask[:min(len(mask), mixedBlockSize-r.used)]
+		if _, err := io.ReadFull(r.stream, chunk); err != nil {
+			return err
+		}
+		r.used += len(chunk)
+		mask = mask[len(chunk):]
+	}
+	return nil
+}
+
+// RandomInt returns a uniformly distributed integer in [0, n) drawn from the
+// source of g, so random choices made around generation, such as the length
+// of each password, are as reproducible as the passwords of a seeded
+// Generator.
+func (g *Generator) RandomInt(n int) (int, error) {
+	return randomInt(g.random, n)
+}
+
+// randomInt returns a uniformly distributed integer in [0, n).
+func randomInt(random io.Reader, n int) (int, error) {
+	value, err := rand.Int(random, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(value.Int64()), nil
+}
+
+// shuffle permutes b in place with a Fisher-Yates shuffle.
+func shuffle[T any](random io.Reader, b []T) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := randomInt(random, i+1)
+		if err != nil {
+			return err
+		}
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+// randomIndices returns n uniformly distributed indices in [0, size).
+// Random bytes are read in bulk, and bytes at or above the largest multiple
+// of the span they cover are rejected so the modulo introduces no bias.
+// Small alphabets pack several indices into each byte, e.g. two digits or
+// two hex characters per byte.
+func randomIndices(random io.Reader, n, size int) ([]int, error) {
+	// A byte cannot cover larger alphabets, so fall back to one draw per index
+	if size > 256 {
+		indices := make([]int, n)
+		for i := range indices {
+			index, err := randomInt(random, size)
+			if err != nil {
+				return nil, err
+			}
+			indices[i] = index
+		}
+		return indices, nil
+	}
+
+	return packedRandomIndices(random, n, size, indicesPerByte(size))
+}
+
+// indicesPerByte is how many indices in [0, size) one random byte can
+// provide: the largest k with size^k <= 256, capped at 8 bits' worth.
+func indicesPerByte(size int) int {
+	perByte, span := 1, size
+	for perByte < 8 && span*size <= 256 {
+		perByte++
+		span *= size
+	}
+	return perByte
+}
+
+// packedRandomIndices reads each byte as perByte base-size digits. Bytes
+// at or above the largest multiple of size^perByte are rejected, so every
+// digit is uniform and independent; for power-of-two sizes nothing is ever
+// rejected. perByte 1 is the plain one-index-per-byte path.
+func packedRandomIndices(random io.Reader, n, size, perByte int) ([]int, error) {
+	span := 1
+	for range perByte {
+		span *= size
+	}
+	limit := 256 - 256%span
+	indices := make([]int, 0, n)
+
+	// Over-read slightly so a single read usually covers the rejections
+	bytesNeeded := (n + perByte - 1) / perByte
+	buf := make([]byte, bytesNeeded+bytesNeeded/4+16)
+	for len(indices) < n {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, fmt.Errorf("failed to generate random number: %w", err)
+		}
+
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+
+			value := int(b) % span
+			for range perByte {
+				if len(indices) == n {
+					break
+				}
+				indices = append(indices, value%size)
+				value /= size
+			}
+			if len(indices) == n {
+				break
+			}
+		}
+	}
+
+	return indices, nil
+}
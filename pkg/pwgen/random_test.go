@@ -0,0 +1,312 @@
+package pwgen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestRandomIndicesUniform(t *testing.T) {
+	// Sizes that do not divide 256 would show modulo bias: without rejection,
+	// indices below 256%size would come up noticeably more often
+	for _, size := range []int{2, 8, 10, 16, 88, 100, 200} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			const perBucket = 2000
+			indices, err := randomIndices(rand.Reader, size*perBucket, size)
+			if err != nil {
+				t.Fatalf("randomIndices() error = %v", err)
+			}
+
+			counts := make([]int, size)
+			for _, index := range indices {
+				if index < 0 || index >= size {
+					t.Fatalf("randomIndices() returned %d, outside [0, %d)", index, size)
+				}
+				counts[index]++
+			}
+
+			chiSquare := 0.0
+			for _, count := range counts {
+				diff := float64(count - perBucket)
+				chiSquare += diff * diff / perBucket
+			}
+
+			// The statistic has mean size-1 and standard deviation
+			// sqrt(2*(size-1)); six deviations keeps false failures negligible
+			degrees := float64(size - 1)
+			if limit := degrees + 6*math.Sqrt(2*degrees); chiSquare > limit {
+				t.Errorf("chi-square = %.1f over %d buckets, want at most %.1f", chiSquare, size, limit)
+			}
+		})
+	}
+}
+
+func TestRandomIndicesPackedAreIndependent(t *testing.T) {
+	// Two digits come from each byte, so check that consecutive pairs are
+	// uniform over all 100 combinations, not just each digit on its own
+	const size, perPair = 10, 1000
+	indices, err := randomIndices(rand.Reader, 2*size*size*perPair, size)
+	if err != nil {
+		t.Fatalf("randomIndices() error = %v", err)
+	}
+
+	counts := make([]int, size*size)
+	for i := 0; i < len(indices); i += 2 {
+		counts[indices[i]*size+indices[i+1]]++
+	}
+
+	chiSquare := 0.0
+	for _, count := range counts {
+		diff := float64(count - perPair)
+		chiSquare += diff * diff / perPair
+	}
+
+	degrees := float64(size*size - 1)
+	if limit := degrees + 6*math.Sqrt(2*degrees); chiSquare > limit {
+		t.Errorf("chi-square = %.1f over %d digit pairs, want at most %.1f", chiSquare, size*size, limit)
+	}
+}
+
+func TestIndicesPerByte(t *testing.T) {
+	tests := []struct {
+		size, want int
+	}{
+		{1, 8}, {2, 8}, {4, 4}, {6, 3}, {10, 2}, {16, 2}, {17, 1}, {94, 1}, {256, 1},
+	}
+
+	for _, tt := range tests {
+		if got := indicesPerByte(tt.size); got != tt.want {
+			t.Errorf("indicesPerByte(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestRandomIndicesPackedRejectsBiasedBytes(t *testing.T) {
+	// Two digits per byte cover 100 values, so bytes from 200 up are
+	// rejected; 123 gives the digits 3 then 2 and 57 gives 7 then 5
+	random := bytes.NewReader(append([]byte{200, 123, 255, 57}, make([]byte, 64)...))
+
+	indices, err := randomIndices(random, 4, 10)
+	if err != nil {
+		t.Fatalf("randomIndices() error = %v", err)
+	}
+
+	if want := []int{3, 2, 7, 5}; fmt.Sprint(indices) != fmt.Sprint(want) {
+		t.Errorf("randomIndices() = %v, want %v with 200 and 255 rejected", indices, want)
+	}
+}
+
+func BenchmarkRandomIndices(b *testing.B) {
+	for _, size := range []int{10, 16, 94} {
+		b.Run(fmt.Sprintf("size %d/one per byte", size), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := packedRandomIndices(rand.Reader, 64, size, 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("size %d/packed", size), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := randomIndices(rand.Reader, 64, size); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomIndicesRejectsBiasedBytes(t *testing.T) {
+	// For 33 characters, 256 % 33 = 25, so bytes from 231 up must be
+	// rejected; mapping them with % would favor the first 25 indices
+	random := bytes.NewReader(append([]byte{231, 255, 5, 240, 230}, make([]byte, 64)...))
+
+	indices, err := randomIndices(random, 2, 33)
+	if err != nil {
+		t.Fatalf("randomIndices() error = %v", err)
+	}
+
+	if indices[0] != 5 || indices[1] != 230%33 {
+		t.Errorf("randomIndices() = %v, want [5 %d] with 231, 255 and 240 rejected", indices, 230%33)
+	}
+}
+
+func TestGenerateCharsetFrequencies(t *testing.T) {
+	// 33 characters do not divide 256, the case where modulo bias would show
+	charset := LowerCase + "0123456"
+	const length, passwords = 100, 660
+
+	counts := make(map[rune]int)
+	for i := 0; i < passwords; i++ {
+		password, err := Generate(Config{Length: length, Charset: charset})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		for _, char := range password {
+			counts[char]++
+		}
+	}
+
+	// With modulo bias the last 8 characters would come up ~10% less often
+	// than the expected 2000 times, pushing chi-square to about 200
+	expected := float64(length*passwords) / float64(len(charset))
+	chiSquare := 0.0
+	for _, char := range charset {
+		diff := float64(counts[char]) - expected
+		chiSquare += diff * diff / expected
+	}
+
+	degrees := float64(len(charset) - 1)
+	if limit := degrees + 6*math.Sqrt(2*degrees); chiSquare > limit {
+		t.Errorf("chi-square = %.1f over %d characters, want at most %.1f (counts %v)", chiSquare, len(charset), limit, counts)
+	}
+}
+
+func TestSeededGeneratorIsReproducible(t *testing.T) {
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, MinDigits: 2}
+
+	generate := func(seed string) []string {
+		g := NewSeededGenerator(seed)
+		var results []string
+		for i := 0; i < 3; i++ {
+			password, err := g.Generate(config)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			results = append(results, password)
+		}
+
+		passphrase, err := g.GeneratePassphrase(PassphraseConfig{Words: 4, Separator: "-"})
+		if err != nil {
+			t.Fatalf("GeneratePassphrase() error = %v", err)
+		}
+		pattern, err := g.GenerateFromPattern("Aaaa-9999")
+		if err != nil {
+			t.Fatalf("GenerateFromPattern() error = %v", err)
+		}
+		n, err := g.RandomInt(1000000)
+		if err != nil {
+			t.Fatalf("RandomInt() error = %v", err)
+		}
+		return append(results, passphrase, pattern, strconv.Itoa(n))
+	}
+
+	first := generate("integration-test")
+	second := generate("integration-test")
+	other := generate("another-seed")
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("output %d differs for the same seed: %q vs %q", i, first[i], second[i])
+		}
+	}
+
+	if first[0] == other[0] {
+		t.Errorf("different seeds produced the same password %q", first[0])
+	}
+
+	// Successive passwords from one generator are not repeated
+	if first[0] == first[1] {
+		t.Errorf("seeded generator repeated %q", first[0])
+	}
+}
+
+func TestMixedReaderDependsOnBothSources(t *testing.T) {
+	// Reading an all-zero source exposes the keystream itself
+	const size = 3*mixedBlockSize + 100
+	keystream := make([]byte, size)
+	if _, err := io.ReadFull(NewMixedReader(bytes.NewReader(make([]byte, size)), []byte("dice rolls")), keystream); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if bytes.Equal(keystream[:64], make([]byte, 64)) {
+		t.Fatal("keystream is all zeros, extra entropy was not mixed in")
+	}
+	if bytes.Equal(keystream[:64], keystream[mixedBlockSize:mixedBlockSize+64]) {
+		t.Error("second keystream block repeats the first")
+	}
+
+	other := make([]byte, 64)
+	if _, err := io.ReadFull(NewMixedReader(bytes.NewReader(make([]byte, 64)), []byte("coin flips")), other); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if bytes.Equal(keystream[:64], other) {
+		t.Error("different extra entropy gave the same keystream")
+	}
+
+	// Over a real source, the output is that source XORed with the keystream
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal(err)
+	}
+	mixed := make([]byte, size)
+	if _, err := io.ReadFull(NewMixedReader(bytes.NewReader(random), []byte("dice rolls")), mixed); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	for i := range mixed {
+		if mixed[i] != random[i]^keystream[i] {
+			t.Fatalf("byte %d = %#x, want %#x", i, mixed[i], random[i]^keystream[i])
+		}
+	}
+}
+
+func TestMixedReaderReportsSourceErrors(t *testing.T) {
+	random := NewMixedReader(bytes.NewReader(nil), []byte("dice rolls"))
+
+	if _, err := randomIndices(random, 4, 10); err == nil {
+		t.Error("randomIndices() from an empty source returned no error")
+	}
+}
+
+func TestGeneratorWithMixedReader(t *testing.T) {
+	generator := NewGenerator(NewMixedReader(rand.Reader, []byte("dice rolls")))
+	config := Config{Length: 20, IncludeUpper: true, IncludeLower: true, IncludeDigits: true}
+
+	first, err := generator.Generate(config)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	second, err := generator.Generate(config)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if len(first) != 20 || first == second {
+		t.Errorf("Generate() = %q then %q, want two different 20 character passwords", first, second)
+	}
+}
+
+func TestGeneratorSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator *Generator
+		want      RandomSource
+		secure    bool
+	}{
+		{"crypto/rand", NewGenerator(rand.Reader), SourceCryptoRand, true},
+		{"mixed", NewGenerator(NewMixedReader(rand.Reader, []byte("dice rolls"))), SourceCombined, true},
+		{"seeded", NewSeededGenerator("fixture"), SourceSeeded, false},
+		{"custom", NewGenerator(bytes.NewReader(nil)), SourceCustom, false},
+		{"mixed custom", NewGenerator(NewMixedReader(bytes.NewReader(nil), []byte("dice rolls"))), SourceCustom, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.generator.Source()
+			if source != tt.want {
+				t.Errorf("Source() = %v, want %v", source, tt.want)
+			}
+			if source.Secure() != tt.secure {
+				t.Errorf("%v.Secure() = %v, want %v", source, source.Secure(), tt.secure)
+			}
+		})
+	}
+
+	if Source() != SourceCryptoRand {
+		t.Errorf("package-level Source() = %v, want crypto/rand", Source())
+	}
+}
@@ -0,0 +1,50 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Entropy models for AnalysisOptions.EntropyModel.
+const (
+	// EntropyModelUniform assumes every character was drawn uniformly from
+	// the character space, which holds for generated passwords.
+	EntropyModelUniform = "uniform"
+	// EntropyModelShannon measures the character frequencies observed in
+	// the password, which overstates human-chosen passwords less.
+	EntropyModelShannon = "shannon"
+)
+
+// EntropyModels lists the accepted AnalysisOptions.EntropyModel values.
+var EntropyModels = []string{EntropyModelUniform, EntropyModelShannon}
+
+// ValidateEntropyModel reports an error for a model other than the empty
+// string, which means EntropyModelUniform, and those in EntropyModels.
+func ValidateEntropyModel(model string) error {
+	if model != "" && !slices.Contains(EntropyModels, model) {
+		return fmt.Errorf("unknown entropy model '%s' (available: %s)", model, strings.Join(EntropyModels, ", "))
+	}
+	return nil
+}
+
+// shannonEntropy returns the Shannon entropy of password's observed
+// character frequencies times its length: -Σ p·log2(p) bits per character
+// for each distinct character of frequency p. "aaaa" has 0 bits and
+// "abcd" 8, however large the character space they were drawn from.
+func shannonEntropy(password string) float64 {
+	counts := make(map[rune]int)
+	length := 0
+	for _, char := range password {
+		counts[char]++
+		length++
+	}
+
+	perChar := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(length)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(length)
+}
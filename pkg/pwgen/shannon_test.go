@@ -0,0 +1,62 @@
+package pwgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{"", 0},
+		{"aaaa", 0},
+		{"abcd", 8},
+		{"aabb", 4},
+		{"ab", 2},
+		{"äöüß", 8}, // Counted per rune, not per byte
+	}
+
+	for _, tt := range tests {
+		if got := shannonEntropy(tt.password); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("shannonEntropy(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestValidateEntropyModel(t *testing.T) {
+	for _, model := range []string{"", EntropyModelUniform, EntropyModelShannon} {
+		if err := ValidateEntropyModel(model); err != nil {
+			t.Errorf("ValidateEntropyModel(%q) error = %v", model, err)
+		}
+	}
+	if err := ValidateEntropyModel("zxcvbn"); err == nil {
+		t.Error("ValidateEntropyModel(\"zxcvbn\") should fail")
+	}
+}
+
+func TestShannonEntropyModel(t *testing.T) {
+	opts := AnalysisOptions{EntropyModel: EntropyModelShannon}
+
+	breakdown := ExplainEntropy("aB3$xY7!", opts)
+	if breakdown.Method != "shannon" || breakdown.CharSpace != 8 || breakdown.RawEntropy != 24 {
+		t.Errorf("ExplainEntropy() = %+v, want the shannon method over 8 distinct characters", breakdown)
+	}
+
+	// Repetition lowers the estimate even though the classes are the same
+	uniform := AnalyzePasswordStrength("aaaaaaaB1!")
+	shannon := AnalyzePasswordStrengthWithOptions("aaaaaaaB1!", opts)
+	if shannon.Entropy >= uniform.Entropy {
+		t.Errorf("shannon entropy = %.1f, want below the uniform %.1f", shannon.Entropy, uniform.Entropy)
+	}
+	if shannon.EntropyModel != EntropyModelShannon || uniform.EntropyModel != EntropyModelUniform {
+		t.Errorf("EntropyModel = %q and %q, want shannon and uniform", shannon.EntropyModel, uniform.EntropyModel)
+	}
+
+	// The model takes precedence over a known charset
+	withCharset := ExplainEntropy("abcd", AnalysisOptions{Charset: LowerCase, EntropyModel: EntropyModelShannon})
+	if withCharset.Method != "shannon" || withCharset.RawEntropy != 8 {
+		t.Errorf("ExplainEntropy() = %+v, want the shannon method", withCharset)
+	}
+}
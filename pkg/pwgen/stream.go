@@ -0,0 +1,41 @@
+package pwgen
+
+import "context"
+
+// GenerateStream sends passwords generated from config to out until ctx is
+// cancelled, then closes out, so a consumer can range over it. It returns
+// ctx.Err() once cancelled, or the first error generation fails with.
+//
+// GenerateStream must be the only writer to out. Cancellation is honored
+// while blocked on a send as well as between passwords, so it returns
+// promptly even when nothing reads from out.
+func GenerateStream(ctx context.Context, config Config, out chan<- string) error {
+	return defaultGenerator.GenerateStream(ctx, config, out)
+}
+
+// GenerateStream is like the package-level GenerateStream, drawing from g's
+// source.
+func (g *Generator) GenerateStream(ctx context.Context, config Config, out chan<- string) error {
+	defer close(out)
+
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		password, err := generatePassword(config, g.random)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case out <- password:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package pwgen
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenerateStreamCancel(t *testing.T) {
+	config := Config{Length: 12, IncludeLower: true, IncludeDigits: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string)
+
+	done := make(chan error, 1)
+	go func() { done <- NewSeededGenerator("stream").GenerateStream(ctx, config, out) }()
+
+	for i := 0; i < 10; i++ {
+		password := <-out
+		if len(password) != config.Length {
+			t.Fatalf("GenerateStream() sent %q, want %d characters", password, config.Length)
+		}
+	}
+
+	// Cancel while the generator is blocked sending, with nobody reading
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("GenerateStream() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GenerateStream() did not return after cancellation")
+	}
+
+	// out is closed once GenerateStream returns
+	for range out {
+	}
+}
+
+func TestGenerateStreamInvalidConfig(t *testing.T) {
+	out := make(chan string)
+	if err := GenerateStream(context.Background(), Config{Length: 12}, out); err == nil {
+		t.Error("GenerateStream() should reject a config without characters")
+	}
+	if _, ok := <-out; ok {
+		t.Error("GenerateStream() should close out on error")
+	}
+}
+
+func BenchmarkGenerateStream(b *testing.B) {
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, IncludeSymbols: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := make(chan string, 64)
+	go GenerateStream(ctx, config, out)
+
+	for b.Loop() {
+		<-out
+	}
+}
@@ -0,0 +1,934 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Character class patterns, compiled once rather than on every analysis.
+var (
+	lowerPattern  = regexp.MustCompile(`[a-z]`)
+	upperPattern  = regexp.MustCompile(`[A-Z]`)
+	digitPattern  = regexp.MustCompile(`[0-9]`)
+	symbolPattern = regexp.MustCompile(`[^a-zA-Z0-9]`)
+)
+
+type StrengthLevel int
+
+const (
+	VeryWeak StrengthLevel = iota
+	Weak
+	Fair
+	Good
+	Strong
+	VeryStrong
+)
+
+func (s StrengthLevel) String() string {
+	switch s {
+	case VeryWeak:
+		return "Very Weak"
+	case Weak:
+		return "Weak"
+	case Fair:
+		return "Fair"
+	case Good:
+		return "Good"
+	case Strong:
+		return "Strong"
+	case VeryStrong:
+		return "Very Strong"
+	default:
+		return "Unknown"
+	}
+}
+
+// StrengthLevelNames lists the names ParseStrengthLevel accepts, from the
+// weakest level to the strongest.
+var StrengthLevelNames = []string{"very-weak", "weak", "fair", "good", "strong", "very-strong"}
+
+// ParseStrengthLevel returns the level with the given name, either one of
+// StrengthLevelNames or as printed by String, ignoring case.
+func ParseStrengthLevel(name string) (StrengthLevel, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-")
+	for level, known := range StrengthLevelNames {
+		if normalized == known {
+			return StrengthLevel(level), nil
+		}
+	}
+	return VeryWeak, fmt.Errorf("unknown strength level '%s' (available: %s)", name, strings.Join(StrengthLevelNames, ", "))
+}
+
+func (s StrengthLevel) Color() string {
+	switch s {
+	case VeryWeak:
+		return "\033[91m" // Red
+	case Weak:
+		return "\033[91m" // Red
+	case Fair:
+		return "\033[93m" // Yellow
+	case Good:
+		return "\033[93m" // Yellow
+	case Strong:
+		return "\033[92m" // Green
+	case VeryStrong:
+		return "\033[92m" // Green
+	default:
+		return "\033[0m" // Reset
+	}
+}
+
+type PasswordStrength struct {
+	Score   int
+	Level   StrengthLevel
+	Entropy float64
+	// Feedback is Messages rendered in English, see LocalizeFeedback for
+	// other languages.
+	Feedback    []string
+	Messages    []FeedbackMessage
+	TimeToCrack string
+	// CrackTimes maps each AttackScenarios name to its time-to-crack
+	// estimate. It is only populated when AnalysisOptions.CrackTimes is set.
+	CrackTimes map[string]string
+	// PolicyAwareEntropy is the entropy against an attacker who knows the
+	// password complies with AnalysisOptions.Policy, see PolicyAwareEntropy,
+	// and never more than Entropy. PolicyAwareTimeToCrack is its
+	// time-to-crack estimate. Both are only populated when a policy is given.
+	PolicyAwareEntropy     float64
+	PolicyAwareTimeToCrack string
+	// EntropyModel is the model Entropy was estimated with, one of
+	// EntropyModels.
+	EntropyModel string
+}
+
+// AnalysisOptions describes what is known about how a password was generated,
+// so the analysis can use the real character space instead of inferring it.
+type AnalysisOptions struct {
+	// Charset is the exact set of characters the password was drawn from.
+	Charset string
+	// Pattern is the template the password was generated from, see
+	// GenerateFromPattern. It takes precedence over Charset.
+	Pattern string
+	// GuessRate is the attacker's guesses per second used for the
+	// time-to-crack estimate. Zero means DefaultGuessRate.
+	GuessRate float64
+	// CrackTimes requests a time-to-crack estimate for every scenario in
+	// AttackScenarios, in addition to TimeToCrack.
+	CrackTimes bool
+	// PassphraseDictSize is the size of the wordlist a passphrase was drawn
+	// from, used when the password looks like separated dictionary words.
+	// Zero means the EFF large wordlist used by GeneratePassphrase.
+	PassphraseDictSize int
+	// Passphrase, when set, is the configuration the password was generated
+	// with by GeneratePassphrase, so it is scored by PassphraseEntropy even
+	// when its words or separators are not recognizable. It takes
+	// precedence over Pattern, Charset and PassphraseDictSize.
+	Passphrase *PassphraseConfig
+	// Hybrid, when set, is the configuration the password was generated
+	// with by GenerateHybrid, so it is scored by HybridEntropy. It takes
+	// precedence over Pattern, Charset and PassphraseDictSize.
+	Hybrid *HybridConfig
+	// AlternateClasses reports that the password was generated with
+	// Config.AlternateClasses, which lowers its entropy.
+	AlternateClasses bool
+	// ClassWeights reports that the password was generated with
+	// Config.ClassWeights, which lowers its entropy unless the weights
+	// match the class sizes.
+	ClassWeights ClassWeights
+	// NoRepeat reports that the password was generated with
+	// Config.NoRepeat, which lowers its entropy.
+	NoRepeat bool
+	// RawBytes is the number of random bytes the password encodes, see
+	// GenerateHex and GenerateBase64. It takes precedence over Pattern and
+	// Charset, and the entropy is exactly RawBytes*8 bits.
+	RawBytes int
+	// Policy, when set, is the policy the attacker knows the password
+	// complies with, for PasswordStrength.PolicyAwareEntropy.
+	Policy *PasswordPolicy
+	// EntropyModel selects how the entropy is estimated, one of
+	// EntropyModels. Empty means EntropyModelUniform, which suits generated
+	// passwords; EntropyModelShannon suits auditing human-chosen ones and
+	// takes precedence over RawBytes, Pattern and Charset.
+	EntropyModel string
+	// PatternPenalties sets the multiplier for each weak pattern found.
+	// Zero fields mean their DefaultPatternPenalties value.
+	PatternPenalties PatternPenalties
+}
+
+// AttackScenario is a named attacker model used for crack time estimates.
+type AttackScenario struct {
+	Name      string
+	GuessRate float64 // Guesses per second
+}
+
+// AttackScenarios are the attacker models reported in
+// PasswordStrength.CrackTimes, from weakest to strongest attacker.
+var AttackScenarios = []AttackScenario{
+	{Name: "online-throttled", GuessRate: 10},    // Rate-limited login form
+	{Name: "online-unthrottled", GuessRate: 1e3}, // Login form without rate limiting
+	{Name: "offline-slow-hash", GuessRate: 1e4},  // bcrypt, scrypt, argon2
+	{Name: "offline-fast-hash", GuessRate: 1e10}, // GPU cluster against MD5, SHA-1
+}
+
+// DefaultGuessRate assumes 1 billion guesses per second (modern hardware).
+const DefaultGuessRate = 1e9
+
+// GuessRatePresets are named attacker guess rates in guesses per second.
+var GuessRatePresets = map[string]float64{
+	"online":            10,   // Throttled login form
+	"offline-slow-hash": 1e4,  // bcrypt, scrypt, argon2
+	"offline-gpu":       1e10, // GPU cluster against a fast hash
+}
+
+// ParseGuessRate accepts either a preset name from GuessRatePresets or a
+// positive number of guesses per second.
+func ParseGuessRate(value string) (float64, error) {
+	if rate, exists := GuessRatePresets[value]; exists {
+		return rate, nil
+	}
+
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return 0, fmt.Errorf("invalid guess rate '%s': use a positive number or one of online, offline-slow-hash, offline-gpu", value)
+	}
+
+	return rate, nil
+}
+
+func AnalyzePasswordStrength(password string) PasswordStrength {
+	return AnalyzePasswordStrengthWithOptions(password, AnalysisOptions{})
+}
+
+func AnalyzePasswordStrengthWithOptions(password string, opts AnalysisOptions) PasswordStrength {
+	score := 0
+	var messages []FeedbackMessage
+
+	length := utf8.RuneCountInString(password)
+
+	// Length scoring
+	if length < 8 {
+		messages = append(messages, FeedbackMessage{ID: MsgUseMinLength})
+	} else if length < 12 {
+		score += 10
+		messages = append(messages, FeedbackMessage{ID: MsgConsiderLonger})
+	} else if length < 16 {
+		score += 20
+	} else {
+		score += 30
+	}
+
+	// Character variety scoring
+	hasLower := lowerPattern.MatchString(password)
+	hasUpper := upperPattern.MatchString(password)
+	hasDigit := digitPattern.MatchString(password)
+	hasSymbol := symbolPattern.MatchString(password)
+
+	varietyCount := 0
+	if hasLower {
+		varietyCount++
+		score += 10
+	} else {
+		messages = append(messages, FeedbackMessage{ID: MsgAddLower})
+	}
+
+	if hasUpper {
+		varietyCount++
+		score += 10
+	} else {
+		messages = append(messages, FeedbackMessage{ID: MsgAddUpper})
+	}
+
+	if hasDigit {
+		varietyCount++
+		score += 10
+	} else {
+		messages = append(messages, FeedbackMessage{ID: MsgAddDigits})
+	}
+
+	if hasSymbol {
+		varietyCount++
+		score += 15
+	} else {
+		messages = append(messages, FeedbackMessage{ID: MsgAddSymbols})
+	}
+
+	// Bonus for using all character types
+	if varietyCount == 4 {
+		score += 10
+	}
+
+	// Pattern penalties
+	if hasRepeatedChars(password) {
+		score -= 10
+		messages = append(messages, FeedbackMessage{ID: MsgAvoidRepeated})
+	}
+
+	if hasSequentialChars(password) {
+		score -= 15
+		messages = append(messages, FeedbackMessage{ID: MsgAvoidSequential})
+	}
+
+	if hasRepeatedSubstrings(password) {
+		score -= 10
+		messages = append(messages, FeedbackMessage{ID: MsgAvoidRepetition})
+	}
+
+	if hasDatePattern(password) {
+		score -= 10
+		messages = append(messages, FeedbackMessage{ID: MsgAvoidDates})
+	}
+
+	if hasFewDistinctChars(password) {
+		score -= 10
+		messages = append(messages, FeedbackMessage{ID: MsgUseMoreDistinct})
+	}
+
+	// Dictionary words cost points in proportion to how much of the
+	// password they cover
+	if matches := findDictionaryMatches(password); len(matches) > 0 {
+		score -= dictionaryPenalty(password, matches)
+
+		var words []string
+		for _, match := range matches {
+			words = append(words, match.Word)
+		}
+		messages = append(messages, FeedbackMessage{ID: MsgAvoidDictionary, Args: []string{strings.Join(words, ", ")}})
+	}
+
+	// Calculate entropy
+	entropy := calculateEntropyWithOptions(password, opts)
+
+	// Adjust score based on entropy
+	if entropy >= 60 {
+		score += 20
+	} else if entropy >= 40 {
+		score += 10
+	} else if entropy < 25 {
+		score -= 15
+		messages = append(messages, FeedbackMessage{ID: MsgTooPredictable})
+	}
+
+	// Ensure score is within bounds
+	if score < 0 {
+		score = 0
+	}
+	if score > 100 {
+		score = 100
+	}
+
+	// Determine strength level
+	level := getStrengthLevel(score)
+
+	// Generate time to crack estimate
+	guessRate := opts.GuessRate
+	if guessRate <= 0 {
+		guessRate = DefaultGuessRate
+	}
+	timeToCrack := estimateTimeToCrack(entropy, guessRate)
+
+	var crackTimes map[string]string
+	if opts.CrackTimes {
+		crackTimes = make(map[string]string, len(AttackScenarios))
+		for _, scenario := range AttackScenarios {
+			crackTimes[scenario.Name] = estimateTimeToCrack(entropy, scenario.GuessRate)
+		}
+	}
+
+	// An attacker who knows the policy skips every non-compliant candidate
+	var policyEntropy float64
+	var policyTimeToCrack string
+	if opts.Policy != nil {
+		policyEntropy = min(entropy, PolicyAwareEntropy(length, policyAwareCharset(password, opts.Charset), *opts.Policy))
+		policyTimeToCrack = estimateTimeToCrack(policyEntropy, guessRate)
+	}
+
+	// Add positive feedback for strong passwords
+	if score >= 80 && len(messages) == 0 {
+		messages = append(messages, FeedbackMessage{ID: MsgExcellentPassword})
+	}
+
+	return PasswordStrength{
+		Score:       score,
+		Level:       level,
+		Entropy:     entropy,
+		Feedback:    LocalizeFeedback(messages, DefaultLanguage),
+		Messages:    messages,
+		TimeToCrack: timeToCrack,
+		CrackTimes:  crackTimes,
+
+		PolicyAwareEntropy:     policyEntropy,
+		PolicyAwareTimeToCrack: policyTimeToCrack,
+		EntropyModel:           entropyModel(opts),
+	}
+}
+
+// entropyModel returns the model opts selects, EntropyModelUniform when
+// it is empty.
+func entropyModel(opts AnalysisOptions) string {
+	if opts.EntropyModel == "" {
+		return EntropyModelUniform
+	}
+	return opts.EntropyModel
+}
+
+func calculateEntropy(password string) float64 {
+	return calculateEntropyWithOptions(password, AnalysisOptions{})
+}
+
+func calculateEntropyWithOptions(password string, opts AnalysisOptions) float64 {
+	return ExplainEntropy(password, opts).Entropy
+}
+
+// EntropyBreakdown shows how the entropy estimate of a password is derived:
+// a raw estimate from the character space and length, then a multiplier per
+// weak pattern found.
+type EntropyBreakdown struct {
+	// Method is how the raw estimate was made: "bytes" (encoded random
+	// bytes), "pattern", "charset", "passphrase" (separated dictionary
+	// words), "hybrid" (words with inserted digits and symbols),
+	// "pronounceable" (per-position consonant/vowel sets), "classes" (the
+	// character classes present in the password) or "shannon" (the
+	// observed character frequencies).
+	Method string
+	// CharSpace is the number of possible characters per position, or of
+	// possible words for the passphrase and hybrid methods, or of distinct
+	// characters for the shannon method. It is 0 for the pattern and
+	// pronounceable methods, which size each position separately.
+	CharSpace int
+	Length    int
+	// Words is the number of dictionary words for the passphrase and
+	// hybrid methods.
+	Words int
+	// Separators is the number of characters each separator between words
+	// was drawn from for the passphrase method, when more than one.
+	Separators int
+	// InsertionEntropy is the part of RawEntropy due to the position and
+	// choice of the inserted digits and symbols for the hybrid method.
+	InsertionEntropy float64
+	RawEntropy       float64 // Bits before penalties
+	Penalties        []EntropyPenalty
+	Entropy          float64 // Bits after penalties
+	// PositionSpaces is the number of possible characters at each random
+	// position for the pattern method, literals left out.
+	PositionSpaces []int
+}
+
+// EntropyPenalty is a weak pattern and the multiplier it applies to the
+// entropy estimate.
+type EntropyPenalty struct {
+	Name       string
+	Multiplier float64
+}
+
+// ExplainEntropy returns the breakdown behind the entropy reported by
+// AnalyzePasswordStrengthWithOptions for the same password and options.
+func ExplainEntropy(password string, opts AnalysisOptions) EntropyBreakdown {
+	breakdown := EntropyBreakdown{Length: utf8.RuneCountInString(password)}
+
+	// The observed frequencies replace the character space altogether,
+	// so only the weak patterns still apply
+	if opts.EntropyModel == EntropyModelShannon {
+		breakdown.Method = "shannon"
+		breakdown.CharSpace = utf8.RuneCountInString(uniqueRunes(password))
+		breakdown.RawEntropy = shannonEntropy(password)
+		breakdown.Penalties = patternPenalties(password, opts.PatternPenalties)
+		breakdown.Entropy = breakdown.RawEntropy
+		for _, penalty := range breakdown.Penalties {
+			breakdown.Entropy *= penalty.Multiplier
+		}
+		return breakdown
+	}
+
+	// Encoded random bytes hold exactly 8 bits each, whatever the encoding
+	// looks like, so no weak pattern applies
+	if opts.RawBytes > 0 {
+		breakdown.Method = "bytes"
+		breakdown.RawEntropy = float64(opts.RawBytes) * 8
+		breakdown.Entropy = breakdown.RawEntropy
+		return breakdown
+	}
+
+	// A known passphrase configuration counts every word and separator
+	// choice, whatever the words look like
+	if opts.Passphrase != nil {
+		breakdown.Method = "passphrase"
+		breakdown.Words = opts.Passphrase.Words
+		breakdown.CharSpace = len(passphraseWords(*opts.Passphrase))
+		if separators := utf8.RuneCountInString(uniqueRunes(opts.Passphrase.Separators)); separators > 1 && breakdown.Words > 1 {
+			breakdown.Separators = separators
+		}
+		breakdown.RawEntropy = PassphraseEntropy(*opts.Passphrase)
+		breakdown.Entropy = breakdown.RawEntropy
+		return breakdown
+	}
+
+	// A known hybrid configuration counts the words and the digits and
+	// symbols inserted among them
+	if opts.Hybrid != nil {
+		breakdown.Method = "hybrid"
+		breakdown.Words = opts.Hybrid.Words
+		breakdown.CharSpace = len(hybridWordlist)
+		breakdown.InsertionEntropy = hybridInsertionEntropy(breakdown.Length, *opts.Hybrid)
+		breakdown.RawEntropy = HybridEntropy(password, *opts.Hybrid)
+		breakdown.Entropy = breakdown.RawEntropy
+		return breakdown
+	}
+
+	// A pattern fixes the class of every position
+	if opts.Pattern != "" {
+		if spaces, err := patternSpaces(opts.Pattern); err == nil {
+			breakdown.Method = "pattern"
+			breakdown.PositionSpaces = spaces
+			for _, space := range spaces {
+				breakdown.RawEntropy += math.Log2(float64(space))
+			}
+		}
+	}
+
+	words := passphraseWordCount(password)
+
+	switch {
+	case breakdown.Method != "":
+
+	// A known charset gives the exact character space
+	case opts.Charset != "":
+		breakdown.Method = "charset"
+		breakdown.CharSpace = utf8.RuneCountInString(uniqueRunes(opts.Charset))
+		breakdown.RawEntropy = float64(breakdown.Length) * math.Log2(float64(breakdown.CharSpace))
+
+	// A passphrase is only as strong as the number of words picked from
+	// the wordlist, however many letters they have. Weak patterns inside
+	// the words are already accounted for by the wordlist size.
+	case words > 0:
+		breakdown.Method = "passphrase"
+		breakdown.Words = words
+		breakdown.CharSpace = opts.PassphraseDictSize
+		if breakdown.CharSpace <= 0 {
+			breakdown.CharSpace = len(wordlist)
+		}
+		breakdown.RawEntropy = float64(breakdown.Words) * math.Log2(float64(breakdown.CharSpace))
+		breakdown.Entropy = breakdown.RawEntropy
+		return breakdown
+
+	// Pronounceable passwords draw each position from a much smaller
+	// consonant or vowel set, so score them per position instead
+	case isPronounceable(password):
+		breakdown.Method = "pronounceable"
+		breakdown.RawEntropy = pronounceableEntropy(password)
+
+	default:
+		breakdown.Method = "classes"
+		breakdown.CharSpace = classCharSpace(password)
+		if breakdown.CharSpace == 0 {
+			return breakdown
+		}
+
+		// Entropy = length * log2(character_space)
+		breakdown.RawEntropy = float64(breakdown.Length) * math.Log2(float64(breakdown.CharSpace))
+	}
+
+	breakdown.Penalties = patternPenalties(password, opts.PatternPenalties)
+	if opts.AlternateClasses && breakdown.Method != "pronounceable" && breakdown.Method != "pattern" {
+		if penalty, ok := alternationPenalty(password, opts.Charset); ok {
+			breakdown.Penalties = append(breakdown.Penalties, penalty)
+		}
+	}
+	if !opts.ClassWeights.IsZero() && breakdown.Method != "pronounceable" && breakdown.Method != "pattern" {
+		if penalty, ok := weightPenalty(password, opts.Charset, opts.ClassWeights); ok {
+			breakdown.Penalties = append(breakdown.Penalties, penalty)
+		}
+	}
+	if opts.NoRepeat && breakdown.Method != "pronounceable" && breakdown.Method != "pattern" {
+		if penalty, ok := noRepeatPenalty(password, opts.Charset); ok {
+			breakdown.Penalties = append(breakdown.Penalties, penalty)
+		}
+	}
+	breakdown.Entropy = breakdown.RawEntropy
+	for _, penalty := range breakdown.Penalties {
+		breakdown.Entropy *= penalty.Multiplier
+	}
+
+	return breakdown
+}
+
+// classCharSpace sums the sizes of the character classes present in password.
+func classCharSpace(password string) int {
+	charSpace := 0
+
+	if lowerPattern.MatchString(password) {
+		charSpace += 26 // lowercase
+	}
+	if upperPattern.MatchString(password) {
+		charSpace += 26 // uppercase
+	}
+	if digitPattern.MatchString(password) {
+		charSpace += 10 // digits
+	}
+	if symbolPattern.MatchString(password) {
+		charSpace += 32 // common symbols
+	}
+
+	return charSpace
+}
+
+// patternPenalties lists the weak patterns found in password with the
+// multiplier from multipliers each applies to the entropy estimate.
+func patternPenalties(password string, multipliers PatternPenalties) []EntropyPenalty {
+	found := []bool{
+		hasRepeatedChars(password),
+		hasSequentialChars(password),
+		hasRepeatedSubstrings(password),
+		hasCommonPatterns(password),
+		hasDatePattern(password),
+		hasFewDistinctChars(password),
+	}
+
+	var penalties []EntropyPenalty
+	for i, multiplier := range multipliers.withDefaults().list() {
+		if found[i] {
+			penalties = append(penalties, EntropyPenalty{Name: patternPenaltyNames[i], Multiplier: multiplier})
+		}
+	}
+	return penalties
+}
+
+// minPassphraseWords is the fewest separated words treated as a passphrase,
+// so two words joined by a symbol are still scored as a password.
+const minPassphraseWords = 3
+
+// passphraseWordCount returns the number of words when password is at least
+// minPassphraseWords dictionary words joined by the same separator
+// character, as GeneratePassphrase produces, and 0 otherwise. Words match
+// case-insensitively against the passphrase wordlist and the dictionaries.
+func passphraseWordCount(password string) int {
+	separator := strings.IndexFunc(password, func(r rune) bool {
+		return !isLower(r) && !isUpper(r)
+	})
+	if separator <= 0 || isDigit(rune(password[separator])) {
+		return 0
+	}
+
+	sepRune, _ := utf8.DecodeRuneInString(password[separator:])
+	words := strings.Split(password, string(sepRune))
+	if len(words) < minPassphraseWords {
+		return 0
+	}
+
+	for _, word := range words {
+		if !isPassphraseWord(word) {
+			return 0
+		}
+	}
+
+	return len(words)
+}
+
+func isPassphraseWord(word string) bool {
+	if len(word) < 3 {
+		return false
+	}
+	for _, r := range word {
+		if !isLower(r) && !isUpper(r) {
+			return false
+		}
+	}
+
+	word = strings.ToLower(word)
+	if wordlistWords[word] {
+		return true
+	}
+	for _, d := range dictionaries {
+		if d.words[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// isPronounceable reports whether the password strictly alternates between
+// the Consonants and Vowels sets used by pronounceable generation.
+func isPronounceable(password string) bool {
+	if len(password) < 2 {
+		return false
+	}
+
+	for i := 0; i < len(password); i++ {
+		isConsonant := strings.IndexByte(Consonants, password[i]) >= 0
+		isVowel := strings.IndexByte(Vowels, password[i]) >= 0
+		if !isConsonant && !isVowel {
+			return false
+		}
+
+		if i > 0 {
+			prevIsVowel := strings.IndexByte(Vowels, password[i-1]) >= 0
+			if isVowel == prevIsVowel {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func pronounceableEntropy(password string) float64 {
+	entropy := 0.0
+	for i := 0; i < len(password); i++ {
+		if strings.IndexByte(Vowels, password[i]) >= 0 {
+			entropy += math.Log2(float64(len(Vowels)))
+		} else {
+			entropy += math.Log2(float64(len(Consonants)))
+		}
+	}
+
+	return entropy
+}
+
+func hasRepeatedChars(password string) bool {
+	return longestRun(password) >= 3
+}
+
+// minRepeatedSubstring is the fewest characters a repeated substring must
+// cover, so "abab" passes but "abcabc" and "xyxyxy" do not.
+const minRepeatedSubstring = 6
+
+// maxRepeatedUnit is the longest substring hasRepeatedSubstrings looks for
+// repeats of, which keeps the scan linear in the length of the password.
+const maxRepeatedUnit = 8
+
+// hasRepeatedSubstrings reports whether password contains a substring of
+// two to maxRepeatedUnit characters repeated back-to-back over at least
+// minRepeatedSubstring characters, such as "abcabc" or "ab" four times,
+// compared case-insensitively. Substrings of a single repeated character
+// are left to hasRepeatedChars.
+func hasRepeatedSubstrings(password string) bool {
+	runes := []rune(strings.ToLower(password))
+
+	for size := 2; size <= maxRepeatedUnit && size*2 <= len(runes); size++ {
+		need := max(size*2, minRepeatedSubstring)
+
+		// matched counts the runes in a row equal to the rune size before
+		// them, so the repeat ending at i spans matched+size runes
+		matched := 0
+		for i := size; i < len(runes); i++ {
+			if runes[i] != runes[i-size] {
+				matched = 0
+				continue
+			}
+			matched++
+
+			// A repeat of a single character stays one as it grows, so it
+			// is enough to look at the unit once, when it is long enough
+			if matched+size == need && !sameRunes(runes[i+1-need:i+1-need+size]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sameRunes reports whether every rune of unit is the same.
+func sameRunes(unit []rune) bool {
+	for _, r := range unit[1:] {
+		if r != unit[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSequentialChars(password string) bool {
+	sequences := []string{
+		"abcdefghijklmnopqrstuvwxyz",
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		"0123456789",
+		"qwertyuiop", "asdfghjkl", "zxcvbnm",
+		"QWERTYUIOP", "ASDFGHJKL", "ZXCVBNM",
+	}
+
+	lower := strings.ToLower(password)
+	for _, seq := range sequences {
+		for i := 0; i <= len(seq)-3; i++ {
+			if strings.Contains(lower, seq[i:i+3]) {
+				return true
+			}
+		}
+	}
+
+	// Check for reverse sequences
+	for _, seq := range sequences {
+		reversed := reverseString(seq)
+		for i := 0; i <= len(reversed)-3; i++ {
+			if strings.Contains(lower, reversed[i:i+3]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// leetSubstitutions maps common l33t-speak symbols back to the letters they
+// stand for. Every entry is a single byte so normalizing keeps offsets.
+var leetSubstitutions = map[string]string{
+	"@": "a", "3": "e", "1": "i", "0": "o", "5": "s", "$": "s", "7": "t",
+}
+
+func leetNormalize(s string) string {
+	for symbol, letter := range leetSubstitutions {
+		s = strings.ReplaceAll(s, symbol, letter)
+	}
+	return s
+}
+
+func hasCommonPatterns(password string) bool {
+	commonPatterns := []string{
+		"password", "123456", "qwerty", "admin", "login",
+		"welcome", "monkey", "dragon", "master", "shadow",
+		"letmein", "football", "iloveyou", "sunshine", "princess",
+	}
+
+	lower := strings.ToLower(password)
+	for _, pattern := range commonPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+
+	// Check for simple substitutions
+	normalized := leetNormalize(lower)
+
+	for _, pattern := range commonPatterns {
+		if strings.Contains(normalized, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// monthNames are matched case-insensitively anywhere in a password.
+var monthNames = []string{
+	"january", "february", "march", "april", "may", "june",
+	"july", "august", "september", "october", "november", "december",
+}
+
+// hasDatePattern reports whether password contains a month name or a run
+// of digits that reads as a date: exactly 4 digits forming a year from
+// 1900 to 2099, or exactly 8 forming an MMDDYYYY or DDMMYYYY date. Digits
+// that are part of a longer run, or split by letters as in "2x4y", do not
+// count.
+func hasDatePattern(password string) bool {
+	lower := strings.ToLower(password)
+	for _, month := range monthNames {
+		if strings.Contains(lower, month) {
+			return true
+		}
+	}
+
+	for _, run := range digitRuns(password) {
+		switch len(run) {
+		case 4:
+			if isYear(run) {
+				return true
+			}
+		case 8:
+			if isYear(run[4:]) && (isMonthDay(run[:2], run[2:4]) || isMonthDay(run[2:4], run[:2])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// digitRuns returns the maximal runs of ASCII digits in password.
+func digitRuns(password string) []string {
+	var runs []string
+	start := -1
+	for i := 0; i <= len(password); i++ {
+		if i < len(password) && isDigit(rune(password[i])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			runs = append(runs, password[start:i])
+			start = -1
+		}
+	}
+	return runs
+}
+
+// isYear reports whether the 4 digits of s are a year from 1900 to 2099.
+func isYear(s string) bool {
+	return s[:2] == "19" || s[:2] == "20"
+}
+
+// isMonthDay reports whether the 2-digit month and day can form a date.
+func isMonthDay(month, day string) bool {
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	return m >= 1 && m <= 12 && d >= 1 && d <= 31
+}
+
+func getStrengthLevel(score int) StrengthLevel {
+	switch {
+	case score < 20:
+		return VeryWeak
+	case score < 40:
+		return Weak
+	case score < 60:
+		return Fair
+	case score < 80:
+		return Good
+	case score < 95:
+		return Strong
+	default:
+		return VeryStrong
+	}
+}
+
+func estimateTimeToCrack(entropy, guessesPerSecond float64) string {
+	// Number of possible combinations
+	combinations := math.Pow(2, entropy)
+
+	// Average time to crack (half the search space)
+	secondsToCrack := combinations / (2 * guessesPerSecond)
+
+	return formatDuration(secondsToCrack)
+}
+
+func formatDuration(seconds float64) string {
+	if seconds < 1 {
+		return "Instant"
+	} else if seconds < 60 {
+		return fmt.Sprintf("%.0f seconds", seconds)
+	} else if seconds < 3600 {
+		return fmt.Sprintf("%.0f minutes", seconds/60)
+	} else if seconds < 86400 {
+		return fmt.Sprintf("%.0f hours", seconds/3600)
+	} else if seconds < 31536000 {
+		return fmt.Sprintf("%.0f days", seconds/86400)
+	} else if seconds < 31536000000 {
+		return fmt.Sprintf("%.0f years", seconds/31536000)
+	} else if seconds < 31536000000000 {
+		return fmt.Sprintf("%.0f thousand years", seconds/31536000000)
+	} else if seconds < 31536000000000000 {
+		return fmt.Sprintf("%.0f million years", seconds/31536000000000)
+	} else {
+		return fmt.Sprintf("%.0f billion years", seconds/31536000000000000)
+	}
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,101 @@
+package pwgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"unicode/utf8"
+)
+
+// distinctIndices returns n different indices in [0, size), uniformly
+// among all such selections, with a partial Fisher-Yates shuffle.
+func distinctIndices(random io.Reader, n, size int) ([]int, error) {
+	if n > size {
+		return nil, fmt.Errorf("cannot pick %d different characters from %d", n, size)
+	}
+
+	pool := make([]int, size)
+	for i := range pool {
+		pool[i] = i
+	}
+	for i := range n {
+		j, err := randomInt(random, size-i)
+		if err != nil {
+			return nil, err
+		}
+		pool[i], pool[i+j] = pool[i+j], pool[i]
+	}
+	return pool[:n], nil
+}
+
+// fillUnique is like fillCapped but never draws a character password
+// already contains, for Config.NoRepeat. password must hold exactly the
+// minimum count of each class.
+func fillUnique(random io.Reader, password []rune, config Config) ([]rune, error) {
+	classes := classLimits(config)
+	counts := make([]int, len(classes))
+	for i, class := range classes {
+		counts[i] = class.min
+	}
+
+	for len(password) < config.Length {
+		var allowed []rune
+		var owners []int
+		for i, class := range classes {
+			if class.max > 0 && counts[i] >= class.max {
+				continue
+			}
+			for _, char := range class.chars {
+				if !slices.Contains(password, char) {
+					allowed = append(allowed, char)
+					owners = append(owners, i)
+				}
+			}
+		}
+
+		if len(allowed) == 0 {
+			return password, fmt.Errorf("the charset has too few characters for a password of length %d without repeated characters", config.Length)
+		}
+
+		index, err := randomInt(random, len(allowed))
+		if err != nil {
+			return password, err
+		}
+		password = append(password, allowed[index])
+		counts[owners[index]]++
+	}
+
+	return password, nil
+}
+
+// noRepeatMultiplier is the fraction of the entropy of length independent
+// picks from size characters that remains when no character may repeat:
+// log2(size!/(size-length)!) over length*log2(size).
+func noRepeatMultiplier(size, length int) float64 {
+	if size <= 1 || length <= 1 || length > size {
+		return 1
+	}
+
+	entropy := 0.0
+	for i := range length {
+		entropy += math.Log2(float64(size - i))
+	}
+	return entropy / (float64(length) * math.Log2(float64(size)))
+}
+
+// noRepeatPenalty is the entropy lost to Config.NoRepeat for password,
+// drawn from charset or, when it is empty, from the classes present in
+// password as classCharSpace counts them.
+func noRepeatPenalty(password, charset string) (EntropyPenalty, bool) {
+	size := classCharSpace(password)
+	if charset != "" {
+		size = utf8.RuneCountInString(uniqueRunes(charset))
+	}
+
+	multiplier := noRepeatMultiplier(size, utf8.RuneCountInString(password))
+	if multiplier == 1 {
+		return EntropyPenalty{}, false
+	}
+	return EntropyPenalty{Name: "no repeated characters", Multiplier: multiplier}, true
+}
@@ -0,0 +1,105 @@
+package pwgen
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerateNoRepeat(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		// Length equal to the charset size leaves exactly one permutation of it
+		{"whole charset", Config{Length: 10, Charset: Digits, NoRepeat: true}},
+		{"class minimums", Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, MinDigits: 10, NoRepeat: true}},
+		{"class maximums", Config{Length: 30, IncludeLower: true, IncludeDigits: true, MaxDigits: 4, NoRepeat: true}},
+		{"unicode", Config{Length: 4, Charset: "äöüß", NoRepeat: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := NewSeededGenerator(tt.name)
+			for i := 0; i < 50; i++ {
+				password, err := generator.Generate(tt.config)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+
+				if utf8.RuneCountInString(password) != tt.config.Length || len(uniqueRunes(password)) != len(password) {
+					t.Fatalf("Generate() = %q, want %d characters without repeats", password, tt.config.Length)
+				}
+				if !meetsLimits(tt.config, []rune(password)) {
+					t.Fatalf("Generate() = %q, does not meet the class limits", password)
+				}
+			}
+		})
+	}
+
+	password, _ := Generate(Config{Length: 10, Charset: Digits, NoRepeat: true})
+	sorted := []rune(password)
+	slices.Sort(sorted)
+	if string(sorted) != Digits {
+		t.Errorf("Generate() = %q, want a permutation of %q", password, Digits)
+	}
+}
+
+func TestValidateConfigNoRepeat(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"longer than the charset", Config{Length: 11, Charset: Digits, NoRepeat: true}, "needs at least 11 different characters, but the charset has 10"},
+		{"class minimum", Config{Length: 12, IncludeLower: true, IncludeDigits: true, MinDigits: 11, NoRepeat: true}, "needs 11 different digits"},
+		{"class maximums", Config{Length: 12, Charset: "abc0123456789", MaxDigits: 5, NoRepeat: true}, "allow at most 8 characters"},
+		{"alternating", Config{Length: 8, IncludeLower: true, IncludeDigits: true, AlternateClasses: true, NoRepeat: true}, "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoRepeatEntropy(t *testing.T) {
+	// 10 digits without repeats: log2(10!) bits instead of 10 × log2(10)
+	want := math.Log2(3628800)
+	got := ExplainEntropy("7203948156", AnalysisOptions{Charset: Digits, NoRepeat: true})
+	if math.Abs(got.Entropy-want) > 0.001 {
+		t.Errorf("ExplainEntropy() Entropy = %.3f, want %.3f", got.Entropy, want)
+	}
+	if best := maxEntropy(Config{Length: 10, Charset: Digits, NoRepeat: true}); math.Abs(best-want) > 0.001 {
+		t.Errorf("maxEntropy() = %.3f, want %.3f", best, want)
+	}
+}
+
+func TestPolicyNoRepeatedChars(t *testing.T) {
+	policy := PasswordPolicy{Name: "Codes", NoRepeatedChars: true}
+
+	if violations := ValidatePasswordAgainstPolicy("K7x!q2Zm", policy); len(violations) != 0 {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v for distinct characters", violations)
+	}
+	violations := ValidatePasswordAgainstPolicy("K7x!q2Zk7", policy)
+	if len(violations) != 1 || violations[0].Rule != "NoRepeatedChars" {
+		t.Errorf("ValidatePasswordAgainstPolicy() = %v, want a NoRepeatedChars violation", violations)
+	}
+
+	var config Config
+	ApplyPolicyToConfig(policy, &config)
+	if !config.NoRepeat {
+		t.Error("ApplyPolicyToConfig() should set NoRepeat")
+	}
+
+	problems := CheckPolicy(PasswordPolicy{Name: "Codes", MinDigits: 11, NoRepeatedChars: true})
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "cannot be met without repeated characters") {
+		t.Errorf("CheckPolicy() = %v, want the digit minimum reported", problems)
+	}
+}
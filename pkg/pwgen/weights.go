@@ -0,0 +1,192 @@
+package pwgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ClassWeights biases how often each character class is picked: each
+// character first picks a class with probability proportional to its
+// weight, then a character of that class uniformly. The zero value picks
+// uniformly over the whole charset instead. A class weighted 0 is only
+// used for its minimum count.
+//
+// Weighting costs entropy unless each class's weight is proportional to
+// its size, which is what uniform selection amounts to.
+type ClassWeights struct {
+	Upper   int
+	Lower   int
+	Digits  int
+	Symbols int
+}
+
+// IsZero reports whether w leaves selection uniform.
+func (w ClassWeights) IsZero() bool {
+	return w == ClassWeights{}
+}
+
+// String formats w the way ParseClassWeights reads it.
+func (w ClassWeights) String() string {
+	return fmt.Sprintf("upper=%d,lower=%d,digit=%d,symbol=%d", w.Upper, w.Lower, w.Digits, w.Symbols)
+}
+
+// list returns the weights in the order of classLimits.
+func (w ClassWeights) list() []int {
+	return []int{w.Upper, w.Lower, w.Digits, w.Symbols}
+}
+
+// ParseClassWeights reads weights such as "upper=1,lower=1,digit=2,symbol=3".
+// Classes left out weigh 1.
+func ParseClassWeights(value string) (ClassWeights, error) {
+	weights := ClassWeights{Upper: 1, Lower: 1, Digits: 1, Symbols: 1}
+
+	for _, item := range strings.Split(value, ",") {
+		name, number, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			return ClassWeights{}, fmt.Errorf("invalid class weight '%s': use class=weight, e.g. symbol=3", item)
+		}
+
+		weight, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || weight < 0 {
+			return ClassWeights{}, fmt.Errorf("invalid class weight '%s': the weight must be a whole number of 0 or more", item)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "upper":
+			weights.Upper = weight
+		case "lower":
+			weights.Lower = weight
+		case "digit", "digits":
+			weights.Digits = weight
+		case "symbol", "symbols":
+			weights.Symbols = weight
+		default:
+			return ClassWeights{}, fmt.Errorf("unknown character class '%s' (available: upper, lower, digit, symbol)", name)
+		}
+	}
+
+	if weights.IsZero() {
+		return ClassWeights{}, fmt.Errorf("at least one class weight must be more than 0")
+	}
+
+	return weights, nil
+}
+
+// fillWeighted is like fillCapped but picks a class by config.ClassWeights
+// before a character within it. password must hold exactly the minimum
+// count of each class.
+func fillWeighted(random io.Reader, password []rune, config Config) ([]rune, error) {
+	classes := classLimits(config)
+	weights := config.ClassWeights.list()
+	counts := make([]int, len(classes))
+	for i, class := range classes {
+		counts[i] = class.min
+	}
+
+	for len(password) < config.Length {
+		var open []int
+		total := 0
+		for i, class := range classes {
+			if class.chars == "" || weights[i] == 0 || (class.max > 0 && counts[i] >= class.max) {
+				continue
+			}
+			open = append(open, i)
+			total += weights[i]
+		}
+
+		if len(open) == 0 {
+			return password, fmt.Errorf("class weights and maximum character counts leave no characters for a password of length %d", config.Length)
+		}
+
+		index, err := randomInt(random, total)
+		if err != nil {
+			return password, err
+		}
+
+		chosen := open[len(open)-1]
+		for _, c := range open {
+			if index < weights[c] {
+				chosen = c
+				break
+			}
+			index -= weights[c]
+		}
+
+		chars := []rune(classes[chosen].chars)
+		index, err = randomInt(random, len(chars))
+		if err != nil {
+			return password, err
+		}
+		password = append(password, chars[index])
+		counts[chosen]++
+	}
+
+	return password, nil
+}
+
+// weightedEntropy is the entropy in bits of one character picked as
+// fillWeighted does, given the size of each class: the entropy of the
+// class choice plus that of the character within it.
+func weightedEntropy(sizes, weights []int) float64 {
+	total := 0
+	for c, size := range sizes {
+		if size > 0 {
+			total += weights[c]
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	entropy := 0.0
+	for c, size := range sizes {
+		if size == 0 || weights[c] == 0 {
+			continue
+		}
+		p := float64(weights[c]) / float64(total)
+		entropy += p * (math.Log2(float64(size)) - math.Log2(p))
+	}
+	return entropy
+}
+
+// weightMultiplier is the fraction of the entropy of a uniform pick from
+// all the classes that remains when picking with weights. It is 1 when the
+// weights are proportional to the class sizes.
+func weightMultiplier(sizes []int, weights ClassWeights) float64 {
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	if total <= 1 || weights.IsZero() {
+		return 1
+	}
+
+	return min(1, weightedEntropy(sizes, weights.list())/math.Log2(float64(total)))
+}
+
+// weightPenalty is the entropy lost to Config.ClassWeights for password,
+// drawn from charset or, when it is empty, from the full classes present
+// in password as classCharSpace counts them.
+func weightPenalty(password, charset string, weights ClassWeights) (EntropyPenalty, bool) {
+	var sizes []int
+	if charset != "" {
+		sizes = classSizes(charset)
+	} else {
+		sizes = classSizes(password)
+		for c, size := range []int{26, 26, 10, 32} {
+			if sizes[c] > 0 {
+				sizes[c] = size
+			}
+		}
+	}
+
+	multiplier := weightMultiplier(sizes, weights)
+	if multiplier > 1-1e-9 || utf8.RuneCountInString(password) == 0 {
+		return EntropyPenalty{}, false
+	}
+	return EntropyPenalty{Name: "weighted character classes", Multiplier: multiplier}, true
+}
@@ -0,0 +1,128 @@
+package pwgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseClassWeights(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ClassWeights
+		wantErr bool
+	}{
+		{value: "upper=1,lower=1,digit=2,symbol=3", want: ClassWeights{1, 1, 2, 3}},
+		{value: "symbols=4", want: ClassWeights{1, 1, 1, 4}},
+		{value: " Digits = 0 , upper=2", want: ClassWeights{2, 1, 0, 1}},
+		{value: "symbol", wantErr: true},
+		{value: "symbol=-1", wantErr: true},
+		{value: "vowel=2", wantErr: true},
+		{value: "upper=0,lower=0,digit=0,symbol=0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseClassWeights(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseClassWeights() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseClassWeights() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateClassWeights(t *testing.T) {
+	// Symbols make up a third of the charset but should fill three quarters
+	// of the password with these weights
+	config := Config{Length: 100, IncludeLower: true, IncludeSymbols: true, ClassWeights: ClassWeights{Lower: 1, Symbols: 3}}
+	generator := NewSeededGenerator("class-weights")
+
+	symbols, total := 0, 0
+	for i := 0; i < 50; i++ {
+		password, err := generator.Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		for _, char := range password {
+			if isSymbol(char) {
+				symbols++
+			}
+			total++
+		}
+	}
+
+	if share := float64(symbols) / float64(total); math.Abs(share-0.75) > 0.03 {
+		t.Errorf("symbols make up %.3f of the passwords, want about 0.75", share)
+	}
+}
+
+func TestGenerateClassWeightsZeroKeepsMinimum(t *testing.T) {
+	config := Config{Length: 12, IncludeLower: true, IncludeDigits: true, MinDigits: 2, ClassWeights: ClassWeights{Lower: 1}}
+
+	for i := 0; i < 50; i++ {
+		password, err := Generate(config)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		digits := 0
+		for _, char := range password {
+			if isDigit(char) {
+				digits++
+			}
+		}
+		if digits != 2 {
+			t.Fatalf("Generate() = %q, want exactly the 2 required digits", password)
+		}
+	}
+}
+
+func TestValidateConfigClassWeights(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"negative", Config{Length: 12, IncludeLower: true, ClassWeights: ClassWeights{Lower: -1, Digits: 2}}},
+		{"alternating", Config{Length: 12, IncludeLower: true, IncludeDigits: true, AlternateClasses: true, ClassWeights: ClassWeights{Lower: 1, Digits: 2}}},
+		{"only zero weights present", Config{Length: 12, IncludeLower: true, ClassWeights: ClassWeights{Digits: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateConfig(tt.config); err == nil {
+				t.Error("ValidateConfig() should reject these class weights")
+			}
+		})
+	}
+}
+
+func TestWeightMultiplier(t *testing.T) {
+	sizes := []int{26, 26, 10, 32}
+
+	// Weights proportional to the class sizes are uniform selection
+	if got := weightMultiplier(sizes, ClassWeights{26, 26, 10, 32}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("weightMultiplier() for proportional weights = %v, want 1", got)
+	}
+
+	// Equal weights over a lower and a digit class: 1 bit for the class,
+	// then log2(26) or log2(10) bits, against log2(36) uniformly
+	want := (1 + (math.Log2(26)+math.Log2(10))/2) / math.Log2(36)
+	if got := weightMultiplier([]int{0, 26, 10, 0}, ClassWeights{1, 1, 1, 1}); math.Abs(got-want) > 1e-9 {
+		t.Errorf("weightMultiplier() = %.4f, want %.4f", got, want)
+	}
+
+	if got := weightMultiplier(sizes, ClassWeights{}); got != 1 {
+		t.Errorf("weightMultiplier() without weights = %v, want 1", got)
+	}
+}
+
+func TestMaxEntropyClassWeights(t *testing.T) {
+	config := Config{Length: 16, IncludeUpper: true, IncludeLower: true, IncludeDigits: true, IncludeSymbols: true}
+	plain := maxEntropy(config)
+
+	config.ClassWeights = ClassWeights{1, 1, 2, 3}
+	if got := maxEntropy(config); got >= plain {
+		t.Errorf("maxEntropy() with ClassWeights = %.1f, want less than %.1f", got, plain)
+	}
+}
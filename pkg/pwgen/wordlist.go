@@ -0,0 +1,96 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// minWordlistSize is the fewest distinct words a custom wordlist may hold.
+// A single word gives a passphrase no entropy at all.
+const minWordlistSize = 2
+
+// ParseWordlist decodes a newline-delimited wordlist for
+// PassphraseConfig.Wordlist. Surrounding whitespace is trimmed, blank lines
+// are skipped and a repeated word is kept once, since it would otherwise be
+// drawn more often and inflate the entropy estimate. Fewer than two
+// distinct words is an error.
+func ParseWordlist(data []byte) ([]string, error) {
+	var words []string
+	seen := make(map[string]bool)
+	for line := range strings.Lines(string(data)) {
+		word := strings.TrimSpace(line)
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+
+	if len(words) < minWordlistSize {
+		return nil, fmt.Errorf("wordlist must contain at least %d distinct words, got %d", minWordlistSize, len(words))
+	}
+	return words, nil
+}
+
+// LoadWordlist reads a wordlist from a file, see ParseWordlist.
+func LoadWordlist(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	words, err := ParseWordlist(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return words, nil
+}
+
+// validateWordlist rejects a PassphraseConfig.Wordlist that ParseWordlist
+// would not return: too few words, blank words or repeated words.
+func validateWordlist(words []string) error {
+	if len(words) < minWordlistSize {
+		return fmt.Errorf("wordlist must contain at least %d distinct words, got %d", minWordlistSize, len(words))
+	}
+
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		if strings.TrimSpace(word) == "" {
+			return fmt.Errorf("wordlist must not contain blank words")
+		}
+		if seen[word] {
+			return fmt.Errorf("wordlist repeats the word %q", word)
+		}
+		seen[word] = true
+	}
+	return nil
+}
+
+// passphraseWords returns the wordlist config draws from: its Wordlist, or
+// the EFF large wordlist when it has none.
+func passphraseWords(config PassphraseConfig) []string {
+	if config.Wordlist != nil {
+		return config.Wordlist
+	}
+	return wordlist
+}
+
+// PassphraseEntropy returns the entropy in bits of a passphrase generated
+// from config: log2 of the wordlist size for every word, plus log2 of the
+// number of separators for every gap between words when they are drawn
+// from PassphraseConfig.Separators. Capitalization adds nothing, since it
+// applies to every word.
+func PassphraseEntropy(config PassphraseConfig) float64 {
+	if config.Words < 1 {
+		return 0
+	}
+
+	entropy := float64(config.Words) * math.Log2(float64(len(passphraseWords(config))))
+	if separators := utf8.RuneCountInString(uniqueRunes(config.Separators)); separators > 1 {
+		entropy += float64(config.Words-1) * math.Log2(float64(separators))
+	}
+	return entropy
+}
@@ -0,0 +1,129 @@
+package pwgen
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseWordlist(t *testing.T) {
+	words, err := ParseWordlist([]byte("alpha\r\nbeta\n\n  gamma \nbeta\nalpha"))
+	if err != nil {
+		t.Fatalf("ParseWordlist() error = %v", err)
+	}
+	if want := []string{"alpha", "beta", "gamma"}; !slices.Equal(words, want) {
+		t.Errorf("ParseWordlist() = %q, want %q", words, want)
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"blank lines", "\n  \n\n"},
+		{"single word", "solo\n"},
+		{"single word repeated", "solo\nsolo\n solo\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWordlist([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), "at least 2 distinct words") {
+				t.Errorf("ParseWordlist() error = %v, want the list rejected", err)
+			}
+		})
+	}
+}
+
+func TestLoadWordlist(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(path, []byte("red\ngreen\nblue\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := LoadWordlist(path)
+	if err != nil || len(words) != 3 {
+		t.Errorf("LoadWordlist() = %q, %v, want 3 words", words, err)
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWordlist(empty); err == nil || !strings.Contains(err.Error(), empty) {
+		t.Errorf("LoadWordlist() error = %v, want it to name the file", err)
+	}
+	if _, err := LoadWordlist(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("LoadWordlist() should fail for a missing file")
+	}
+}
+
+func TestGeneratePassphraseCustom(t *testing.T) {
+	list := []string{"red", "green", "blue", "éclair"}
+	config := PassphraseConfig{Words: 5, Wordlist: list, Separators: "0123456789", Capitalize: true}
+	generator := NewSeededGenerator("custom passphrase")
+
+	separators := make(map[rune]bool)
+	for i := 0; i < 50; i++ {
+		passphrase, err := generator.GeneratePassphrase(config)
+		if err != nil {
+			t.Fatalf("GeneratePassphrase() error = %v", err)
+		}
+
+		words := strings.FieldsFunc(passphrase, func(r rune) bool {
+			if isDigit(r) {
+				separators[r] = true
+				return true
+			}
+			return false
+		})
+		if len(words) != config.Words {
+			t.Fatalf("GeneratePassphrase() = %q, want %d words", passphrase, config.Words)
+		}
+		for _, word := range words {
+			if !slices.Contains(list, strings.ToLower(word)) || strings.ToLower(word) == word {
+				t.Fatalf("GeneratePassphrase() = %q, has %q, want capitalized words of the list", passphrase, word)
+			}
+		}
+	}
+
+	// Separators are drawn at random rather than fixed
+	if len(separators) < 5 {
+		t.Errorf("GeneratePassphrase() used %d distinct separators, want most of the 10", len(separators))
+	}
+}
+
+func TestPassphraseEntropyFromConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PassphraseConfig
+		want   float64
+	}{
+		{"built-in list", PassphraseConfig{Words: 4, Separator: "-"}, 4 * math.Log2(7776)},
+		{"custom list", PassphraseConfig{Words: 3, Wordlist: []string{"a", "b", "c", "d"}}, 6},
+		{"random separators", PassphraseConfig{Words: 4, Wordlist: []string{"a", "b"}, Separators: "0123456789"}, 4 + 3*math.Log2(10)},
+		{"repeated separators count once", PassphraseConfig{Words: 3, Wordlist: []string{"a", "b"}, Separators: "!!??"}, 3 + 2},
+		{"single separator", PassphraseConfig{Words: 3, Wordlist: []string{"a", "b"}, Separators: "."}, 3},
+		{"no words", PassphraseConfig{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PassphraseEntropy(tt.config); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("PassphraseEntropy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The analysis uses it when told how the passphrase was generated,
+	// even for words and separators it would not recognize
+	config := PassphraseConfig{Words: 4, Wordlist: []string{"zq", "xv"}, Separators: "0123456789"}
+	breakdown := ExplainEntropy("zq3xv7zq0xv", AnalysisOptions{Passphrase: &config, PassphraseDictSize: 100})
+	if breakdown.Method != "passphrase" || breakdown.CharSpace != 2 || breakdown.Separators != 10 || breakdown.Entropy != PassphraseEntropy(config) {
+		t.Errorf("ExplainEntropy() = %+v, want the passphrase method from the config", breakdown)
+	}
+}
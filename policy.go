@@ -1,331 +1,177 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
-	"regexp"
+	"io"
+	"os"
 	"strings"
-)
-
-type PasswordPolicy struct {
-	Name              string   `yaml:"name"`
-	Description       string   `yaml:"description"`
-	MinLength         int      `yaml:"min_length"`
-	MaxLength         int      `yaml:"max_length"`
-	RequireUpper      bool     `yaml:"require_upper"`
-	RequireLower      bool     `yaml:"require_lower"`
-	RequireDigits     bool     `yaml:"require_digits"`
-	RequireSymbols    bool     `yaml:"require_symbols"`
-	MinUpper          int      `yaml:"min_upper"`
-	MinLower          int      `yaml:"min_lower"`
-	MinDigits         int      `yaml:"min_digits"`
-	MinSymbols        int      `yaml:"min_symbols"`
-	ExcludeAmbiguous  bool     `yaml:"exclude_ambiguous"`
-	ForbiddenChars    string   `yaml:"forbidden_chars"`
-	ForbiddenPatterns []string `yaml:"forbidden_patterns"`
-	MinEntropy        float64  `yaml:"min_entropy"`
-}
 
-type PolicyViolation struct {
-	Rule        string
-	Description string
-}
-
-var BuiltinPolicies = map[string]PasswordPolicy{
-	"basic": {
-		Name:              "Basic Security",
-		Description:       "Minimal security requirements suitable for low-risk applications",
-		MinLength:         8,
-		MaxLength:         0, // No limit
-		RequireUpper:      true,
-		RequireLower:      true,
-		RequireDigits:     true,
-		RequireSymbols:    false,
-		MinUpper:          1,
-		MinLower:          1,
-		MinDigits:         1,
-		MinSymbols:        0,
-		ExcludeAmbiguous:  false,
-		ForbiddenChars:    "",
-		ForbiddenPatterns: []string{"password", "123456", "qwerty"},
-		MinEntropy:        25,
-	},
-	"corporate": {
-		Name:              "Corporate Standard",
-		Description:       "Standard corporate password policy with moderate security",
-		MinLength:         12,
-		MaxLength:         0,
-		RequireUpper:      true,
-		RequireLower:      true,
-		RequireDigits:     true,
-		RequireSymbols:    true,
-		MinUpper:          2,
-		MinLower:          2,
-		MinDigits:         2,
-		MinSymbols:        1,
-		ExcludeAmbiguous:  true,
-		ForbiddenChars:    "",
-		ForbiddenPatterns: []string{"password", "123456", "qwerty", "admin", "login", "welcome"},
-		MinEntropy:        40,
-	},
-	"high-security": {
-		Name:             "High Security",
-		Description:      "Stringent requirements for high-security environments",
-		MinLength:        16,
-		MaxLength:        0,
-		RequireUpper:     true,
-		RequireLower:     true,
-		RequireDigits:    true,
-		RequireSymbols:   true,
-		MinUpper:         3,
-		MinLower:         3,
-		MinDigits:        3,
-		MinSymbols:       2,
-		ExcludeAmbiguous: true,
-		ForbiddenChars:   "",
-		ForbiddenPatterns: []string{
-			"password", "123456", "qwerty", "admin", "login", "welcome",
-			"letmein", "monkey", "dragon", "master", "shadow", "football",
-		},
-		MinEntropy: 60,
-	},
-	"aws": {
-		Name:              "AWS IAM Policy",
-		Description:       "Meets AWS IAM password policy requirements",
-		MinLength:         8,
-		MaxLength:         128,
-		RequireUpper:      true,
-		RequireLower:      true,
-		RequireDigits:     true,
-		RequireSymbols:    true,
-		MinUpper:          1,
-		MinLower:          1,
-		MinDigits:         1,
-		MinSymbols:        1,
-		ExcludeAmbiguous:  false,
-		ForbiddenChars:    "",
-		ForbiddenPatterns: []string{},
-		MinEntropy:        30,
-	},
-	"azure": {
-		Name:              "Azure AD Policy",
-		Description:       "Meets Azure AD password complexity requirements",
-		MinLength:         8,
-		MaxLength:         256,
-		RequireUpper:      true,
-		RequireLower:      true,
-		RequireDigits:     true,
-		RequireSymbols:    true,
-		MinUpper:          1,
-		MinLower:          1,
-		MinDigits:         1,
-		MinSymbols:        1,
-		ExcludeAmbiguous:  false,
-		ForbiddenChars:    "",
-		ForbiddenPatterns: []string{},
-		MinEntropy:        35,
-	},
-	"pci-dss": {
-		Name:              "PCI DSS Compliant",
-		Description:       "Meets PCI DSS password requirements for payment systems",
-		MinLength:         7,
-		MaxLength:         0,
-		RequireUpper:      true,
-		RequireLower:      true,
-		RequireDigits:     true,
-		RequireSymbols:    false,
-		MinUpper:          1,
-		MinLower:          1,
-		MinDigits:         1,
-		MinSymbols:        0,
-		ExcludeAmbiguous:  false,
-		ForbiddenChars:    "",
-		ForbiddenPatterns: []string{},
-		MinEntropy:        28,
-	},
-}
+	"github.com/romdj/password-generator/pkg/pwgen"
+	"gopkg.in/yaml.v3"
+)
 
-func GetPolicy(name string) (PasswordPolicy, error) {
-	if policy, exists := BuiltinPolicies[name]; exists {
-		return policy, nil
+// runPolicyCommand runs "pwgen policy validate FILE...", which lints each
+// custom policy file with the checks pwgen.LoadPolicyFile applies and lists
+// every problem found. It returns the exit status: 0 when every file is
+// sound, 1 when any has problems and 2 for a usage error.
+func runPolicyCommand(stdout, stderr io.Writer, args []string) int {
+	if len(args) < 2 || args[0] != "validate" {
+		fmt.Fprintf(stderr, "Usage: %s policy validate path.yaml...\n", os.Args[0])
+		return 2
 	}
-	return PasswordPolicy{}, fmt.Errorf("policy '%s' not found", name)
-}
 
-func ListPolicies() []string {
-	var policies []string
-	for name := range BuiltinPolicies {
-		policies = append(policies, name)
+	status := 0
+	for _, path := range args[1:] {
+		if !validatePolicyFile(stdout, path) {
+			status = 1
+		}
 	}
-	return policies
+	return status
 }
 
-func ValidatePasswordAgainstPolicy(password string, policy PasswordPolicy) []PolicyViolation {
-	var violations []PolicyViolation
-
-	// Length checks
-	if len(password) < policy.MinLength {
-		violations = append(violations, PolicyViolation{
-			Rule:        "MinLength",
-			Description: fmt.Sprintf("Password must be at least %d characters long", policy.MinLength),
-		})
-	}
-
-	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
-		violations = append(violations, PolicyViolation{
-			Rule:        "MaxLength",
-			Description: fmt.Sprintf("Password must not exceed %d characters", policy.MaxLength),
-		})
-	}
-
-	// Character type requirements
-	upperCount := countMatches(password, `[A-Z]`)
-	lowerCount := countMatches(password, `[a-z]`)
-	digitCount := countMatches(password, `[0-9]`)
-	symbolCount := countMatches(password, `[^a-zA-Z0-9]`)
-
-	if policy.RequireUpper && upperCount == 0 {
-		violations = append(violations, PolicyViolation{
-			Rule:        "RequireUpper",
-			Description: "Password must contain at least one uppercase letter",
-		})
-	}
-
-	if policy.RequireLower && lowerCount == 0 {
-		violations = append(violations, PolicyViolation{
-			Rule:        "RequireLower",
-			Description: "Password must contain at least one lowercase letter",
-		})
-	}
-
-	if policy.RequireDigits && digitCount == 0 {
-		violations = append(violations, PolicyViolation{
-			Rule:        "RequireDigits",
-			Description: "Password must contain at least one digit",
-		})
-	}
-
-	if policy.RequireSymbols && symbolCount == 0 {
-		violations = append(violations, PolicyViolation{
-			Rule:        "RequireSymbols",
-			Description: "Password must contain at least one symbol",
-		})
+// validatePolicyFile reports whether the policy in path is sound, writing
+// either a one-line verdict or the list of its problems to w.
+func validatePolicyFile(w io.Writer, path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %v\n", path, err)
+		return false
 	}
 
-	// Minimum character counts
-	if upperCount < policy.MinUpper {
-		violations = append(violations, PolicyViolation{
-			Rule:        "MinUpper",
-			Description: fmt.Sprintf("Password must contain at least %d uppercase letters", policy.MinUpper),
-		})
+	policy, err := pwgen.ParsePolicy(data)
+	if err != nil {
+		fmt.Fprintf(w, "%s: invalid policy YAML: %v\n", path, err)
+		return false
 	}
 
-	if lowerCount < policy.MinLower {
-		violations = append(violations, PolicyViolation{
-			Rule:        "MinLower",
-			Description: fmt.Sprintf("Password must contain at least %d lowercase letters", policy.MinLower),
-		})
+	problems := pwgen.CheckPolicy(policy)
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s: ✓ %s is valid\n", path, policy.Name)
+		return true
 	}
 
-	if digitCount < policy.MinDigits {
-		violations = append(violations, PolicyViolation{
-			Rule:        "MinDigits",
-			Description: fmt.Sprintf("Password must contain at least %d digits", policy.MinDigits),
-		})
+	if len(problems) == 1 {
+		fmt.Fprintf(w, "%s: ✗ 1 problem:\n", path)
+	} else {
+		fmt.Fprintf(w, "%s: ✗ %d problems:\n", path, len(problems))
 	}
-
-	if symbolCount < policy.MinSymbols {
-		violations = append(violations, PolicyViolation{
-			Rule:        "MinSymbols",
-			Description: fmt.Sprintf("Password must contain at least %d symbols", policy.MinSymbols),
-		})
+	for _, problem := range problems {
+		fmt.Fprintf(w, "  - %s\n", problem)
 	}
+	return false
+}
 
-	// Ambiguous character check
-	if policy.ExcludeAmbiguous {
-		ambiguous := "0O1lI"
-		for _, char := range ambiguous {
-			if strings.ContainsRune(password, char) {
-				violations = append(violations, PolicyViolation{
-					Rule:        "ExcludeAmbiguous",
-					Description: fmt.Sprintf("Password must not contain ambiguous characters (%s)", ambiguous),
-				})
-				break
-			}
-		}
+// registerPolicyDir makes every policy file in dir available to --policy
+// and --list-policies under its file name. Files that fail to load are
+// skipped with a warning, as are policies replacing one of the same name;
+// files are read in name order, so the last one wins.
+func registerPolicyDir(stderr io.Writer, dir string) error {
+	policies, skipped, err := pwgen.LoadPolicyDir(dir)
+	if err != nil {
+		return err
 	}
 
-	// Forbidden characters
-	if policy.ForbiddenChars != "" {
-		for _, char := range policy.ForbiddenChars {
-			if strings.ContainsRune(password, char) {
-				violations = append(violations, PolicyViolation{
-					Rule:        "ForbiddenChars",
-					Description: fmt.Sprintf("Password must not contain forbidden character '%c'", char),
-				})
-			}
-		}
+	for _, err := range skipped {
+		fmt.Fprintf(stderr, "Warning: skipped %s\n", strings.ReplaceAll(err.Error(), "\n", "\n  "))
 	}
 
-	// Forbidden patterns
-	lower := strings.ToLower(password)
-	for _, pattern := range policy.ForbiddenPatterns {
-		if strings.Contains(lower, strings.ToLower(pattern)) {
-			violations = append(violations, PolicyViolation{
-				Rule:        "ForbiddenPatterns",
-				Description: fmt.Sprintf("Password must not contain forbidden pattern '%s'", pattern),
-			})
+	for _, file := range policies {
+		if pwgen.RegisterPolicy(file.Key, file.Policy) {
+			fmt.Fprintf(stderr, "Warning: %s replaces the %s policy\n", file.Path, file.Key)
 		}
 	}
-
-	// Entropy check
-	if policy.MinEntropy > 0 {
-		entropy := calculateEntropy(password)
-		if entropy < policy.MinEntropy {
-			violations = append(violations, PolicyViolation{
-				Rule:        "MinEntropy",
-				Description: fmt.Sprintf("Password entropy (%.1f bits) must be at least %.1f bits", entropy, policy.MinEntropy),
-			})
-		}
-	}
-
-	return violations
+	return nil
 }
 
-func countMatches(text, pattern string) int {
-	re := regexp.MustCompile(pattern)
-	matches := re.FindAllString(text, -1)
-	return len(matches)
+// policyFieldComments explains each key of a policy file, by the yaml tag
+// of its PasswordPolicy field, for SavePolicyExample.
+var policyFieldComments = map[string]string{
+	"name":                        "Shown in validation results and --list-policies",
+	"description":                 "One line describing the policy, shown by --list-policies",
+	"min_length":                  "Minimum password length in characters",
+	"max_length":                  "Maximum password length in characters, 0 for no limit",
+	"require_upper":               "Require at least one uppercase letter (A-Z)",
+	"require_lower":               "Require at least one lowercase letter (a-z)",
+	"require_digits":              "Require at least one digit (0-9)",
+	"require_symbols":             "Require at least one symbol, any character other than an ASCII letter or digit",
+	"min_upper":                   "Minimum number of uppercase letters, guaranteed when generating",
+	"min_lower":                   "Minimum number of lowercase letters",
+	"min_digits":                  "Minimum number of digits",
+	"min_symbols":                 "Minimum number of symbols",
+	"max_upper":                   "Maximum number of uppercase letters, 0 for no limit",
+	"max_lower":                   "Maximum number of lowercase letters, 0 for no limit",
+	"max_digits":                  "Maximum number of digits, 0 for no limit",
+	"max_symbols":                 "Maximum number of symbols, 0 for no limit",
+	"min_character_classes":       "Require this many of the 4 classes (uppercase, lowercase, digits, symbols), e.g. 3 for \"3 of 4\", 0 disables",
+	"exclude_ambiguous":           "Reject look-alike characters such as 0, O, 1, l and I",
+	"ambiguous_chars":             "The look-alike characters exclude_ambiguous rejects, empty for the default set",
+	"forbidden_chars":             "Characters a password must never contain",
+	"allowed_symbols":             "When set, the only symbols a password may contain, empty allows any",
+	"forbidden_patterns":          "Substrings a password must not contain, compared case-insensitively",
+	"max_similarity_to_forbidden": "From 0 to 1: reject passwords this similar to a forbidden pattern after l33t normalization, 0 disables",
+	"min_entropy":                 "Minimum entropy in bits, 0 disables",
+	"max_consecutive_repeat":      "Longest run of one repeated character, e.g. 2 rejects \"aaa\", 0 allows any",
+	"forbid_dictionary_words":     "Reject embedded English words of at least this many letters, even in l33t, 0 disables",
+	"forbid_dates":                "Reject years from 1900 to 2099, MMDDYYYY and DDMMYYYY dates and month names, as in \"Summer2024\"",
+	"no_repeated_chars":           "Reject passwords using any character more than once, e.g. for short codes",
+	"forbid_repeated_substrings":  "Reject a group of characters repeated back-to-back, as in \"abcabc\" or \"xyxyxy\"",
+	"max_same_class_run":          "Longest run of characters of one class, e.g. 3 rejects \"1234\", 0 allows any",
+	"forbid_zero_width":           "Reject invisible characters such as zero-width spaces, joiners and bidi marks",
+	"min_distinct_chars":          "Minimum number of different characters, e.g. 6 rejects \"abababab\", 0 disables",
 }
 
-func ApplyPolicyToConfig(policy PasswordPolicy, config *PasswordConfig) {
-	// Adjust length to meet minimum requirements
-	if config.Length < policy.MinLength {
-		config.Length = policy.MinLength
-	}
-
-	if policy.MaxLength > 0 && config.Length > policy.MaxLength {
-		config.Length = policy.MaxLength
-	}
-
-	// Enable required character types
-	if policy.RequireUpper {
-		config.IncludeUpper = true
-	}
-
-	if policy.RequireLower {
-		config.IncludeLower = true
-	}
-
-	if policy.RequireDigits {
-		config.IncludeDigits = true
-	}
-
-	if policy.RequireSymbols {
-		config.IncludeSymbols = true
-	}
-
-	// Apply ambiguous character exclusion
-	if policy.ExcludeAmbiguous {
-		config.ExcludeAmbiguous = true
-	}
+// SavePolicyExample writes an example custom policy to path, with a
+// comment above every key explaining it. The file passes "pwgen policy
+// validate" as is, and is a starting point for --policy-dir.
+func SavePolicyExample(path string) error {
+	policy := pwgen.PasswordPolicy{
+		Name:                     "Team Policy",
+		Description:              "Example custom policy, adjust it to your requirements",
+		MinLength:                14,
+		MaxLength:                64,
+		RequireUpper:             true,
+		RequireLower:             true,
+		RequireDigits:            true,
+		RequireSymbols:           true,
+		MinUpper:                 1,
+		MinLower:                 1,
+		MinDigits:                1,
+		MinSymbols:               1,
+		MinCharacterClasses:      4,
+		ExcludeAmbiguous:         false,
+		AllowedSymbols:           "!@#$%^&*-_=+",
+		ForbiddenPatterns:        []string{"password", "qwerty", "123456", "letmein"},
+		MaxSimilarityToForbidden: 0.75,
+		MinEntropy:               60,
+		MaxConsecutiveRepeat:     2,
+		ForbidDictionaryWords:    5,
+		ForbidDates:              true,
+		ForbidRepeatedSubstrings: true,
+		ForbidZeroWidth:          true,
+		MinDistinctChars:         8,
+	}
+
+	var node yaml.Node
+	if err := node.Encode(policy); err != nil {
+		return fmt.Errorf("failed to marshal policy: %w", err)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		node.Content[i].HeadComment = policyFieldComments[node.Content[i].Value]
+	}
+
+	var data bytes.Buffer
+	data.WriteString(`# Password Policy
+# Place this file in a --policy-dir directory to select it with --policy by
+# its file name, and check it with "pwgen policy validate" after editing.
+# Keys left out keep their zero value, which disables the rule
+
+`)
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to marshal policy: %w", err)
+	}
+
+	return os.WriteFile(path, data.Bytes(), 0644)
 }
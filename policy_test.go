@@ -1,353 +1,171 @@
 package main
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
-)
 
-func TestGetPolicy(t *testing.T) {
-	tests := []struct {
-		name       string
-		policyName string
-		wantErr    bool
-		wantMinLen int
-	}{
-		{
-			name:       "basic policy",
-			policyName: "basic",
-			wantErr:    false,
-			wantMinLen: 8,
-		},
-		{
-			name:       "corporate policy",
-			policyName: "corporate",
-			wantErr:    false,
-			wantMinLen: 12,
-		},
-		{
-			name:       "high-security policy",
-			policyName: "high-security",
-			wantErr:    false,
-			wantMinLen: 16,
-		},
-		{
-			name:       "nonexistent policy",
-			policyName: "nonexistent",
-			wantErr:    true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			policy, err := GetPolicy(tt.policyName)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetPolicy() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
 
-			if !tt.wantErr && policy.MinLength != tt.wantMinLen {
-				t.Errorf("GetPolicy() MinLength = %d, want %d", policy.MinLength, tt.wantMinLen)
-			}
-		})
+func writePolicyFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
 	}
+	return path
 }
 
-func TestValidatePasswordAgainstPolicy(t *testing.T) {
-	basicPolicy, _ := GetPolicy("basic")
-	corporatePolicy, _ := GetPolicy("corporate")
+func TestRunPolicyCommandValidate(t *testing.T) {
+	valid := writePolicyFile(t, "valid.yaml", "name: Team\nmin_length: 14\nrequire_symbols: true\n")
+	invalid := writePolicyFile(t, "invalid.yaml", "min_length: 20\nmax_length: 12\nmin_digits: 8\nmin_symbols: 8\nmin_entropy: 100\n")
+	malformed := writePolicyFile(t, "malformed.yaml", "name: Typo\nmin_lenght: 14\n")
 
 	tests := []struct {
-		name           string
-		password       string
-		policy         PasswordPolicy
-		wantViolations int
+		name       string
+		paths      []string
+		wantStatus int
+		want       []string
 	}{
 		{
-			name:           "valid basic password",
-			password:       "MySecure1", // Changed to avoid 'password' pattern
-			policy:         basicPolicy,
-			wantViolations: 0,
-		},
-		{
-			name:           "too short",
-			password:       "Pass1",
-			policy:         basicPolicy,
-			wantViolations: 1,
+			name:       "valid",
+			paths:      []string{valid},
+			wantStatus: 0,
+			want:       []string{"valid.yaml: ✓ Team is valid"},
+		},
+		{
+			name:       "inconsistent",
+			paths:      []string{invalid},
+			wantStatus: 1,
+			want: []string{
+				"invalid.yaml: ✗ 4 problems:",
+				"  - policy has no name",
+				"  - minimum length 20 is more than the maximum length 12",
+				"  - minimum character counts add up to 16, more than the maximum length 12",
+				"  - minimum entropy of 100.0 bits is unreachable",
+			},
 		},
 		{
-			name:           "missing digits",
-			password:       "MySecure", // Changed to avoid 'password' pattern
-			policy:         basicPolicy,
-			wantViolations: 2, // RequireDigits and MinDigits
+			name:       "unknown key",
+			paths:      []string{malformed},
+			wantStatus: 1,
+			want:       []string{"malformed.yaml: invalid policy YAML:", "min_lenght"},
 		},
 		{
-			name:           "corporate policy violation",
-			password:       "weak123", // Simple password with multiple violations
-			policy:         corporatePolicy,
-			wantViolations: 7, // All the violations listed in test output
+			name:       "missing file",
+			paths:      []string{filepath.Join(t.TempDir(), "missing.yaml")},
+			wantStatus: 1,
+			want:       []string{"missing.yaml"},
 		},
 		{
-			name:           "valid corporate password",
-			password:       "MyC2rp2r@te!Secure", // Removed ambiguous chars and 'password' pattern
-			policy:         corporatePolicy,
-			wantViolations: 0,
+			name:       "every file is checked",
+			paths:      []string{invalid, valid},
+			wantStatus: 1,
+			want:       []string{"invalid.yaml: ✗", "valid.yaml: ✓"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			violations := ValidatePasswordAgainstPolicy(tt.password, tt.policy)
-			if len(violations) != tt.wantViolations {
-				t.Errorf("ValidatePasswordAgainstPolicy() violations = %d, want %d",
-					len(violations), tt.wantViolations)
-				for _, v := range violations {
-					t.Logf("  - %s: %s", v.Rule, v.Description)
+			var stdout, stderr bytes.Buffer
+			status := runPolicyCommand(&stdout, &stderr, append([]string{"validate"}, tt.paths...))
+			if status != tt.wantStatus {
+				t.Errorf("runPolicyCommand() = %d, want %d\n%s", status, tt.wantStatus, stdout.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("runPolicyCommand() output = %q, want it to contain %q", stdout.String(), want)
 				}
 			}
 		})
 	}
 }
 
-func TestValidatePasswordAgainstPolicyExtended(t *testing.T) {
-	policy := PasswordPolicy{
-		Name:              "Test Policy",
-		MinLength:         8,
-		MaxLength:         20,
-		RequireUpper:      true,
-		RequireLower:      true,
-		RequireDigits:     true,
-		RequireSymbols:    true,
-		MinUpper:          2,
-		MinLower:          2,
-		MinDigits:         1,
-		MinSymbols:        1,
-		ExcludeAmbiguous:  true,
-		ForbiddenChars:    "xyz",
-		ForbiddenPatterns: []string{"test"},
-		MinEntropy:        50,
-	}
-
-	tests := []struct {
-		name           string
-		password       string
-		wantViolations []string
-	}{
-		{
-			name:           "too long password",
-			password:       "ThisPasswordIsTooLongForThePolicy123!",
-			wantViolations: []string{"MaxLength"},
-		},
-		{
-			name:           "forbidden chars",
-			password:       "Password123x!",
-			wantViolations: []string{"ForbiddenChars"},
-		},
-		{
-			name:           "forbidden pattern",
-			password:       "TestPassword123!",
-			wantViolations: []string{"ForbiddenPatterns"},
-		},
-		{
-			name:           "multiple violations",
-			password:       "short",
-			wantViolations: []string{"MinLength", "RequireUpper", "RequireDigits", "RequireSymbols", "MinUpper", "MinDigits", "MinSymbols", "MinEntropy"},
-		},
-		{
-			name:           "min lower violation",
-			password:       "PASSWORD123!",
-			wantViolations: []string{"MinLower"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			violations := ValidatePasswordAgainstPolicy(tt.password, policy)
-
-			violationRules := make(map[string]bool)
-			for _, v := range violations {
-				violationRules[v.Rule] = true
-			}
-
-			for _, expectedRule := range tt.wantViolations {
-				if !violationRules[expectedRule] {
-					t.Errorf("Expected violation rule '%s' not found", expectedRule)
-				}
-			}
-		})
+func TestRunPolicyCommandUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"validate"}, {"lint", "policy.yaml"}} {
+		var stdout, stderr bytes.Buffer
+		if status := runPolicyCommand(&stdout, &stderr, args); status != 2 {
+			t.Errorf("runPolicyCommand(%q) = %d, want 2", args, status)
+		}
+		if !strings.Contains(stderr.String(), "policy validate") {
+			t.Errorf("runPolicyCommand(%q) stderr = %q, want usage", args, stderr.String())
+		}
 	}
 }
 
-func TestApplyPolicyToConfig(t *testing.T) {
-	basicPolicy, _ := GetPolicy("basic")
-
-	config := PasswordConfig{
-		Length:           6, // too short
-		IncludeUpper:     false,
-		IncludeLower:     true,
-		IncludeDigits:    false,
-		IncludeSymbols:   false,
-		ExcludeAmbiguous: false,
+func TestRegisterPolicyDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"team.yaml":    "name: Team\nmin_length: 20\n",
+		"basic.yml":    "name: Stricter basic\nmin_length: 10\n",
+		"invalid.yaml": "min_length: 20\nmax_length: 12\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	ApplyPolicyToConfig(basicPolicy, &config)
+	builtin := pwgen.BuiltinPolicies["basic"]
+	t.Cleanup(func() {
+		pwgen.BuiltinPolicies["basic"] = builtin
+		delete(pwgen.BuiltinPolicies, "team")
+	})
 
-	if config.Length < basicPolicy.MinLength {
-		t.Errorf("ApplyPolicyToConfig() length = %d, want >= %d", config.Length, basicPolicy.MinLength)
+	var stderr bytes.Buffer
+	if err := registerPolicyDir(&stderr, dir); err != nil {
+		t.Fatalf("registerPolicyDir() error = %v", err)
 	}
 
-	if !config.IncludeUpper {
-		t.Error("ApplyPolicyToConfig() should enable uppercase letters")
+	if policy, err := pwgen.GetPolicy("team"); err != nil || policy.MinLength != 20 {
+		t.Errorf("GetPolicy(team) = %+v, %v, want the policy from team.yaml", policy, err)
 	}
-
-	if !config.IncludeDigits {
-		t.Error("ApplyPolicyToConfig() should enable digits")
+	if policy, _ := pwgen.GetPolicy("basic"); policy.Name != "Stricter basic" {
+		t.Errorf("GetPolicy(basic) = %+v, want it replaced by basic.yml", policy)
 	}
-}
-
-func TestApplyPolicyToConfigExtended(t *testing.T) {
-	tests := []struct {
-		name           string
-		policy         PasswordPolicy
-		initialConfig  PasswordConfig
-		expectedConfig PasswordConfig
-	}{
-		{
-			name: "max length constraint",
-			policy: PasswordPolicy{
-				MinLength: 8,
-				MaxLength: 12,
-			},
-			initialConfig: PasswordConfig{
-				Length: 20, // too long
-			},
-			expectedConfig: PasswordConfig{
-				Length: 12,
-			},
-		},
-		{
-			name: "require symbols",
-			policy: PasswordPolicy{
-				RequireSymbols: true,
-			},
-			initialConfig: PasswordConfig{
-				IncludeSymbols: false,
-			},
-			expectedConfig: PasswordConfig{
-				IncludeSymbols: true,
-			},
-		},
-		{
-			name: "exclude ambiguous",
-			policy: PasswordPolicy{
-				ExcludeAmbiguous: true,
-			},
-			initialConfig: PasswordConfig{
-				ExcludeAmbiguous: false,
-			},
-			expectedConfig: PasswordConfig{
-				ExcludeAmbiguous: true,
-			},
-		},
-		{
-			name: "no changes needed",
-			policy: PasswordPolicy{
-				MinLength:        8,
-				RequireUpper:     false,
-				RequireLower:     false,
-				RequireDigits:    false,
-				RequireSymbols:   false,
-				ExcludeAmbiguous: false,
-			},
-			initialConfig: PasswordConfig{
-				Length:           10,
-				IncludeUpper:     true,
-				IncludeLower:     true,
-				IncludeDigits:    true,
-				IncludeSymbols:   true,
-				ExcludeAmbiguous: true,
-			},
-			expectedConfig: PasswordConfig{
-				Length:           10,
-				IncludeUpper:     true,
-				IncludeLower:     true,
-				IncludeDigits:    true,
-				IncludeSymbols:   true,
-				ExcludeAmbiguous: true,
-			},
-		},
-		{
-			name: "all requirements",
-			policy: PasswordPolicy{
-				MinLength:        16,
-				RequireUpper:     true,
-				RequireLower:     true,
-				RequireDigits:    true,
-				RequireSymbols:   true,
-				ExcludeAmbiguous: true,
-			},
-			initialConfig: PasswordConfig{
-				Length:           10,
-				IncludeUpper:     false,
-				IncludeLower:     false,
-				IncludeDigits:    false,
-				IncludeSymbols:   false,
-				ExcludeAmbiguous: false,
-			},
-			expectedConfig: PasswordConfig{
-				Length:           16,
-				IncludeUpper:     true,
-				IncludeLower:     true,
-				IncludeDigits:    true,
-				IncludeSymbols:   true,
-				ExcludeAmbiguous: true,
-			},
-		},
+	if _, err := pwgen.GetPolicy("invalid"); err == nil {
+		t.Error("GetPolicy(invalid) should fail, the file is inconsistent")
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			config := tt.initialConfig
-			ApplyPolicyToConfig(tt.policy, &config)
-
-			if config.Length != tt.expectedConfig.Length {
-				t.Errorf("ApplyPolicyToConfig() Length = %d, want %d", config.Length, tt.expectedConfig.Length)
-			}
-			if config.IncludeUpper != tt.expectedConfig.IncludeUpper {
-				t.Errorf("ApplyPolicyToConfig() IncludeUpper = %v, want %v", config.IncludeUpper, tt.expectedConfig.IncludeUpper)
-			}
-			if config.IncludeLower != tt.expectedConfig.IncludeLower {
-				t.Errorf("ApplyPolicyToConfig() IncludeLower = %v, want %v", config.IncludeLower, tt.expectedConfig.IncludeLower)
-			}
-			if config.IncludeDigits != tt.expectedConfig.IncludeDigits {
-				t.Errorf("ApplyPolicyToConfig() IncludeDigits = %v, want %v", config.IncludeDigits, tt.expectedConfig.IncludeDigits)
-			}
-			if config.IncludeSymbols != tt.expectedConfig.IncludeSymbols {
-				t.Errorf("ApplyPolicyToConfig() IncludeSymbols = %v, want %v", config.IncludeSymbols, tt.expectedConfig.IncludeSymbols)
-			}
-			if config.ExcludeAmbiguous != tt.expectedConfig.ExcludeAmbiguous {
-				t.Errorf("ApplyPolicyToConfig() ExcludeAmbiguous = %v, want %v", config.ExcludeAmbiguous, tt.expectedConfig.ExcludeAmbiguous)
-			}
-		})
+	for _, want := range []string{
+		"Warning: skipped " + filepath.Join(dir, "invalid.yaml") + ": policy has no name\n  minimum length 20",
+		"Warning: " + filepath.Join(dir, "basic.yml") + " replaces the basic policy",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("registerPolicyDir() wrote\n%s\nwant it to contain %q", stderr.String(), want)
+		}
 	}
 }
 
-func TestListPolicies(t *testing.T) {
-	policies := ListPolicies()
-
-	expectedPolicies := []string{"basic", "corporate", "high-security", "aws", "azure", "pci-dss"}
-
-	if len(policies) < len(expectedPolicies) {
-		t.Errorf("ListPolicies() returned %d policies, want at least %d", len(policies), len(expectedPolicies))
+func TestSavePolicyExample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := SavePolicyExample(path); err != nil {
+		t.Fatalf("SavePolicyExample() error = %v", err)
 	}
 
-	// Check if all expected policies are present
-	policyMap := make(map[string]bool)
-	for _, policy := range policies {
-		policyMap[policy] = true
+	// The example must load as is, so it passes every CheckPolicy check
+	if _, err := pwgen.LoadPolicyFile(path); err != nil {
+		t.Fatalf("LoadPolicyFile() error = %v", err)
 	}
 
-	for _, expected := range expectedPolicies {
-		if !policyMap[expected] {
-			t.Errorf("ListPolicies() missing expected policy: %s", expected)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policyType := reflect.TypeFor[pwgen.PasswordPolicy]()
+	for i := range policyType.NumField() {
+		key := policyType.Field(i).Tag.Get("yaml")
+		comment, ok := policyFieldComments[key]
+		if !ok {
+			t.Errorf("policyFieldComments has no comment for %s", key)
+			continue
+		}
+		if !strings.Contains(string(data), "# "+comment+"\n"+key+":") {
+			t.Errorf("SavePolicyExample() does not document %s", key)
 		}
 	}
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// defaultPolicyURLTimeout bounds a --policy-url request, from connecting
+// to reading the whole policy.
+const defaultPolicyURLTimeout = 10 * time.Second
+
+// maxPolicySize is the largest policy --policy-url reads. Policies are a
+// few hundred bytes, so anything near it is not a policy.
+const maxPolicySize = 1024 * 1024
+
+// policyURLOptions configures fetchPolicy.
+type policyURLOptions struct {
+	Timeout   time.Duration
+	CAFile    string // PEM certificates trusted on top of the system ones
+	CachePath string // Keeps the last policy fetched, for offline runs
+}
+
+// errPolicyUnreachable is wrapped by fetchPolicy errors where the server
+// could not be reached or did not send the whole policy, the failures a
+// cached policy stands in for.
+var errPolicyUnreachable = errors.New("could not fetch policy")
+
+// policyURLKey returns the name a --policy-url policy is selected by with
+// --policy: the last segment of its path without a .yaml or .yml
+// extension, e.g. "acme" for https://example.com/policies/acme.yaml.
+func policyURLKey(u *url.URL) string {
+	name := path.Base(u.Path)
+	if ext := path.Ext(name); ext == ".yaml" || ext == ".yml" {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name == "/" || name == "." {
+		return u.Hostname()
+	}
+	return name
+}
+
+// policyClient returns the HTTP client for --policy-url. Certificates are
+// always verified, against the system roots and those in opts.CAFile, and
+// redirects must stay on HTTPS.
+func policyClient(opts policyURLOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no PEM certificates found", opts.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s leaves HTTPS", req.URL.Redacted())
+			}
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}, nil
+}
+
+// downloadPolicy returns the body of a successful GET of policyURL.
+func downloadPolicy(client *http.Client, policyURL *url.URL) ([]byte, error) {
+	response, err := client.Get(policyURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errPolicyUnreachable, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("%s answered %s", policyURL.Redacted(), response.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxPolicySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errPolicyUnreachable, err)
+	}
+	if len(data) > maxPolicySize {
+		return nil, fmt.Errorf("%s sent more than %d bytes, too large for a policy", policyURL.Redacted(), maxPolicySize)
+	}
+	return data, nil
+}
+
+// parsePolicyData decodes and checks a policy like pwgen.LoadPolicyFile,
+// naming source in errors.
+func parsePolicyData(source string, data []byte) (pwgen.PasswordPolicy, error) {
+	policy, err := pwgen.ParsePolicy(data)
+	if err != nil {
+		return pwgen.PasswordPolicy{}, fmt.Errorf("%s: %w", source, err)
+	}
+	if problems := pwgen.CheckPolicy(policy); len(problems) > 0 {
+		return pwgen.PasswordPolicy{}, fmt.Errorf("%s: %w", source, errors.Join(problems...))
+	}
+	return policy, nil
+}
+
+// fetchPolicy downloads the policy at policyURL, which must use HTTPS, and
+// checks it like a policy file. A good policy is written to opts.CachePath
+// when set.
+func fetchPolicy(policyURL *url.URL, opts policyURLOptions) (pwgen.PasswordPolicy, error) {
+	if policyURL.Scheme != "https" {
+		return pwgen.PasswordPolicy{}, fmt.Errorf("%s: only https URLs are supported", policyURL.Redacted())
+	}
+
+	client, err := policyClient(opts)
+	if err != nil {
+		return pwgen.PasswordPolicy{}, err
+	}
+
+	data, err := downloadPolicy(client, policyURL)
+	if err != nil {
+		return pwgen.PasswordPolicy{}, err
+	}
+
+	policy, err := parsePolicyData(policyURL.Redacted(), data)
+	if err != nil {
+		return pwgen.PasswordPolicy{}, err
+	}
+
+	if opts.CachePath != "" {
+		if err := os.WriteFile(opts.CachePath, data, 0644); err != nil {
+			return pwgen.PasswordPolicy{}, fmt.Errorf("could not write policy cache: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// registerPolicyURL makes the policy at rawURL available to --policy and
+// --list-policies under policyURLKey. When the server cannot be reached,
+// the policy cached by an earlier run is used instead, with a warning on
+// stderr. A server that answers with anything but a 2xx status, or with a
+// bad policy, is an error either way, as the cache would hide a policy
+// withdrawn or broken on purpose.
+func registerPolicyURL(stderr io.Writer, rawURL string, opts policyURLOptions) error {
+	policyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	policy, err := fetchPolicy(policyURL, opts)
+	if errors.Is(err, errPolicyUnreachable) && opts.CachePath != "" {
+		cached, cacheErr := pwgen.LoadPolicyFile(opts.CachePath)
+		if cacheErr != nil {
+			return fmt.Errorf("%w; no usable cached policy: %w", err, cacheErr)
+		}
+		fmt.Fprintf(stderr, "Warning: %v; using the policy cached in %s\n", err, opts.CachePath)
+		policy, err = cached, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key := policyURLKey(policyURL)
+	if pwgen.RegisterPolicy(key, policy) {
+		fmt.Fprintf(stderr, "Warning: %s replaces the %s policy\n", policyURL.Redacted(), key)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+const remotePolicy = "name: Remote\nmin_length: 14\nrequire_symbols: true\n"
+
+// policyServer serves handler over TLS and returns its URL and a
+// policyURLOptions trusting its certificate.
+func policyServer(t *testing.T, handler http.HandlerFunc) (string, policyURLOptions) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected certificates are logged
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(pwgen.BuiltinPolicies, "remote") })
+
+	return server.URL + "/policies/remote.yaml", policyURLOptions{Timeout: 5 * time.Second, CAFile: caFile}
+}
+
+func TestRegisterPolicyURL(t *testing.T) {
+	policyURL, opts := policyServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(remotePolicy))
+	})
+	opts.CachePath = filepath.Join(t.TempDir(), "remote.yaml")
+
+	var stderr bytes.Buffer
+	if err := registerPolicyURL(&stderr, policyURL, opts); err != nil {
+		t.Fatalf("registerPolicyURL() error = %v", err)
+	}
+	policy, err := pwgen.GetPolicy("remote")
+	if err != nil || policy.Name != "Remote" || policy.MinLength != 14 {
+		t.Errorf("GetPolicy(\"remote\") = %+v, %v, want the fetched policy", policy, err)
+	}
+	if cached, err := os.ReadFile(opts.CachePath); err != nil || string(cached) != remotePolicy {
+		t.Errorf("cache holds %q, %v, want the fetched policy", cached, err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("registerPolicyURL() warned %q", stderr.String())
+	}
+}
+
+func TestRegisterPolicyURLCache(t *testing.T) {
+	// The server is gone, so the cached policy stands in for it
+	policyURL, opts := policyServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	opts.CachePath = filepath.Join(t.TempDir(), "remote.yaml")
+	if err := os.WriteFile(opts.CachePath, []byte(remotePolicy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unreachable, _ := url.Parse(policyURL)
+	unreachable.Host = "127.0.0.1:1"
+
+	var stderr bytes.Buffer
+	if err := registerPolicyURL(&stderr, unreachable.String(), opts); err != nil {
+		t.Fatalf("registerPolicyURL() error = %v", err)
+	}
+	if policy, err := pwgen.GetPolicy("remote"); err != nil || policy.Name != "Remote" {
+		t.Errorf("GetPolicy(\"remote\") = %+v, %v, want the cached policy", policy, err)
+	}
+	if !strings.Contains(stderr.String(), "using the policy cached in "+opts.CachePath) {
+		t.Errorf("registerPolicyURL() warned %q, want the cache named", stderr.String())
+	}
+
+	// Without a cache the same failure is an error
+	opts.CachePath = filepath.Join(t.TempDir(), "missing.yaml")
+	if err := registerPolicyURL(&stderr, unreachable.String(), opts); !errors.Is(err, errPolicyUnreachable) {
+		t.Errorf("registerPolicyURL() error = %v without a cache, want errPolicyUnreachable", err)
+	}
+}
+
+func TestRegisterPolicyURLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "gone", http.StatusNotFound)
+			},
+			want: "answered 404 Not Found",
+		},
+		{
+			name: "bad policy",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("name: Typo\nmin_lenght: 14\n"))
+			},
+			want: "field min_lenght not found",
+		},
+		{
+			name: "too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write(bytes.Repeat([]byte("#"), maxPolicySize+1))
+			},
+			want: "too large for a policy",
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(500 * time.Millisecond)
+				w.Write([]byte(remotePolicy))
+			},
+			want: "Timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policyURL, opts := policyServer(t, tt.handler)
+			opts.Timeout = 100 * time.Millisecond
+
+			// A cache never hides an answer from the server
+			opts.CachePath = filepath.Join(t.TempDir(), "remote.yaml")
+			if tt.name != "timeout" {
+				if err := os.WriteFile(opts.CachePath, []byte(remotePolicy), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := registerPolicyURL(&bytes.Buffer{}, policyURL, opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("registerPolicyURL() error = %v, want it to contain %q", err, tt.want)
+			}
+			if _, err := pwgen.GetPolicy("remote"); err == nil {
+				t.Error("registerPolicyURL() registered the policy despite the error")
+			}
+		})
+	}
+}
+
+func TestRegisterPolicyURLVerifiesTLS(t *testing.T) {
+	policyURL, opts := policyServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(remotePolicy))
+	})
+
+	// Without the test CA the self-signed certificate is rejected
+	opts.CAFile = ""
+	err := registerPolicyURL(&bytes.Buffer{}, policyURL, opts)
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("registerPolicyURL() error = %v, want the certificate rejected", err)
+	}
+
+	insecure := strings.Replace(policyURL, "https://", "http://", 1)
+	err = registerPolicyURL(&bytes.Buffer{}, insecure, opts)
+	if err == nil || !strings.Contains(err.Error(), "only https") {
+		t.Errorf("registerPolicyURL() error = %v for http, want it refused", err)
+	}
+}
+
+func TestPolicyURLKey(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/policies/acme.yaml": "acme",
+		"https://example.com/acme.yml?v=2":       "acme",
+		"https://example.com/api/policy":         "policy",
+		"https://example.com/":                   "example.com",
+	}
+
+	for rawURL, want := range tests {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := policyURLKey(u); got != want {
+			t.Errorf("policyURLKey(%s) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
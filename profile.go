@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Profile is a named bundle of settings selected with --profile. Settings
+// uses the config file keys, and is applied over the config files before
+// the environment and flags.
+type Profile struct {
+	Description string
+	Settings    map[string]any
+}
+
+// builtinProfiles are the profiles available without a config file. A
+// profile of the same name under "profiles" in a config file replaces one.
+var builtinProfiles = map[string]Profile{
+	"wifi": {
+		Description: "16 letters and digits without ambiguous characters, easy to type on a TV or phone",
+		Settings: map[string]any{
+			"length":            16,
+			"include_upper":     true,
+			"include_lower":     true,
+			"include_digits":    true,
+			"include_symbols":   false,
+			"exclude_ambiguous": true,
+		},
+	},
+	"db": {
+		Description: "32 characters of every type, with symbols safe in connection URLs",
+		Settings: map[string]any{
+			"length":          32,
+			"include_upper":   true,
+			"include_lower":   true,
+			"include_digits":  true,
+			"include_symbols": true,
+			"symbol_set":      "safe-url",
+		},
+	},
+	"pin": {
+		Description: "6-digit PIN without repeated digits or runs",
+		Settings: map[string]any{
+			"pin":                true,
+			"length":             6,
+			"forbid_trivial_pin": true,
+		},
+	},
+	"api-key": {
+		Description: "32 random bytes as URL-safe base64",
+		Settings: map[string]any{
+			"base64":     32,
+			"base64_url": true,
+		},
+	},
+}
+
+// apply sets the settings of p on config. An unknown key, a value of the
+// wrong type or an out-of-range value is an error, and leaves config
+// untouched.
+func (p Profile) apply(config *Config) error {
+	data, err := json.Marshal(p.Settings)
+	if err != nil {
+		return err
+	}
+
+	merged := *config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&merged); err != nil {
+		// Name a misspelled setting like a config file does
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("unknown key %s", field)
+		}
+		return err
+	}
+	if err := checkConfigRanges(merged); err != nil {
+		return err
+	}
+
+	*config = merged
+	return nil
+}
+
+// summary describes p for --list-profiles: its description, or its
+// settings when it has none.
+func (p Profile) summary() string {
+	if p.Description != "" {
+		return p.Description
+	}
+
+	settings := make([]string, 0, len(p.Settings))
+	for _, key := range slices.Sorted(maps.Keys(p.Settings)) {
+		settings = append(settings, fmt.Sprintf("%s=%v", key, p.Settings[key]))
+	}
+	return strings.Join(settings, ", ")
+}
+
+// parseProfiles converts the "profiles" map of a config file. An optional
+// "description" key in a profile is shown by --list-profiles. Each profile
+// is checked by applying it to base, so a typo is reported when the file is
+// loaded rather than when the profile is selected.
+func parseProfiles(raw map[string]map[string]any, base Config) (map[string]Profile, error) {
+	profiles := make(map[string]Profile, len(raw))
+	for name, settings := range raw {
+		profile := Profile{Settings: maps.Clone(settings)}
+		if description, ok := profile.Settings["description"]; ok {
+			profile.Description = fmt.Sprint(description)
+			delete(profile.Settings, "description")
+		}
+
+		check := base
+		if err := profile.apply(&check); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	for name, profile := range builtinProfiles {
+		config := DefaultConfig()
+		if err := profile.apply(&config); err != nil {
+			t.Errorf("profile %s: apply() error = %v", name, err)
+		}
+		if profile.Description == "" {
+			t.Errorf("profile %s has no description", name)
+		}
+	}
+
+	config := DefaultConfig()
+	config.IncludeSymbols = true
+	if err := builtinProfiles["wifi"].apply(&config); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if config.Length != 16 || config.IncludeSymbols || !config.ExcludeAmbiguous || !config.IncludeDigits {
+		t.Errorf("wifi profile gave %+v, want 16 characters without symbols or ambiguous characters", config)
+	}
+	if config.Count != DefaultConfig().Count {
+		t.Errorf("wifi profile Count = %d, settings it leaves out should keep their value", config.Count)
+	}
+}
+
+func TestLoadConfigProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "pwgen.yaml")
+	content := `count: 3
+profiles:
+  wifi:
+    length: 20
+  deploy:
+    description: Deploy keys
+    length: 40
+    include_symbols: false
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	config, profiles, err := LoadConfigFrom(path, "deploy")
+	if err != nil {
+		t.Fatalf("LoadConfigFrom() error = %v", err)
+	}
+	if config.Length != 40 || config.IncludeSymbols || config.Count != 3 {
+		t.Errorf("LoadConfigFrom() = %+v, want the deploy profile over the file", config)
+	}
+	if profiles["deploy"].summary() != "Deploy keys" {
+		t.Errorf("deploy summary = %q, want its description", profiles["deploy"].summary())
+	}
+	if _, ok := profiles["db"]; !ok {
+		t.Error("LoadConfigFrom() profiles should include the builtin ones")
+	}
+
+	// A file profile replaces the builtin of the same name
+	config, _, err = LoadConfigFrom(path, "wifi")
+	if err != nil {
+		t.Fatalf("LoadConfigFrom() error = %v", err)
+	}
+	if config.Length != 20 || config.ExcludeAmbiguous != DefaultConfig().ExcludeAmbiguous {
+		t.Errorf("LoadConfigFrom() Length = %d, want 20 from the file's wifi profile alone", config.Length)
+	}
+
+	// The environment still overrides the profile
+	t.Setenv("PWGEN_LENGTH", "12")
+	config, _, err = LoadConfigFrom(path, "deploy")
+	if err != nil || config.Length != 12 {
+		t.Errorf("LoadConfigFrom() Length = %d, error = %v, want 12 from PWGEN_LENGTH", config.Length, err)
+	}
+
+	_, _, err = LoadConfigFrom(path, "nope")
+	if err == nil || !strings.Contains(err.Error(), "unknown profile 'nope'") || !strings.Contains(err.Error(), "deploy") {
+		t.Errorf("LoadConfigFrom() error = %v, want the unknown profile and the available ones", err)
+	}
+}
+
+func TestLoadConfigProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"unknown key", "pwgen.yaml", "profiles:\n  x:\n    lenght: 20\n", `profile x: unknown key "lenght"`},
+		{"wrong type", "pwgen.yaml", "profiles:\n  x:\n    length: long\n", "profile x"},
+		{"out of range", "pwgen.json", `{"profiles": {"x": {"count": 0}}}`, "profile x: count"},
+		{"toml", "pwgen.toml", "[profiles.x]\nlenght = 20\n", `profile x: unknown key "lenght"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+
+			// A broken profile is reported even when it is not selected
+			_, _, err := LoadConfigFrom(path, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfigFrom() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"crypto/fips140"
+	"fmt"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// describeSource explains a random source for --rng-info, e.g.
+// "RNG: crypto/rand (operating system CSPRNG), FIPS 140-3 mode: off".
+func describeSource(source pwgen.RandomSource) string {
+	var detail string
+	switch source {
+	case pwgen.SourceCryptoRand:
+		detail = "operating system CSPRNG"
+	case pwgen.SourceCombined:
+		detail = "crypto/rand mixed with --extra-entropy by HKDF-SHA256"
+	case pwgen.SourceSeeded:
+		detail = "INSECURE deterministic ChaCha8 stream from --seed, for tests only"
+	default:
+		detail = "unknown reader"
+	}
+
+	fips := "off"
+	if fips140.Enabled() {
+		fips = "on"
+	}
+	return fmt.Sprintf("RNG: %s (%s), FIPS 140-3 mode: %s", source, detail, fips)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestDescribeSource(t *testing.T) {
+	tests := []struct {
+		source pwgen.RandomSource
+		want   string
+	}{
+		{pwgen.SourceCryptoRand, "RNG: crypto/rand (operating system CSPRNG)"},
+		{pwgen.SourceCombined, "RNG: combined (crypto/rand mixed with --extra-entropy"},
+		{pwgen.SourceSeeded, "RNG: seeded (INSECURE"},
+	}
+
+	for _, tt := range tests {
+		got := describeSource(tt.source)
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("describeSource(%v) = %q, want it to start with %q", tt.source, got, tt.want)
+		}
+		if !strings.Contains(got, "FIPS 140-3 mode: ") {
+			t.Errorf("describeSource(%v) = %q, want the FIPS 140-3 mode", tt.source, got)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// maxRequestBytes caps the JSON body of a serve request.
+const maxRequestBytes = 64 * 1024
+
+// shutdownTimeout is how long serve waits for requests in flight after
+// SIGINT or SIGTERM.
+const shutdownTimeout = 5 * time.Second
+
+// Timeouts of the serve connections, so a client trickling its request or
+// reading the response slowly cannot hold a connection open indefinitely.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 120 * time.Second
+)
+
+// serveLimits guards the serve endpoints against abusive requests.
+type serveLimits struct {
+	maxLength int // Longest password generated or validated
+	maxCount  int // Most passwords per /generate request
+}
+
+// runServeCommand runs "pwgen serve", an HTTP service exposing POST
+// /generate and POST /validate until SIGINT or SIGTERM, after which it
+// finishes the requests in flight. It returns the exit status.
+func runServeCommand(stderr io.Writer, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	maxLength := flags.Int("max-length", 256, "Reject requests for passwords longer than this")
+	maxCount := flags.Int("max-count", 100, "Reject requests for more passwords than this")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 || *maxLength < 1 || *maxCount < 1 {
+		fmt.Fprintf(stderr, "Usage: %s serve [--addr :8080] [--max-length 256] [--max-count 100]\n", os.Args[0])
+		return 2
+	}
+
+	server := newServer(*addr, serveLimits{maxLength: *maxLength, maxCount: *maxCount}, stderr)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+	fmt.Fprintf(stderr, "Listening on %s\n", *addr)
+
+	select {
+	case err := <-errs:
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintln(stderr, "Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newServer returns the serve HTTP server listening on addr, logging its
+// errors to stderr.
+func newServer(addr string, limits serveLimits, stderr io.Writer) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           newServeHandler(limits),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		ErrorLog:          log.New(stderr, "", log.LstdFlags),
+	}
+}
+
+func newServeHandler(limits serveLimits) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /generate", limits.generate)
+	mux.HandleFunc("POST /validate", limits.validate)
+	return mux
+}
+
+// generateRequest is the body of POST /generate: the config file keys of
+// random-character passwords, which are all it generates. Keys of the
+// other modes, such as passphrase or pin, are unknown and rejected rather
+// than ignored.
+type generateRequest struct {
+	Count            int     `json:"count"`
+	PolicyTemplate   string  `json:"policy_template"`
+	MinEntropy       float64 `json:"min_entropy"`
+	Weight           string  `json:"weight"`
+	Length           int     `json:"length"`
+	IncludeUpper     bool    `json:"include_upper"`
+	IncludeLower     bool    `json:"include_lower"`
+	IncludeDigits    bool    `json:"include_digits"`
+	IncludeSymbols   bool    `json:"include_symbols"`
+	IncludeSpace     bool    `json:"include_space"`
+	ExcludeAmbiguous bool    `json:"exclude_ambiguous"`
+	Charset          string  `json:"charset"`
+	ExcludeChars     string  `json:"exclude_chars"`
+	ASCIIOnly        bool    `json:"ascii_only"`
+	AllowControl     bool    `json:"allow_control"`
+	AmbiguousChars   string  `json:"ambiguous_chars"`
+	SymbolSet        string  `json:"symbol_set"`
+	MinUpper         int     `json:"min_upper"`
+	MinLower         int     `json:"min_lower"`
+	MinDigits        int     `json:"min_digits"`
+	MinSymbols       int     `json:"min_symbols"`
+	MaxUpper         int     `json:"max_upper"`
+	MaxLower         int     `json:"max_lower"`
+	MaxDigits        int     `json:"max_digits"`
+	MaxSymbols       int     `json:"max_symbols"`
+	MaxRepeat        int     `json:"max_repeat"`
+	MaxAttempts      int     `json:"max_attempts"`
+	MaxClassRun      int     `json:"max_class_run"`
+	MinDistinct      int     `json:"min_distinct"`
+	AvoidCommon      bool    `json:"avoid_common"`
+	AlternateClasses bool    `json:"alternate_classes"`
+	NoRepeat         bool    `json:"no_repeat"`
+}
+
+// newGenerateRequest returns a request holding the defaults of a config
+// file, for the body to be decoded over.
+func newGenerateRequest() generateRequest {
+	defaults := DefaultConfig()
+	return generateRequest{
+		Count:          1,
+		Length:         defaults.Length,
+		IncludeUpper:   defaults.IncludeUpper,
+		IncludeLower:   defaults.IncludeLower,
+		IncludeDigits:  defaults.IncludeDigits,
+		IncludeSymbols: defaults.IncludeSymbols,
+		MaxAttempts:    defaults.MaxAttempts,
+	}
+}
+
+// passwordConfig returns the generator settings of the request.
+func (r generateRequest) passwordConfig() pwgen.Config {
+	return pwgen.Config{
+		Length:           r.Length,
+		IncludeUpper:     r.IncludeUpper,
+		IncludeLower:     r.IncludeLower,
+		IncludeDigits:    r.IncludeDigits,
+		IncludeSymbols:   r.IncludeSymbols,
+		IncludeSpace:     r.IncludeSpace,
+		ExcludeAmbiguous: r.ExcludeAmbiguous,
+		Charset:          r.Charset,
+		ExcludeChars:     r.ExcludeChars,
+		ASCIIOnly:        r.ASCIIOnly,
+		AllowControl:     r.AllowControl,
+		AmbiguousChars:   r.AmbiguousChars,
+		SymbolSet:        r.SymbolSet,
+		MinUpper:         r.MinUpper,
+		MinLower:         r.MinLower,
+		MinDigits:        r.MinDigits,
+		MinSymbols:       r.MinSymbols,
+		MaxUpper:         r.MaxUpper,
+		MaxLower:         r.MaxLower,
+		MaxDigits:        r.MaxDigits,
+		MaxSymbols:       r.MaxSymbols,
+		MaxRepeat:        r.MaxRepeat,
+		MaxAttempts:      r.MaxAttempts,
+		MaxClassRun:      r.MaxClassRun,
+		MinDistinct:      r.MinDistinct,
+		AvoidCommon:      r.AvoidCommon,
+		AlternateClasses: r.AlternateClasses,
+		NoRepeat:         r.NoRepeat,
+	}
+}
+
+// generate answers POST /generate. The body holds a generateRequest,
+// applied over the defaults, and the response the passwords with their
+// strength as in --format json.
+func (l serveLimits) generate(w http.ResponseWriter, r *http.Request) {
+	request := newGenerateRequest()
+	if err := decodeRequest(w, r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.Count < 1 || request.Count > l.maxCount {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", l.maxCount))
+		return
+	}
+
+	// Clients may lower the retry limit, but never make the server spin longer
+	if request.MaxAttempts < 1 || request.MaxAttempts > pwgen.DefaultMaxAttempts {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("max_attempts must be between 1 and %d", pwgen.DefaultMaxAttempts))
+		return
+	}
+
+	config := request.passwordConfig()
+	if request.Weight != "" {
+		weights, err := pwgen.ParseClassWeights(request.Weight)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		config.ClassWeights = weights
+	}
+
+	var policy pwgen.PasswordPolicy
+	minEntropy := request.MinEntropy
+	if request.PolicyTemplate != "" {
+		var err error
+		policy, err = pwgen.MergePolicies(splitPolicyNames(request.PolicyTemplate))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		pwgen.ApplyPolicyToConfig(policy, &config)
+		minEntropy = max(minEntropy, policy.MinEntropy)
+	}
+
+	// Checked after the policy, which may raise the length
+	if config.Length > l.maxLength {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("length %d is more than the maximum of %d", config.Length, l.maxLength))
+		return
+	}
+
+	var opts pwgen.AnalysisOptions
+	if pwgen.HasExactCharset(config) {
+		opts.Charset = pwgen.Charset(config)
+	}
+	opts.AlternateClasses = config.AlternateClasses && pwgen.CanAlternateClasses(config)
+	opts.ClassWeights = config.ClassWeights
+	opts.NoRepeat = config.NoRepeat
+
+	response := struct {
+		Passwords []jsonPassword `json:"passwords"`
+	}{}
+	for i := range request.Count {
+		var password string
+		var err error
+		if minEntropy > 0 {
+			password, err = pwgen.GenerateWithMinEntropy(config, minEntropy)
+		} else {
+			password, err = pwgen.Generate(config)
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		strength := pwgen.AnalyzePasswordStrengthWithOptions(password, opts)
+		entry := jsonPassword{
+			Index:       i + 1,
+			Password:    password,
+			Level:       strength.Level.String(),
+			Score:       strength.Score,
+			Entropy:     strength.Entropy,
+			TimeToCrack: strength.TimeToCrack,
+		}
+		if request.PolicyTemplate != "" {
+			for _, violation := range pwgen.ValidatePasswordAgainstPolicy(password, policy) {
+				entry.Violations = append(entry.Violations, violation.Description)
+			}
+		}
+		response.Passwords = append(response.Passwords, entry)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// validateRequest is the body of POST /validate. Policy may list several
+// comma-separated policies, as --validate does.
+type validateRequest struct {
+	Password string `json:"password"`
+	Policy   string `json:"policy"`
+}
+
+type validateViolation struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+// validateResult is the verdict of one policy in a /validate response.
+type validateResult struct {
+	Valid      bool                `json:"valid"`
+	Violations []validateViolation `json:"violations"`
+}
+
+// validate answers POST /validate with the violations of each policy.
+func (l serveLimits) validate(w http.ResponseWriter, r *http.Request) {
+	var request validateRequest
+	if err := decodeRequest(w, r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch {
+	case request.Password == "":
+		writeError(w, http.StatusBadRequest, errors.New("password is required"))
+		return
+	case request.Policy == "":
+		writeError(w, http.StatusBadRequest, errors.New("policy is required"))
+		return
+	case len([]rune(request.Password)) > l.maxLength:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("password is longer than the maximum of %d characters", l.maxLength))
+		return
+	}
+
+	names := splitPolicyNames(request.Policy)
+	for _, name := range names {
+		if _, err := pwgen.GetPolicy(name); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	response := struct {
+		Valid    bool                      `json:"valid"`
+		Policies map[string]validateResult `json:"policies"`
+	}{Valid: true, Policies: map[string]validateResult{}}
+	for name, violations := range pwgen.ValidateAgainstPolicies(request.Password, names) {
+		result := validateResult{Valid: len(violations) == 0, Violations: []validateViolation{}}
+		for _, violation := range violations {
+			result.Violations = append(result.Violations, validateViolation{Rule: violation.Rule, Description: violation.Description})
+		}
+		response.Valid = response.Valid && result.Valid
+		response.Policies[name] = result
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// decodeRequest reads the JSON body of r into v, rejecting unknown keys
+// and bodies over maxRequestBytes.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON request: %w", err)
+	}
+	return nil
+}
+
+func splitPolicyNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeJSON writes v without escaping <, > and &, which passwords contain.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func serveRequest(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := newServeHandler(serveLimits{maxLength: 64, maxCount: 5})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func TestServeGenerate(t *testing.T) {
+	recorder := serveRequest(t, http.MethodPost, "/generate", `{"length": 20, "include_symbols": true, "count": 3, "policy_template": "corporate"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("POST /generate status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	var response struct {
+		Passwords []jsonPassword `json:"passwords"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON response: %v\n%s", err, recorder.Body)
+	}
+	if len(response.Passwords) != 3 {
+		t.Fatalf("got %d passwords, want 3", len(response.Passwords))
+	}
+	for i, entry := range response.Passwords {
+		if entry.Index != i+1 || utf8.RuneCountInString(entry.Password) != 20 || entry.Level == "" || entry.Entropy <= 0 {
+			t.Errorf("password %d = %+v, want 20 characters with a strength analysis", i+1, entry)
+		}
+		if len(entry.Violations) > 0 {
+			t.Errorf("password %d violates the corporate policy: %v", i+1, entry.Violations)
+		}
+	}
+	if strings.Contains(recorder.Body.String(), "entropy_model") {
+		t.Errorf("POST /generate response has an empty entropy_model: %s", recorder.Body)
+	}
+}
+
+func TestServeGenerateRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"too long", `{"length": 65}`, "length 65 is more than the maximum of 64"},
+		{"too many", `{"count": 6}`, "count must be between 1 and 5"},
+		{"too many attempts", `{"max_attempts": 1000000}`, "max_attempts must be between 1 and 1000"},
+		{"unsatisfiable", `{"length": 5, "charset": "aB", "min_upper": 5, "min_entropy": 5, "max_attempts": 3}`, "could not satisfy constraints after 3 attempts"},
+		{"unknown key", `{"lenght": 12}`, "invalid JSON request"},
+		{"passphrase", `{"passphrase": true}`, `unknown field "passphrase"`},
+		{"pin", `{"pin": true, "length": 6}`, `unknown field "pin"`},
+		{"pattern", `{"pattern": "Aaaa9999"}`, `unknown field "pattern"`},
+		{"analyzer", `{"analyzer": "builtin"}`, `unknown field "analyzer"`},
+		{"entropy penalty", `{"entropy_penalty_common": 0.5}`, `unknown field "entropy_penalty_common"`},
+		{"malformed", `{"length": `, "invalid JSON request"},
+		{"invalid config", `{"include_upper": false, "include_lower": false, "include_digits": false}`, "at least one character type"},
+		{"unknown policy", `{"policy_template": "nope"}`, "nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveRequest(t, http.MethodPost, "/generate", tt.body)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", recorder.Code)
+			}
+
+			var response map[string]string
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid JSON response: %v\n%s", err, recorder.Body)
+			}
+			if !strings.Contains(response["error"], tt.want) {
+				t.Errorf("error = %q, want it to contain %q", response["error"], tt.want)
+			}
+		})
+	}
+}
+
+func TestServeValidate(t *testing.T) {
+	recorder := serveRequest(t, http.MethodPost, "/validate", `{"password": "password", "policy": "basic, corporate"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("POST /validate status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	var response struct {
+		Valid    bool                      `json:"valid"`
+		Policies map[string]validateResult `json:"policies"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON response: %v\n%s", err, recorder.Body)
+	}
+	if response.Valid || len(response.Policies) != 2 {
+		t.Fatalf("response = %+v, want two failing policies", response)
+	}
+	for name, result := range response.Policies {
+		if result.Valid || len(result.Violations) == 0 || result.Violations[0].Rule == "" {
+			t.Errorf("%s result = %+v, want violations with their rule", name, result)
+		}
+	}
+
+	recorder = serveRequest(t, http.MethodPost, "/validate", `{"password": "Xk9#mP2@qR5!vL7z", "policy": "corporate"}`)
+	if !strings.Contains(recorder.Body.String(), `"valid":true`) {
+		t.Errorf("POST /validate of a compliant password = %s", recorder.Body)
+	}
+
+	recorder = serveRequest(t, http.MethodPost, "/validate", `{"password": "`+strings.Repeat("a", 65)+`", "policy": "basic"}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("POST /validate of a password over --max-length status = %d, want 400", recorder.Code)
+	}
+}
+
+func TestServeMethods(t *testing.T) {
+	if code := serveRequest(t, http.MethodGet, "/generate", "").Code; code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /generate status = %d, want 405", code)
+	}
+	if code := serveRequest(t, http.MethodPost, "/other", "{}").Code; code != http.StatusNotFound {
+		t.Errorf("POST /other status = %d, want 404", code)
+	}
+}
+
+func TestNewServerTimeouts(t *testing.T) {
+	server := newServer(":0", serveLimits{maxLength: 64, maxCount: 5}, io.Discard)
+	if server.ReadHeaderTimeout <= 0 || server.ReadTimeout <= 0 || server.WriteTimeout <= 0 || server.IdleTimeout <= 0 {
+		t.Errorf("newServer() timeouts = %v, %v, %v, %v, want all set", server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+}
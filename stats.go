@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// strengthLevels lists every strength level from weakest to strongest, the
+// order --stats reports them in.
+var strengthLevels = []pwgen.StrengthLevel{pwgen.VeryWeak, pwgen.Weak, pwgen.Fair, pwgen.Good, pwgen.Strong, pwgen.VeryStrong}
+
+// batchStats aggregates the strength of a generated batch for --stats.
+// PolicyPassed is only set with --policy.
+type batchStats struct {
+	Count        int            `json:"count"`
+	AverageScore float64        `json:"average_score"`
+	MinEntropy   float64        `json:"min_entropy"`
+	MaxEntropy   float64        `json:"max_entropy"`
+	Levels       map[string]int `json:"levels"`
+	PolicyPassed *int           `json:"policy_passed,omitempty"`
+
+	totalScore int
+}
+
+func newBatchStats(policy bool) *batchStats {
+	stats := &batchStats{Levels: map[string]int{}}
+	if policy {
+		stats.PolicyPassed = new(int)
+	}
+	return stats
+}
+
+// add counts one password of the batch, with its policy violations when
+// the batch is checked against a policy.
+func (s *batchStats) add(strength pwgen.PasswordStrength, violations []pwgen.PolicyViolation) {
+	if s.Count == 0 || strength.Entropy < s.MinEntropy {
+		s.MinEntropy = strength.Entropy
+	}
+	if s.Count == 0 || strength.Entropy > s.MaxEntropy {
+		s.MaxEntropy = strength.Entropy
+	}
+
+	s.Count++
+	s.totalScore += strength.Score
+	s.AverageScore = float64(s.totalScore) / float64(s.Count)
+	s.Levels[strength.Level.String()]++
+
+	if s.PolicyPassed != nil && len(violations) == 0 {
+		*s.PolicyPassed++
+	}
+}
+
+// String summarizes the batch on one line, listing only the levels that
+// occur, e.g. "Stats: 3 passwords, average score 71.7/100, entropy
+// 65.5-71.5 bits, levels: Good 2, Strong 1, policy passed: 3/3".
+func (s *batchStats) String() string {
+	if s.Count == 0 {
+		return "Stats: no passwords"
+	}
+
+	var levels []string
+	for _, level := range strengthLevels {
+		if n := s.Levels[level.String()]; n > 0 {
+			levels = append(levels, fmt.Sprintf("%s %d", level, n))
+		}
+	}
+
+	noun := "passwords"
+	if s.Count == 1 {
+		noun = "password"
+	}
+
+	line := fmt.Sprintf("Stats: %d %s, average score %.1f/100, entropy %.1f-%.1f bits, levels: %s",
+		s.Count, noun, s.AverageScore, s.MinEntropy, s.MaxEntropy, strings.Join(levels, ", "))
+	if s.PolicyPassed != nil {
+		line += fmt.Sprintf(", policy passed: %d/%d", *s.PolicyPassed, s.Count)
+	}
+	return line
+}
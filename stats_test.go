@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestBatchStats(t *testing.T) {
+	stats := newBatchStats(true)
+	stats.add(pwgen.PasswordStrength{Level: pwgen.Good, Score: 70, Entropy: 71.5}, nil)
+	stats.add(pwgen.PasswordStrength{Level: pwgen.Strong, Score: 80, Entropy: 65.5}, []pwgen.PolicyViolation{{Rule: "MinLength"}})
+	stats.add(pwgen.PasswordStrength{Level: pwgen.Good, Score: 65, Entropy: 68}, nil)
+
+	want := "Stats: 3 passwords, average score 71.7/100, entropy 65.5-71.5 bits, levels: Good 2, Strong 1, policy passed: 2/3"
+	if got := stats.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded["count"] != 3.0 || decoded["min_entropy"] != 65.5 || decoded["max_entropy"] != 71.5 || decoded["policy_passed"] != 2.0 {
+		t.Errorf("JSON stats = %s", data)
+	}
+	if levels, _ := decoded["levels"].(map[string]any); levels["Good"] != 2.0 || levels["Strong"] != 1.0 {
+		t.Errorf("JSON levels = %v, want Good 2 and Strong 1", decoded["levels"])
+	}
+}
+
+func TestBatchStatsWithoutPolicy(t *testing.T) {
+	stats := newBatchStats(false)
+	stats.add(pwgen.PasswordStrength{Level: pwgen.VeryStrong, Score: 95, Entropy: 78.7}, nil)
+
+	want := "Stats: 1 password, average score 95.0/100, entropy 78.7-78.7 bits, levels: Very Strong 1"
+	if got := stats.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, ok := decoded["policy_passed"]; ok {
+		t.Errorf("JSON stats = %s, want no policy_passed without a policy", data)
+	}
+}
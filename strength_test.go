@@ -1,466 +0,0 @@
-package main
-
-import (
-	"testing"
-)
-
-func TestAnalyzePasswordStrength(t *testing.T) {
-	tests := []struct {
-		name      string
-		password  string
-		wantLevel StrengthLevel
-		minScore  int
-		maxScore  int
-	}{
-		{
-			name:      "very weak password",
-			password:  "123",
-			wantLevel: VeryWeak,
-			minScore:  0,
-			maxScore:  20,
-		},
-		{
-			name:      "weak password",
-			password:  "simple123", // Weak but not as penalized
-			wantLevel: VeryWeak,    // Adjust expectation based on actual scoring
-			minScore:  0,
-			maxScore:  20,
-		},
-		{
-			name:      "fair password",
-			password:  "SecureKey123!", // Longer password for fair rating
-			wantLevel: Good,            // Adjust expectation based on actual scoring
-			minScore:  60,
-			maxScore:  80,
-		},
-		{
-			name:      "good password",
-			password:  "Rx7!kNm9@pQz", // Good strength without patterns
-			wantLevel: VeryStrong,     // Adjust expectation based on actual scoring
-			minScore:  90,
-			maxScore:  100,
-		},
-		{
-			name:      "strong password",
-			password:  "C0mpl3x!P@ssw0rd#2024",
-			wantLevel: Strong,
-			minScore:  80,
-			maxScore:  95,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			strength := AnalyzePasswordStrength(tt.password)
-
-			if strength.Level != tt.wantLevel {
-				t.Errorf("AnalyzePasswordStrength() level = %v, want %v", strength.Level, tt.wantLevel)
-			}
-
-			if strength.Score < tt.minScore || strength.Score > tt.maxScore {
-				t.Errorf("AnalyzePasswordStrength() score = %d, want between %d and %d",
-					strength.Score, tt.minScore, tt.maxScore)
-			}
-
-			if strength.Entropy <= 0 {
-				t.Errorf("AnalyzePasswordStrength() entropy should be > 0, got %f", strength.Entropy)
-			}
-		})
-	}
-}
-
-func TestStrengthLevelString(t *testing.T) {
-	tests := []struct {
-		level StrengthLevel
-		want  string
-	}{
-		{VeryWeak, "Very Weak"},
-		{Weak, "Weak"},
-		{Fair, "Fair"},
-		{Good, "Good"},
-		{Strong, "Strong"},
-		{VeryStrong, "Very Strong"},
-		{StrengthLevel(99), "Unknown"}, // Invalid level
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.want, func(t *testing.T) {
-			if got := tt.level.String(); got != tt.want {
-				t.Errorf("StrengthLevel.String() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestStrengthLevelColor(t *testing.T) {
-	tests := []struct {
-		level StrengthLevel
-		want  string
-	}{
-		{VeryWeak, "\033[91m"},
-		{Weak, "\033[91m"},
-		{Fair, "\033[93m"},
-		{Good, "\033[93m"},
-		{Strong, "\033[92m"},
-		{VeryStrong, "\033[92m"},
-		{StrengthLevel(99), "\033[0m"}, // Invalid level
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.level.String(), func(t *testing.T) {
-			if got := tt.level.Color(); got != tt.want {
-				t.Errorf("StrengthLevel.Color() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestGetStrengthLevel(t *testing.T) {
-	tests := []struct {
-		name  string
-		score int
-		want  StrengthLevel
-	}{
-		{"very weak", 10, VeryWeak},
-		{"weak", 30, Weak},
-		{"fair", 50, Fair},
-		{"good", 70, Good},
-		{"strong", 90, Strong},
-		{"very strong", 100, VeryStrong},
-		{"boundary very weak", 19, VeryWeak},
-		{"boundary weak", 20, Weak},
-		{"boundary fair", 40, Fair},
-		{"boundary good", 60, Good},
-		{"boundary strong", 80, Strong},
-		{"boundary very strong", 95, VeryStrong},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := getStrengthLevel(tt.score); got != tt.want {
-				t.Errorf("getStrengthLevel(%d) = %v, want %v", tt.score, got, tt.want)
-			}
-		})
-	}
-}
-
-func TestFormatDuration(t *testing.T) {
-	tests := []struct {
-		name    string
-		seconds float64
-		want    string
-	}{
-		{"instant", 0.5, "Instant"},
-		{"seconds", 30, "30 seconds"},
-		{"minutes", 120, "2 minutes"},
-		{"hours", 7200, "2 hours"},
-		{"days", 172800, "2 days"},
-		{"years", 63072000, "2 years"},
-		{"thousand years", 63072000000, "2 thousand years"},
-		{"million years", 63072000000000, "2 million years"},
-		{"billion years", 63072000000000000, "2 billion years"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := formatDuration(tt.seconds); got != tt.want {
-				t.Errorf("formatDuration(%f) = %v, want %v", tt.seconds, got, tt.want)
-			}
-		})
-	}
-}
-
-func TestCalculateEntropy(t *testing.T) {
-	tests := []struct {
-		name       string
-		password   string
-		minEntropy float64
-	}{
-		{
-			name:       "simple password",
-			password:   "abc",
-			minEntropy: 5.0,
-		},
-		{
-			name:       "mixed case",
-			password:   "AbC123",
-			minEntropy: 25.0, // Adjust expected entropy
-		},
-		{
-			name:       "complex password",
-			password:   "C0mpl3x!P@ss",
-			minEntropy: 60.0,
-		},
-		{
-			name:       "empty password",
-			password:   "",
-			minEntropy: 0.0,
-		},
-		{
-			name:       "single character",
-			password:   "a",
-			minEntropy: 0.0,
-		},
-		{
-			name:       "only uppercase",
-			password:   "ABCD",
-			minEntropy: 10.0,
-		},
-		{
-			name:       "only digits",
-			password:   "1234",
-			minEntropy: 9.0,
-		},
-		{
-			name:       "only symbols",
-			password:   "!@#$",
-			minEntropy: 10.0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			entropy := calculateEntropy(tt.password)
-			if entropy < tt.minEntropy {
-				t.Errorf("calculateEntropy() = %f, want >= %f", entropy, tt.minEntropy)
-			}
-		})
-	}
-}
-
-func TestAnalyzePasswordStrengthComprehensive(t *testing.T) {
-	tests := []struct {
-		name               string
-		password           string
-		expectedMinScore   int
-		expectedMaxScore   int
-		expectedLevel      StrengthLevel
-		shouldHaveFeedback bool
-	}{
-		{
-			name:               "very short password",
-			password:           "a",
-			expectedMinScore:   0,
-			expectedMaxScore:   10,
-			expectedLevel:      VeryWeak,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "no uppercase",
-			password:           "lowercase123!",
-			expectedMinScore:   20,
-			expectedMaxScore:   60,
-			expectedLevel:      Fair,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "no lowercase",
-			password:           "UPPERCASE123!",
-			expectedMinScore:   20,
-			expectedMaxScore:   60,
-			expectedLevel:      Fair,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "no digits",
-			password:           "NoDigitsHere!",
-			expectedMinScore:   60,
-			expectedMaxScore:   80,
-			expectedLevel:      Good,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "no symbols",
-			password:           "NoSymbolsHere123",
-			expectedMinScore:   60,
-			expectedMaxScore:   80,
-			expectedLevel:      Good,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "all character types",
-			password:           "AllTypes123!",
-			expectedMinScore:   50,
-			expectedMaxScore:   80,
-			expectedLevel:      Good,
-			shouldHaveFeedback: false,
-		},
-		{
-			name:               "high entropy password",
-			password:           "HighEntropyPasswordWith123!@#",
-			expectedMinScore:   50,
-			expectedMaxScore:   80,
-			expectedLevel:      Good,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "very low entropy",
-			password:           "aaa",
-			expectedMinScore:   0,
-			expectedMaxScore:   15,
-			expectedLevel:      VeryWeak,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "score over 100 capped",
-			password:           "VeryLongPasswordWithAllTypesAndHighEntropy123!@#$%^&*()",
-			expectedMinScore:   50,
-			expectedMaxScore:   80,
-			expectedLevel:      Good,
-			shouldHaveFeedback: true,
-		},
-		{
-			name:               "negative score capped",
-			password:           "bad",
-			expectedMinScore:   0,
-			expectedMaxScore:   15,
-			expectedLevel:      VeryWeak,
-			shouldHaveFeedback: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			strength := AnalyzePasswordStrength(tt.password)
-
-			if strength.Score < tt.expectedMinScore || strength.Score > tt.expectedMaxScore {
-				t.Errorf("AnalyzePasswordStrength() score = %d, want between %d and %d",
-					strength.Score, tt.expectedMinScore, tt.expectedMaxScore)
-			}
-
-			if strength.Level != tt.expectedLevel {
-				t.Errorf("AnalyzePasswordStrength() level = %v, want %v", strength.Level, tt.expectedLevel)
-			}
-
-			if tt.shouldHaveFeedback && len(strength.Feedback) == 0 {
-				t.Error("AnalyzePasswordStrength() should have feedback but got none")
-			}
-
-			if !tt.shouldHaveFeedback && len(strength.Feedback) > 1 {
-				// Allow one positive feedback message for strong passwords
-				if !(len(strength.Feedback) == 1 && strength.Score >= 80) {
-					t.Errorf("AnalyzePasswordStrength() should not have feedback but got: %v", strength.Feedback)
-				}
-			}
-		})
-	}
-}
-
-func TestAnalyzePasswordStrengthEdgeCases(t *testing.T) {
-	// Test case to hit the score > 100 capping logic
-	tests := []struct {
-		name     string
-		password string
-	}{
-		{
-			name:     "score capping test",
-			password: "VeryVeryVeryVeryLongPasswordWithExtremelyHighEntropyABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890!@#$%^&*()_+-=[]{}|;:,.<>?",
-		},
-		{
-			name:     "negative score test with many penalties",
-			password: "password123",
-		},
-		{
-			name:     "excellent feedback test",
-			password: "Ex3ll3ntP@ssw0rd!2024",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			strength := AnalyzePasswordStrength(tt.password)
-			// Just ensure we get valid results
-			if strength.Score < 0 || strength.Score > 100 {
-				t.Errorf("AnalyzePasswordStrength() score = %d, should be between 0-100", strength.Score)
-			}
-		})
-	}
-}
-
-func TestScoreOver100Capping(t *testing.T) {
-	// Create a password designed to score over 100 before capping
-	// Use completely random characters avoiding any patterns
-	// Very long (30pts) + all char types (45pts) + all types bonus (10pts) + high entropy (20pts) = 105pts
-	password := "mKj8!pQx3@nZr7#bYf9$cWh4%dXk6&wTv5*uGs2+iNq1?oLm0_sEa"
-
-	strength := AnalyzePasswordStrength(password)
-
-	// Log the actual score for debugging
-	t.Logf("Password score: %d, entropy: %.1f", strength.Score, strength.Entropy)
-	t.Logf("Feedback: %v", strength.Feedback)
-
-	// The score should be capped at 100 if it exceeds that
-	if strength.Score > 100 {
-		t.Errorf("Score should be capped at 100, got %d", strength.Score)
-	}
-}
-
-func TestHasRepeatedChars(t *testing.T) {
-	tests := []struct {
-		name     string
-		password string
-		want     bool
-	}{
-		{"no repeats", "abc123", false},
-		{"has repeats", "aaa123", true},
-		{"triple repeat", "password111", true},
-		{"double only", "password11", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := hasRepeatedChars(tt.password); got != tt.want {
-				t.Errorf("hasRepeatedChars() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestHasSequentialChars(t *testing.T) {
-	tests := []struct {
-		name     string
-		password string
-		want     bool
-	}{
-		{"no sequence", "P@ssw0rd", false},
-		{"alphabet sequence", "abc123", true},
-		{"number sequence", "test123", true},
-		{"keyboard sequence", "qwerty", true},
-		{"reverse sequence", "cba987", true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := hasSequentialChars(tt.password); got != tt.want {
-				t.Errorf("hasSequentialChars() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestHasCommonPatterns(t *testing.T) {
-	tests := []struct {
-		name     string
-		password string
-		want     bool
-	}{
-		{"no common patterns", "C0mpl3x!P@ss", false},
-		{"contains password", "mypassword123", true},
-		{"contains qwerty", "qwerty123", true},
-		{"with substitutions", "p@ssw0rd", true},
-		{"admin pattern", "admin123", true},
-		{"login pattern", "login456", true},
-		{"welcome pattern", "welcome789", true},
-		{"monkey pattern", "monkey123", true},
-		{"completely random", "xK9#mP2@qR5", false},
-		{"mixed case but no patterns", "MyS3cur3P@ss", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := hasCommonPatterns(tt.password); got != tt.want {
-				t.Errorf("hasCommonPatterns() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
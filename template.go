@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// defaultOutputTemplate renders the password and its strength the way pwgen
+// always has. With --hash the hash follows the password, or replaces it
+// without --with-password.
+const defaultOutputTemplate = `{{.Password}}{{with .Hash}}{{if $.Password}}{{"\t"}}{{end}}{{.}}{{end}}` +
+	` [{{level .Level}}, Score: {{.Score}}/100, Entropy: {{printf "%.1f" .Entropy}} bits{{entropyModel .EntropyModel}}, Time to crack: {{.TimeToCrack}}]`
+
+// outputLine is the data available to --output-template: every field of
+// the strength analysis, with Feedback in the --lang language, plus the
+// password, its position in the batch and its --policy violations.
+type outputLine struct {
+	pwgen.PasswordStrength
+	Index      int    // 1-based position in the batch
+	Password   string // As printed: masked with --mask, empty in clipboard mode or with --hash alone
+	Hash       string // The --hash of the password, if any
+	Violations []pwgen.PolicyViolation
+}
+
+// parseOutputTemplate parses text, or the file it names when it starts with
+// @, as the template for each password's strength line. Templates are run
+// once against sample data so a misspelled field fails here rather than
+// after generating. level prints a StrengthLevel, colored when color is
+// set, bar draws the score as a --bar strength bar, e.g. for
+// {{bar .Score .Level}}, join is strings.Join, e.g. for
+// {{join .Feedback "; "}}, and entropyModel labels an entropy model other
+// than the default.
+func parseOutputTemplate(text string, color bool) (*template.Template, error) {
+	if path, ok := strings.CutPrefix(text, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = strings.TrimSuffix(string(data), "\n")
+	}
+
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"level":        func(level pwgen.StrengthLevel) string { return formatLevel(level, color) },
+		"bar":          func(score int, level pwgen.StrengthLevel) string { return formatBar(score, level, color) },
+		"join":         strings.Join,
+		"entropyModel": entropyModelLabel,
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := outputLine{
+		PasswordStrength: pwgen.AnalyzePasswordStrength("sample"),
+		Index:            1,
+		Password:         "sample",
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// renderOutputLine executes tmpl for one password.
+func renderOutputLine(tmpl *template.Template, line outputLine) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, line); err != nil {
+		return "", fmt.Errorf("output template: %w", err)
+	}
+	return b.String(), nil
+}
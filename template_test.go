@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestDefaultOutputTemplate(t *testing.T) {
+	tmpl, err := parseOutputTemplate(defaultOutputTemplate, false)
+	if err != nil {
+		t.Fatalf("parseOutputTemplate() error = %v", err)
+	}
+
+	strength := pwgen.PasswordStrength{Level: pwgen.Good, Score: 70, Entropy: 71.54, TimeToCrack: "51 years"}
+	summary := " [Good, Score: 70/100, Entropy: 71.5 bits, Time to crack: 51 years]"
+
+	tests := []struct {
+		name           string
+		password, hash string
+		want           string
+	}{
+		{"password", "Xy7#kP", "", "Xy7#kP" + summary},
+		{"hash only", "", "$2a$04$abc", "$2a$04$abc" + summary},
+		{"password and hash", "Xy7#kP", "$2a$04$abc", "Xy7#kP\t$2a$04$abc" + summary},
+		{"clipboard", "", "", summary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderOutputLine(tmpl, outputLine{PasswordStrength: strength, Password: tt.password, Hash: tt.hash})
+			if err != nil {
+				t.Fatalf("renderOutputLine() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderOutputLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputTemplateFields(t *testing.T) {
+	tmpl, err := parseOutputTemplate(`{{.Index}} level={{.Level}} colored={{level .Level}} feedback={{join .Feedback "|"}} violations={{len .Violations}}`, true)
+	if err != nil {
+		t.Fatalf("parseOutputTemplate() error = %v", err)
+	}
+
+	line := outputLine{
+		PasswordStrength: pwgen.PasswordStrength{Level: pwgen.Weak, Feedback: []string{"Add symbols", "Use more characters"}},
+		Index:            3,
+		Violations:       []pwgen.PolicyViolation{{Rule: "MinLength"}},
+	}
+	got, err := renderOutputLine(tmpl, line)
+	if err != nil {
+		t.Fatalf("renderOutputLine() error = %v", err)
+	}
+
+	want := "3 level=Weak colored=" + pwgen.Weak.Color() + "Weak" + colorReset + " feedback=Add symbols|Use more characters violations=1"
+	if got != want {
+		t.Errorf("renderOutputLine() = %q, want %q", got, want)
+	}
+}
+
+func TestOutputTemplateBar(t *testing.T) {
+	tmpl, err := parseOutputTemplate(`{{.Password}} {{bar .Score .Level}}`, false)
+	if err != nil {
+		t.Fatalf("parseOutputTemplate() error = %v", err)
+	}
+
+	line := outputLine{PasswordStrength: pwgen.PasswordStrength{Level: pwgen.Good, Score: 70}, Password: "x"}
+	if got, err := renderOutputLine(tmpl, line); err != nil || got != "x ███████░░░ 70/100" {
+		t.Errorf("renderOutputLine() = %q, %v", got, err)
+	}
+}
+
+func TestParseOutputTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"syntax error", "{{.Score", "unclosed action"},
+		{"unknown field", "{{.Strength}}", "can't evaluate field Strength"},
+		{"unknown function", "{{upper .Password}}", `function "upper" not defined`},
+		{"missing file", "@" + filepath.Join(t.TempDir(), "missing.tmpl"), "no such file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOutputTemplate(tt.text, false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseOutputTemplate(%q) error = %v, want one mentioning %q", tt.text, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputTemplateFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "line.tmpl")
+	if err := os.WriteFile(path, []byte("score={{.Score}}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := parseOutputTemplate("@"+path, false)
+	if err != nil {
+		t.Fatalf("parseOutputTemplate() error = %v", err)
+	}
+
+	// The file's final newline is dropped, since each line already ends in one
+	got, err := renderOutputLine(tmpl, outputLine{PasswordStrength: pwgen.PasswordStrength{Score: 42}})
+	if err != nil {
+		t.Fatalf("renderOutputLine() error = %v", err)
+	}
+	if got != "score=42" {
+		t.Errorf("renderOutputLine() = %q, want %q", got, "score=42")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// uniqueSet is the set of passwords, or prefixes, a batch has used. It is
+// shared by the uniqueGenerator of every --jobs worker, so it is safe for
+// concurrent use.
+type uniqueSet struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newUniqueSet() *uniqueSet {
+	return &uniqueSet{seen: make(map[string]bool)}
+}
+
+// add records key and reports whether it was new.
+func (s *uniqueSet) add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[key] {
+		return false
+	}
+	s.seen[key] = true
+	return true
+}
+
+// size returns the number of keys recorded.
+func (s *uniqueSet) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
+
+// uniqueGenerator returns passwords from generate, regenerating any already
+// in seen so a batch never contains duplicates. With a prefix above zero
+// only the first prefix characters must differ, for --unique-prefix. It
+// gives up after attempts tries, pwgen.DefaultMaxAttempts when zero.
+type uniqueGenerator struct {
+	generate func() (string, error)
+	seen     *uniqueSet
+	prefix   int
+	attempts int
+}
+
+func newUniqueGenerator(generate func() (string, error), seen *uniqueSet, prefix, attempts int) *uniqueGenerator {
+	return &uniqueGenerator{generate: generate, seen: seen, prefix: prefix, attempts: attempts}
+}
+
+// key returns the part of password that must not repeat: its first
+// u.prefix characters, or all of it when it is shorter or prefix is zero.
+func (u *uniqueGenerator) key(password string) string {
+	if u.prefix > 0 {
+		if runes := []rune(password); len(runes) > u.prefix {
+			return string(runes[:u.prefix])
+		}
+	}
+	return password
+}
+
+func (u *uniqueGenerator) next() (string, error) {
+	for attempt := 0; attempt < attemptLimit(u.attempts); attempt++ {
+		password, err := u.generate()
+		if err != nil {
+			return "", err
+		}
+
+		if u.seen.add(u.key(password)) {
+			return password, nil
+		}
+	}
+
+	if u.prefix > 0 {
+		return "", fmt.Errorf("%w after %d attempts: only %d unique %d-character prefixes found, the charset allows too few distinct prefixes", pwgen.ErrMaxAttempts, attemptLimit(u.attempts), u.seen.size(), u.prefix)
+	}
+	return "", fmt.Errorf("%w after %d attempts: only %d unique passwords found, the length and charset allow too few distinct passwords", pwgen.ErrMaxAttempts, attemptLimit(u.attempts), u.seen.size())
+}
+
+// checkKeyspace fails when count exceeds the 2^bits distinct passwords a
+// generation mode can produce, or distinct prefixes of prefix characters
+// when prefix is above zero, so --unique errors before printing anything
+// rather than partway through the batch.
+func checkKeyspace(count int, bits float64, prefix int) error {
+	if bits >= 63 {
+		return nil
+	}
+
+	// Round away the error of bits computed with Log2, e.g. for PINs
+	if possible := math.Round(math.Exp2(bits)); float64(count) > possible {
+		if prefix > 0 {
+			return fmt.Errorf("cannot generate %d passwords with unique %d-character prefixes, only %.0f distinct prefixes are possible", count, prefix, possible)
+		}
+		return fmt.Errorf("cannot generate %d unique passwords, only %.0f distinct passwords are possible", count, possible)
+	}
+	return nil
+}
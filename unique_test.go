@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+func TestUniqueGenerator(t *testing.T) {
+	// "ab" at length 2 has exactly 4 passwords, so collisions are certain
+	config := pwgen.Config{Length: 2, Charset: "ab"}
+	generator := pwgen.NewSeededGenerator("unique")
+	unique := newUniqueGenerator(func() (string, error) {
+		return generator.Generate(config)
+	}, newUniqueSet(), 0, 0)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		password, err := unique.next()
+		if err != nil {
+			t.Fatalf("next() error = %v after %d passwords", err, i)
+		}
+		if seen[password] {
+			t.Fatalf("next() returned duplicate %q", password)
+		}
+		seen[password] = true
+	}
+
+	if _, err := unique.next(); !errors.Is(err, pwgen.ErrMaxAttempts) {
+		t.Errorf("next() error = %v once the keyspace is exhausted, want ErrMaxAttempts", err)
+	}
+}
+
+func TestUniqueGeneratorMaxAttempts(t *testing.T) {
+	calls := 0
+	unique := newUniqueGenerator(func() (string, error) {
+		calls++
+		return "same", nil
+	}, newUniqueSet(), 0, 5)
+
+	if _, err := unique.next(); err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+	calls = 0
+	_, err := unique.next()
+	if !errors.Is(err, pwgen.ErrMaxAttempts) || calls != 5 {
+		t.Errorf("next() error = %v after %d calls, want ErrMaxAttempts after 5", err, calls)
+	}
+	if err != nil && !strings.Contains(err.Error(), "after 5 attempts") {
+		t.Errorf("next() error = %q, want it to name the attempts", err)
+	}
+}
+
+func TestUniqueGeneratorPrefix(t *testing.T) {
+	// "ab" has exactly 4 prefixes of 2 characters, so collisions are
+	// certain well before the 64 passwords of length 6 run out
+	config := pwgen.Config{Length: 6, Charset: "ab"}
+	generator := pwgen.NewSeededGenerator("unique-prefix")
+	unique := newUniqueGenerator(func() (string, error) {
+		return generator.Generate(config)
+	}, newUniqueSet(), 2, 0)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		password, err := unique.next()
+		if err != nil {
+			t.Fatalf("next() error = %v after %d passwords", err, i)
+		}
+		if len(password) != 6 {
+			t.Fatalf("next() = %q, want the whole password", password)
+		}
+		if seen[password[:2]] {
+			t.Fatalf("next() returned %q, whose prefix was already used", password)
+		}
+		seen[password[:2]] = true
+	}
+
+	_, err := unique.next()
+	if !errors.Is(err, pwgen.ErrMaxAttempts) || !strings.Contains(err.Error(), "2-character prefixes") {
+		t.Errorf("next() error = %v once the prefixes are exhausted, want ErrMaxAttempts naming the prefix", err)
+	}
+
+	// A password no longer than the prefix is keyed on all of it
+	short := &uniqueGenerator{prefix: 8}
+	if key := short.key("abc"); key != "abc" {
+		t.Errorf("key(%q) = %q, want the whole password", "abc", key)
+	}
+	if key := (&uniqueGenerator{prefix: 2}).key("äöü"); key != "äö" {
+		t.Errorf("key(%q) = %q, want the first 2 characters", "äöü", key)
+	}
+}
+
+func TestCheckKeyspace(t *testing.T) {
+	tests := []struct {
+		count   int
+		bits    float64
+		prefix  int
+		wantErr bool
+	}{
+		{count: 4, bits: 2, wantErr: false},
+		{count: 5, bits: 2, wantErr: true},
+		{count: 10000, bits: 4 * math.Log2(10), wantErr: false}, // 4-digit PIN
+		{count: 10001, bits: 4 * math.Log2(10), wantErr: true},
+		{count: 1 << 30, bits: 128, wantErr: false},
+		{count: 5, bits: 2, prefix: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := checkKeyspace(tt.count, tt.bits, tt.prefix); (err != nil) != tt.wantErr {
+			t.Errorf("checkKeyspace(%d, %.2f, %d) error = %v, wantErr %v", tt.count, tt.bits, tt.prefix, err, tt.wantErr)
+		}
+	}
+
+	if err := checkKeyspace(5, 2, 1); err == nil || !strings.Contains(err.Error(), "distinct prefixes") {
+		t.Errorf("checkKeyspace() error = %v, want it to count prefixes", err)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+// validationResult is the policy verdict for the password on one line of
+// the input. Password is masked unless --show is given.
+type validationResult struct {
+	Line       int      `json:"line"`
+	Password   string   `json:"password"`
+	Passed     bool     `json:"passed"`
+	Violations []string `json:"violations"`
+}
+
+type validationSummary struct {
+	Total  int    `json:"total"`
+	Passed int    `json:"passed"`
+	Failed int    `json:"failed"`
+	Policy string `json:"policy"`
+}
+
+// validationWriter writes one result at a time, like analysisWriter.
+type validationWriter interface {
+	write(result validationResult) error
+	finish(summary validationSummary) error
+}
+
+// validatePasswords reads passwords from r, one per line, and writes
+// whether each meets policy to w in the given format, followed by a
+// summary. Blank lines are skipped but still counted, so line numbers match
+// the input. Passwords are masked with maskPassword unless show is set.
+func validatePasswords(r io.Reader, w io.Writer, format string, policy pwgen.PasswordPolicy, show bool) (validationSummary, error) {
+	var output validationWriter
+	switch format {
+	case "csv":
+		output = &csvValidationWriter{writer: csv.NewWriter(w)}
+	case "json":
+		output = &jsonValidationWriter{w: w}
+	default:
+		output = &textValidationWriter{w: w}
+	}
+
+	summary := validationSummary{Policy: policy.Name}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxAnalyzeLineLength)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		password := strings.TrimSuffix(scanner.Text(), "\r")
+		if password == "" {
+			continue
+		}
+
+		result := validationResult{Line: line, Password: password, Violations: []string{}}
+		if !show {
+			result.Password = maskPassword(password)
+		}
+		for _, violation := range pwgen.ValidatePasswordAgainstPolicy(password, policy) {
+			result.Violations = append(result.Violations, violation.Description)
+		}
+		result.Passed = len(result.Violations) == 0
+
+		summary.Total++
+		if result.Passed {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+
+		if err := output.write(result); err != nil {
+			return summary, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return summary, fmt.Errorf("line %d: %w", line+1, err)
+	}
+
+	return summary, output.finish(summary)
+}
+
+type textValidationWriter struct {
+	w      io.Writer
+	header bool
+}
+
+func (o *textValidationWriter) write(result validationResult) error {
+	if !o.header {
+		o.header = true
+		if _, err := fmt.Fprintf(o.w, "%6s  %-6s  %s\n", "Line", "Result", "Password"); err != nil {
+			return err
+		}
+	}
+
+	verdict := "PASS"
+	if !result.Passed {
+		verdict = "FAIL"
+	}
+	if _, err := fmt.Fprintf(o.w, "%6d  %-6s  %s\n", result.Line, verdict, result.Password); err != nil {
+		return err
+	}
+	for _, violation := range result.Violations {
+		if _, err := fmt.Fprintf(o.w, "%16s- %s\n", "", violation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *textValidationWriter) finish(summary validationSummary) error {
+	if summary.Total == 0 {
+		_, err := fmt.Fprintln(o.w, "No passwords to validate")
+		return err
+	}
+
+	_, err := fmt.Fprintf(o.w, "\nValidated %d passwords against %s: %d passed, %d failed (%.0f%%)\n",
+		summary.Total,
+		summary.Policy,
+		summary.Passed,
+		summary.Failed,
+		100*float64(summary.Failed)/float64(summary.Total),
+	)
+	return err
+}
+
+type csvValidationWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+// write joins the violations of a password into one field, and leaves the
+// summary out so the output stays one row per password. Passwords are
+// escaped with csvText, masked ones included, as they keep their first
+// character.
+func (o *csvValidationWriter) write(result validationResult) error {
+	if !o.header {
+		o.header = true
+		if err := o.writer.Write([]string{"line", "password", "passed", "violations"}); err != nil {
+			return err
+		}
+	}
+
+	return o.writer.Write([]string{
+		strconv.Itoa(result.Line),
+		csvText(result.Password),
+		strconv.FormatBool(result.Passed),
+		strings.Join(result.Violations, "; "),
+	})
+}
+
+func (o *csvValidationWriter) finish(validationSummary) error {
+	o.writer.Flush()
+	return o.writer.Error()
+}
+
+// jsonValidationWriter writes {"results": [...], "summary": {...}}, like
+// jsonAnalysisWriter.
+type jsonValidationWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (o *jsonValidationWriter) write(result validationResult) error {
+	prefix := ",\n    "
+	if o.count == 0 {
+		prefix = "{\n  \"results\": [\n    "
+	}
+	o.count++
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(o.w, "%s%s", prefix, data)
+	return err
+}
+
+func (o *jsonValidationWriter) finish(summary validationSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	if o.count == 0 {
+		_, err = fmt.Fprintf(o.w, "{\n  \"results\": [],\n  \"summary\": %s\n}\n", data)
+	} else {
+		_, err = fmt.Fprintf(o.w, "\n  ],\n  \"summary\": %s\n}\n", data)
+	}
+	return err
+}
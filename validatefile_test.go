@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/romdj/password-generator/pkg/pwgen"
+)
+
+const validateInput = "password\nKx7!mQ#z8305Lp\n\nabababab\r\n"
+
+var validatePolicy = pwgen.PasswordPolicy{Name: "Audit", MinLength: 8, RequireDigits: true, MinDistinctChars: 6}
+
+func TestValidatePasswordsSummary(t *testing.T) {
+	var buf bytes.Buffer
+	summary, err := validatePasswords(strings.NewReader(validateInput), &buf, "text", validatePolicy, false)
+	if err != nil {
+		t.Fatalf("validatePasswords() error = %v", err)
+	}
+
+	if summary.Total != 3 || summary.Passed != 1 || summary.Failed != 2 {
+		t.Errorf("validatePasswords() = %+v, want 3 passwords with 1 passed (blank lines skipped)", summary)
+	}
+
+	output := buf.String()
+	if strings.Contains(output, "Kx7!mQ") || strings.Contains(output, "ababab") {
+		t.Errorf("validatePasswords() output should mask passwords:\n%s", output)
+	}
+	for _, want := range []string{
+		"     4  FAIL    a******b\n",
+		"- Password must contain at least 6 different characters, found 2\n",
+		"Validated 3 passwords against Audit: 1 passed, 2 failed (67%)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("validatePasswords() output is missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestValidatePasswordsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := validatePasswords(strings.NewReader(validateInput), &buf, "json", validatePolicy, true); err != nil {
+		t.Fatalf("validatePasswords() error = %v", err)
+	}
+
+	var report struct {
+		Results []validationResult `json:"results"`
+		Summary validationSummary  `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("validatePasswords() produced invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if len(report.Results) != 3 {
+		t.Fatalf("validatePasswords() wrote %d results, want 3", len(report.Results))
+	}
+	// --show prints the passwords as they are, without the \r
+	passed := report.Results[1]
+	if passed.Line != 2 || passed.Password != "Kx7!mQ#z8305Lp" || !passed.Passed || passed.Violations == nil {
+		t.Errorf("validatePasswords() result = %+v, want line 2 passed with an empty violation list", passed)
+	}
+	failed := report.Results[2]
+	if failed.Line != 4 || failed.Password != "abababab" || failed.Passed || len(failed.Violations) != 2 {
+		t.Errorf("validatePasswords() result = %+v, want line 4 failed on digits and distinct characters", failed)
+	}
+	if report.Summary.Policy != "Audit" || report.Summary.Failed != 2 {
+		t.Errorf("validatePasswords() summary = %+v", report.Summary)
+	}
+
+	buf.Reset()
+	if _, err := validatePasswords(strings.NewReader(""), &buf, "json", validatePolicy, false); err != nil {
+		t.Fatalf("validatePasswords() error = %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Errorf("validatePasswords() produced invalid JSON for empty input: %v\n%s", err, buf.String())
+	}
+}
+
+func TestValidatePasswordsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := validatePasswords(strings.NewReader(validateInput), &buf, "csv", validatePolicy, false); err != nil {
+		t.Fatalf("validatePasswords() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("validatePasswords() produced invalid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("validatePasswords() wrote %d CSV rows, want a header and 3 rows", len(records))
+	}
+	if strings.Join(records[0], ",") != "line,password,passed,violations" {
+		t.Errorf("validatePasswords() header = %v", records[0])
+	}
+	want := []string{"4", "a******b", "false", "Password must contain at least one digit; Password must contain at least 6 different characters, found 2"}
+	if strings.Join(records[3], "|") != strings.Join(want, "|") {
+		t.Errorf("validatePasswords() row = %q, want %q", records[3], want)
+	}
+
+	// A password a spreadsheet would run as a formula is escaped
+	buf.Reset()
+	if _, err := validatePasswords(strings.NewReader("=HYPERLINK(1)\n"), &buf, "csv", validatePolicy, true); err != nil {
+		t.Fatalf("validatePasswords() error = %v", err)
+	}
+	if records, err := csv.NewReader(&buf).ReadAll(); err != nil || len(records) != 2 || records[1][1] != "'=HYPERLINK(1)" {
+		t.Errorf("validatePasswords() rows = %q, %v, want the password escaped", records, err)
+	}
+}
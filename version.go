@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version, CommitSHA and BuildDate describe the build for --version. Release
+// builds set them with
+// -ldflags "-X main.Version=v1.2.0 -X main.CommitSHA=abc1234 -X main.BuildDate=2026-01-02T15:04:05Z".
+var (
+	Version   = "dev"
+	CommitSHA = ""
+	BuildDate = ""
+)
+
+// buildInfo is the build metadata --version prints.
+type buildInfo struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+	Platform  string
+}
+
+// currentBuild returns the metadata of the running binary. A version or
+// commit the ldflags leave unset is taken from the module version of go
+// install or the VCS stamp go build embeds when building in a git checkout.
+func currentBuild() buildInfo {
+	info := buildInfo{
+		Version:   Version,
+		Commit:    CommitSHA,
+		Date:      BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if embedded, ok := debug.ReadBuildInfo(); ok {
+		fillBuildInfo(&info, embedded)
+	}
+	return info
+}
+
+// fillBuildInfo sets the version and commit of info, when still at their
+// defaults, from the build information embedded in the binary. A commit
+// built with uncommitted changes is marked "-dirty".
+func fillBuildInfo(info *buildInfo, embedded *debug.BuildInfo) {
+	if info.Version == "dev" && embedded.Main.Version != "" && embedded.Main.Version != "(devel)" {
+		info.Version = embedded.Main.Version
+	}
+
+	settings := make(map[string]string)
+	for _, setting := range embedded.Settings {
+		settings[setting.Key] = setting.Value
+	}
+
+	if info.Commit == "" && settings["vcs.revision"] != "" {
+		info.Commit = settings["vcs.revision"]
+		if len(info.Commit) > 7 {
+			info.Commit = info.Commit[:7]
+		}
+		if settings["vcs.modified"] == "true" {
+			info.Commit += "-dirty"
+		}
+	}
+}
+
+// String formats info as the line --version prints, e.g.
+// "pwgen v1.2.0 (commit abc1234, built 2026-01-02T15:04:05Z, go1.25.0 linux/amd64)".
+func (info buildInfo) String() string {
+	commit, date := info.Commit, info.Date
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("pwgen %s (commit %s, built %s, %s %s)", info.Version, commit, date, info.GoVersion, info.Platform)
+}
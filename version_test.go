@@ -0,0 +1,50 @@
+package main
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestFillBuildInfo(t *testing.T) {
+	embedded := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info := buildInfo{Version: "dev"}
+	fillBuildInfo(&info, embedded)
+	if info.Version != "v1.4.0" || info.Commit != "0123456-dirty" {
+		t.Errorf("fillBuildInfo() = %+v, want the module version and short dirty commit", info)
+	}
+
+	// Values set with -ldflags win
+	info = buildInfo{Version: "v2.0.0", Commit: "abc1234"}
+	fillBuildInfo(&info, embedded)
+	if info.Version != "v2.0.0" || info.Commit != "abc1234" {
+		t.Errorf("fillBuildInfo() = %+v, want the ldflags values kept", info)
+	}
+
+	// A plain go build in a checkout reports "(devel)"
+	info = buildInfo{Version: "dev"}
+	fillBuildInfo(&info, &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+	if info.Version != "dev" || info.Commit != "" {
+		t.Errorf("fillBuildInfo() = %+v, want nothing filled in", info)
+	}
+}
+
+func TestBuildInfoString(t *testing.T) {
+	info := buildInfo{Version: "v1.2.0", Commit: "abc1234", Date: "2026-01-02T15:04:05Z", GoVersion: "go1.25.0", Platform: "linux/amd64"}
+	want := "pwgen v1.2.0 (commit abc1234, built 2026-01-02T15:04:05Z, go1.25.0 linux/amd64)"
+	if got := info.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	info = buildInfo{Version: "dev", GoVersion: "go1.25.0", Platform: "linux/amd64"}
+	want = "pwgen dev (commit unknown, built unknown, go1.25.0 linux/amd64)"
+	if got := info.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}